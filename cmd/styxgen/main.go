@@ -0,0 +1,271 @@
+// Command styxgen generates typed column descriptors from the db tags of
+// struct types, for use with isql.Query:
+//
+//	//go:generate go run github.com/masudur-rahman/styx/cmd/styxgen -type User,Post
+//
+// For every selected type T it writes a TCols variable whose fields are
+// isql.Column values named after T's fields, so a renamed or misspelled
+// column fails to compile instead of failing at runtime.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+const defaultOutput = "styx_columns.go"
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated struct types to generate (default: every struct with a db tag)")
+	output := flag.String("output", defaultOutput, "output file name, relative to the package directory")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+
+	if err := run(dir, *output, types); err != nil {
+		fmt.Fprintln(os.Stderr, "styxgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, output string, types []string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no Go files in %s", dir)
+	}
+
+	src, err := generate(files, types)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, output), src, 0o644)
+}
+
+type column struct {
+	field string
+	name  string
+	typ   ast.Expr
+}
+
+type model struct {
+	name    string
+	columns []column
+}
+
+// generate renders the column descriptors for the selected struct types of
+// a single package. Without typeNames, every struct that has a db tag is used.
+func generate(files []*ast.File, typeNames []string) ([]byte, error) {
+	wanted := make(map[string]bool, len(typeNames))
+	for _, t := range typeNames {
+		wanted[strings.TrimSpace(t)] = true
+	}
+	found := make(map[string]bool, len(typeNames))
+
+	var models []model
+	imports := map[string]string{} // import path -> name used in the source
+	for _, file := range files {
+		fileImports := importNames(file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || ts.TypeParams != nil {
+					continue
+				}
+				if len(wanted) > 0 && !wanted[ts.Name.Name] {
+					continue
+				}
+				if len(wanted) == 0 && !hasDBTag(st) {
+					continue
+				}
+				found[ts.Name.Name] = true
+
+				m := model{name: ts.Name.Name, columns: structColumns(st)}
+				for _, c := range m.columns {
+					collectImports(c.typ, fileImports, imports)
+				}
+				models = append(models, m)
+			}
+		}
+	}
+	var missing []string
+	for name := range wanted {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("struct types not found: %s", strings.Join(missing, ", "))
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no struct types with db tags found")
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].name < models[j].name })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by styxgen. DO NOT EDIT.\n\npackage %s\n\n", files[0].Name.Name)
+	buf.WriteString("import (\n")
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if name := imports[path]; name != defaultImportName(path) {
+			fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+	}
+	if len(paths) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\tisql \"github.com/masudur-rahman/styx/sql\"\n)\n")
+
+	for _, m := range models {
+		fmt.Fprintf(&buf, "\n// %sCols holds typed column references for %s.\n", m.name, m.name)
+		fmt.Fprintf(&buf, "var %sCols = struct {\n", m.name)
+		for _, c := range m.columns {
+			fmt.Fprintf(&buf, "\t%s isql.Column[%s]\n", c.field, types.ExprString(c.typ))
+		}
+		buf.WriteString("}{\n")
+		for _, c := range m.columns {
+			fmt.Fprintf(&buf, "\t%s: isql.Col[%s](%q),\n", c.field, types.ExprString(c.typ), c.name)
+		}
+		buf.WriteString("}\n")
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// structColumns mirrors isql.GetDBFieldMap: every exported field is a
+// column named by its db tag, or by the field name, in snake case.
+func structColumns(st *ast.StructType) []column {
+	var cols []column
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			continue
+		}
+		tagName := ""
+		if tag := dbTag(f); tag != "" {
+			tagName = strings.Split(tag, ",")[0]
+		}
+		if tagName == "-" {
+			continue
+		}
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			name := tagName
+			if name == "" {
+				name = ident.Name
+			}
+			cols = append(cols, column{
+				field: ident.Name,
+				name:  strcase.ToSnake(name),
+				typ:   f.Type,
+			})
+		}
+	}
+	return cols
+}
+
+func hasDBTag(st *ast.StructType) bool {
+	for _, f := range st.Fields.List {
+		if dbTag(f) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func dbTag(f *ast.Field) string {
+	if f.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag).Get("db")
+}
+
+// importNames maps the names a file refers to its imports by to their paths.
+func importNames(file *ast.File) map[string]string {
+	names := make(map[string]string, len(file.Imports))
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := defaultImportName(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		names[name] = path
+	}
+	return names
+}
+
+// collectImports records the imports a field type refers to, such as time
+// for time.Time, so the generated file can name the same types.
+func collectImports(expr ast.Expr, fileImports, imports map[string]string) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			if path, ok := fileImports[pkg.Name]; ok {
+				imports[path] = pkg.Name
+			}
+		}
+		return false
+	})
+}
+
+func defaultImportName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
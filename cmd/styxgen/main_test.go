@@ -0,0 +1,88 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const modelsSrc = `package models
+
+import (
+	"encoding/json"
+	stdtime "time"
+)
+
+type User struct {
+	ID        int64            ` + "`db:\"id,pk autoincr\"`" + `
+	Email     string           ` + "`db:\"email,uq\"`" + `
+	FullName  string
+	CreatedAt stdtime.Time     ` + "`db:\"created_at\"`" + `
+	DeletedAt *stdtime.Time    ` + "`db:\",soft_delete\"`" + `
+	Skipped   string           ` + "`db:\"-\"`" + `
+	secret    string
+}
+
+type Post struct {
+	ID      int64           ` + "`db:\"id,pk\"`" + `
+	Payload json.RawMessage ` + "`db:\"payload\"`" + `
+}
+
+type options struct {
+	Verbose bool
+}
+`
+
+func parse(t *testing.T) []*ast.File {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "models.go", modelsSrc, 0)
+	assert.NoError(t, err)
+	return []*ast.File{file}
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := generate(parse(t), []string{"User"})
+	assert.NoError(t, err)
+	assert.Equal(t, `// Code generated by styxgen. DO NOT EDIT.
+
+package models
+
+import (
+	stdtime "time"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// UserCols holds typed column references for User.
+var UserCols = struct {
+	ID        isql.Column[int64]
+	Email     isql.Column[string]
+	FullName  isql.Column[string]
+	CreatedAt isql.Column[stdtime.Time]
+	DeletedAt isql.Column[*stdtime.Time]
+}{
+	ID:        isql.Col[int64]("id"),
+	Email:     isql.Col[string]("email"),
+	FullName:  isql.Col[string]("full_name"),
+	CreatedAt: isql.Col[stdtime.Time]("created_at"),
+	DeletedAt: isql.Col[*stdtime.Time]("deleted_at"),
+}
+`, string(src))
+}
+
+func TestGenerate_allTaggedStructs(t *testing.T) {
+	src, err := generate(parse(t), nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "\"encoding/json\"")
+	assert.Contains(t, string(src), "var PostCols = struct")
+	assert.Contains(t, string(src), "var UserCols = struct")
+	assert.NotContains(t, string(src), "optionsCols", "structs without db tags are skipped")
+}
+
+func TestGenerate_unknownType(t *testing.T) {
+	_, err := generate(parse(t), []string{"User", "Comment"})
+	assert.EqualError(t, err, "struct types not found: Comment")
+}
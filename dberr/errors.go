@@ -54,8 +54,46 @@ var (
 
 	// ErrValidationFailed is returned when validation rules are not satisfied.
 	ErrValidationFailed = errors.New("styx: validation failed")
+
+	// ErrNotSupported is returned when an operation is not available on the current engine.
+	ErrNotSupported = errors.New("styx: operation not supported")
+
+	// ErrForeignKeyViolation is returned when a write references a missing row or removes a referenced one.
+	ErrForeignKeyViolation = errors.New("styx: foreign key violation")
+
+	// ErrNotNullViolation is returned when a NOT NULL column is written without a value.
+	ErrNotNullViolation = errors.New("styx: not null violation")
+
+	// ErrCheckViolation is returned when a CHECK constraint is not satisfied.
+	ErrCheckViolation = errors.New("styx: check constraint violation")
+
+	// ErrDeadlock is returned when the database aborts a transaction to break a deadlock.
+	ErrDeadlock = errors.New("styx: deadlock detected")
+
+	// ErrSerializationFailure is returned when a transaction cannot be serialized with concurrent ones.
+	ErrSerializationFailure = errors.New("styx: serialization failure")
 )
 
+// DBError is a driver error translated by an engine. Err is the dberr
+// sentinel it maps to and Cause the original driver error; errors.Is and
+// errors.As match both. Constraint, Table and Column are filled in as far as
+// the driver reports them.
+type DBError struct {
+	Err        error
+	Constraint string
+	Table      string
+	Column     string
+	Cause      error
+}
+
+func (e *DBError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Err, e.Cause)
+}
+
+func (e *DBError) Unwrap() []error {
+	return []error{e.Err, e.Cause}
+}
+
 // ValidationError represents a collection of validation errors.
 type ValidationError struct {
 	FieldErrors map[string][]string
@@ -87,6 +87,12 @@ func TestSentinelErrorsAreDistinct(t *testing.T) {
 		ErrInvalidID,
 		ErrConnectionFailed,
 		ErrValidationFailed,
+		ErrNotSupported,
+		ErrForeignKeyViolation,
+		ErrNotNullViolation,
+		ErrCheckViolation,
+		ErrDeadlock,
+		ErrSerializationFailure,
 	}
 	for i, a := range sentinels {
 		for j, b := range sentinels {
@@ -97,3 +103,23 @@ func TestSentinelErrorsAreDistinct(t *testing.T) {
 		}
 	}
 }
+
+type driverError struct{ code string }
+
+func (e *driverError) Error() string { return "driver: " + e.code }
+
+func TestDBError(t *testing.T) {
+	cause := &driverError{code: "23505"}
+	err := fmt.Errorf("insert: %w", &DBError{Err: ErrDuplicateEntry, Constraint: "user_email_key", Cause: cause})
+
+	assert.True(t, IsDuplicate(err))
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "insert: styx: duplicate entry: driver: 23505", err.Error())
+
+	var de *driverError
+	assert.ErrorAs(t, err, &de)
+	var dbErr *DBError
+	if assert.ErrorAs(t, err, &dbErr) {
+		assert.Equal(t, "user_email_key", dbErr.Constraint)
+	}
+}
@@ -2,6 +2,7 @@ package arangodb
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/masudur-rahman/styx/dberr"
 	"github.com/masudur-rahman/styx/nosql"
@@ -14,6 +15,14 @@ type ArangoDB struct {
 	db             arango.Database
 	id             string
 	collectionName string
+	txCollections  []string
+	tx             *arangoTx
+}
+
+// arangoTx holds the ID of a stream transaction. Engine copies share it, so
+// Commit or Rollback on any of them ends the transaction for all.
+type arangoTx struct {
+	id arango.TransactionID
 }
 
 func NewArangoDB(db arango.Database) ArangoDB {
@@ -22,6 +31,76 @@ func NewArangoDB(db arango.Database) ArangoDB {
 	}
 }
 
+var _ nosql.Engine = ArangoDB{}
+
+// TxCollections declares the collections BeginTx locks for writing, in
+// addition to the one selected with Collection.
+func (a ArangoDB) TxCollections(collections ...string) ArangoDB {
+	a.txCollections = append(a.txCollections[:len(a.txCollections):len(a.txCollections)], collections...)
+	return a
+}
+
+// BeginTx starts a stream transaction that locks the collection selected
+// with Collection and those declared with TxCollections for writing. Other
+// collections can only be read inside the transaction, and collections that
+// do not exist yet are not created.
+func (a ArangoDB) BeginTx(ctx context.Context) (nosql.Engine, error) {
+	if a.txID() != "" {
+		return nil, dberr.ErrTransactionAlreadyStarted
+	}
+
+	cols := transactionCollections(a.collectionName, a.txCollections)
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%w: select a collection or declare them with TxCollections before BeginTx", dberr.ErrInvalidEntityName)
+	}
+
+	tid, err := a.db.BeginTransaction(ctx, arango.TransactionCollections{Write: cols}, &arango.BeginTransactionOptions{AllowImplicit: true})
+	if err != nil {
+		return nil, err
+	}
+	a.tx = &arangoTx{id: tid}
+	return a, nil
+}
+
+// Commit commits the stream transaction. Later calls to Commit or Rollback
+// return dberr.ErrTransactionNotStarted.
+func (a ArangoDB) Commit() error {
+	tid := a.txID()
+	if tid == "" {
+		return dberr.ErrTransactionNotStarted
+	}
+	a.tx.id = ""
+	return a.db.CommitTransaction(context.Background(), tid, nil)
+}
+
+// Rollback aborts the stream transaction. Later calls to Commit or Rollback
+// return dberr.ErrTransactionNotStarted.
+func (a ArangoDB) Rollback() error {
+	tid := a.txID()
+	if tid == "" {
+		return dberr.ErrTransactionNotStarted
+	}
+	a.tx.id = ""
+	return a.db.AbortTransaction(context.Background(), tid, nil)
+}
+
+// txID returns the ID of the active stream transaction, if any.
+func (a ArangoDB) txID() arango.TransactionID {
+	if a.tx == nil {
+		return ""
+	}
+	return a.tx.id
+}
+
+// txContext attaches the active stream transaction, if any, to ctx.
+func (a ArangoDB) txContext(ctx context.Context) context.Context {
+	tid := a.txID()
+	if tid == "" {
+		return ctx
+	}
+	return arango.WithTransactionID(ctx, tid)
+}
+
 func (a ArangoDB) Collection(collection string) nosql.Engine {
 	a.collectionName = collection
 	return a
@@ -33,11 +112,12 @@ func (a ArangoDB) ID(id string) nosql.Engine {
 }
 
 func (a ArangoDB) FindOne(ctx context.Context, document interface{}, filter ...interface{}) (bool, error) {
+	ctx = a.txContext(ctx)
 	if err := dberr.CheckIdOrFilterNonEmpty(a.id, filter); err != nil {
 		return false, err
 	}
 
-	collection, err := getDBCollection(ctx, a.db, a.collectionName)
+	collection, err := getDBCollection(ctx, a.db, a.collectionName, a.txID() != "")
 	if err != nil {
 		return false, err
 	}
@@ -70,7 +150,8 @@ func (a ArangoDB) FindOne(ctx context.Context, document interface{}, filter ...i
 }
 
 func (a ArangoDB) FindMany(ctx context.Context, documents interface{}, filter interface{}) error {
-	_, err := getDBCollection(ctx, a.db, a.collectionName)
+	ctx = a.txContext(ctx)
+	_, err := getDBCollection(ctx, a.db, a.collectionName, a.txID() != "")
 	if err != nil {
 		return err
 	}
@@ -85,7 +166,8 @@ func (a ArangoDB) FindMany(ctx context.Context, documents interface{}, filter in
 }
 
 func (a ArangoDB) InsertOne(ctx context.Context, document interface{}) (id string, err error) {
-	collection, err := getDBCollection(ctx, a.db, a.collectionName)
+	ctx = a.txContext(ctx)
+	collection, err := getDBCollection(ctx, a.db, a.collectionName, a.txID() != "")
 	if err != nil {
 		return "", err
 	}
@@ -99,7 +181,8 @@ func (a ArangoDB) InsertOne(ctx context.Context, document interface{}) (id strin
 }
 
 func (a ArangoDB) InsertMany(ctx context.Context, documents []interface{}) ([]string, error) {
-	collection, err := getDBCollection(ctx, a.db, a.collectionName)
+	ctx = a.txContext(ctx)
+	collection, err := getDBCollection(ctx, a.db, a.collectionName, a.txID() != "")
 	if err != nil {
 		return nil, err
 	}
@@ -119,11 +202,12 @@ func (a ArangoDB) InsertMany(ctx context.Context, documents []interface{}) ([]st
 }
 
 func (a ArangoDB) UpdateOne(ctx context.Context, document interface{}) error {
+	ctx = a.txContext(ctx)
 	if err := dberr.CheckIDNonEmpty(a.id); err != nil {
 		return err
 	}
 
-	collection, err := getDBCollection(ctx, a.db, a.collectionName)
+	collection, err := getDBCollection(ctx, a.db, a.collectionName, a.txID() != "")
 	if err != nil {
 		return err
 	}
@@ -133,11 +217,12 @@ func (a ArangoDB) UpdateOne(ctx context.Context, document interface{}) error {
 }
 
 func (a ArangoDB) DeleteOne(ctx context.Context, filter ...interface{}) error {
+	ctx = a.txContext(ctx)
 	if err := dberr.CheckIdOrFilterNonEmpty(a.id, filter); err != nil {
 		return err
 	}
 
-	collection, err := getDBCollection(ctx, a.db, a.collectionName)
+	collection, err := getDBCollection(ctx, a.db, a.collectionName, a.txID() != "")
 	if err != nil {
 		return err
 	}
@@ -157,7 +242,8 @@ func (a ArangoDB) DeleteOne(ctx context.Context, filter ...interface{}) error {
 }
 
 func (a ArangoDB) Query(ctx context.Context, query string, bindParams map[string]interface{}) (interface{}, error) {
-	_, err := getDBCollection(ctx, a.db, a.collectionName)
+	ctx = a.txContext(ctx)
+	_, err := getDBCollection(ctx, a.db, a.collectionName, a.txID() != "")
 	if err != nil {
 		return nil, err
 	}
@@ -8,6 +8,8 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/masudur-rahman/styx/dberr"
+
 	arango "github.com/arangodb/go-driver"
 	ahttp "github.com/arangodb/go-driver/http"
 	"github.com/iancoleman/strcase"
@@ -50,18 +52,39 @@ type Query struct {
 	bindVars    map[string]interface{}
 }
 
-func getDBCollection(ctx context.Context, db arango.Database, col string) (arango.Collection, error) {
+// getDBCollection returns the collection named col, creating it when it is
+// missing. Inside a stream transaction (inTx) a missing collection is an
+// error instead, since the transaction could not have declared it.
+func getDBCollection(ctx context.Context, db arango.Database, col string, inTx bool) (arango.Collection, error) {
 	collection, err := db.Collection(ctx, col)
 	if err != nil {
-		if arango.IsNotFoundGeneral(err) {
-			return db.CreateCollection(ctx, col, &arango.CreateCollectionOptions{})
+		if !arango.IsNotFoundGeneral(err) {
+			return nil, err
 		}
-		return nil, err
+		if inTx {
+			return nil, fmt.Errorf("%w: collection %s must exist before BeginTx", dberr.ErrNotSupported, col)
+		}
+		return db.CreateCollection(ctx, col, &arango.CreateCollectionOptions{})
 	}
 
 	return collection, nil
 }
 
+// transactionCollections returns the collections a stream transaction should
+// declare for writing.
+func transactionCollections(col string, declared []string) []string {
+	var names []string
+	if col != "" {
+		names = append(names, col)
+	}
+	for _, name := range declared {
+		if name != "" && name != col {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func generateArangoQuery(collection string, filter interface{}, removeQuery bool) *Query {
 	queryString := "FOR doc IN " + collection // + " FILTER "
 	bindVars := map[string]interface{}{}
@@ -3,6 +3,10 @@ package nosql
 import "context"
 
 type Engine interface {
+	BeginTx(ctx context.Context) (Engine, error)
+	Commit() error
+	Rollback() error
+
 	Collection(name string) Engine
 
 	ID(id string) Engine
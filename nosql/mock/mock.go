@@ -35,6 +35,21 @@ func (m *MockEngine) EXPECT() *MockEngineMockRecorder {
 	return m.recorder
 }
 
+// BeginTx mocks base method.
+func (m *MockEngine) BeginTx(ctx context.Context) (nosql.Engine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTx", ctx)
+	ret0, _ := ret[0].(nosql.Engine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTx indicates an expected call of BeginTx.
+func (mr *MockEngineMockRecorder) BeginTx(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockEngine)(nil).BeginTx), ctx)
+}
+
 // Collection mocks base method.
 func (m *MockEngine) Collection(name string) nosql.Engine {
 	m.ctrl.T.Helper()
@@ -49,6 +64,20 @@ func (mr *MockEngineMockRecorder) Collection(name interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Collection", reflect.TypeOf((*MockEngine)(nil).Collection), name)
 }
 
+// Commit mocks base method.
+func (m *MockEngine) Commit() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockEngineMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockEngine)(nil).Commit))
+}
+
 // DeleteOne mocks base method.
 func (m *MockEngine) DeleteOne(ctx context.Context, filter ...interface{}) error {
 	m.ctrl.T.Helper()
@@ -161,6 +190,20 @@ func (mr *MockEngineMockRecorder) Query(ctx, query, bindParams interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockEngine)(nil).Query), ctx, query, bindParams)
 }
 
+// Rollback mocks base method.
+func (m *MockEngine) Rollback() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockEngineMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockEngine)(nil).Rollback))
+}
+
 // UpdateOne mocks base method.
 func (m *MockEngine) UpdateOne(ctx context.Context, document interface{}) error {
 	m.ctrl.T.Helper()
@@ -1 +1,271 @@
 package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/nosql"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoDB struct {
+	db             database
+	id             string
+	collectionName string
+	tx             *mongoTx
+}
+
+// mongoTx holds the session of a transaction. Engine copies share it, so
+// Commit or Rollback on any of them ends the transaction for all.
+type mongoTx struct {
+	session session
+}
+
+func NewMongoDB(db *mongo.Database) MongoDB {
+	return MongoDB{
+		db: driverDatabase{db: db},
+	}
+}
+
+var _ nosql.Engine = MongoDB{}
+
+// BeginTx starts a session and a multi-document transaction on it. The server
+// must be a replica set or sharded cluster for transactions to be accepted.
+func (m MongoDB) BeginTx(ctx context.Context) (nosql.Engine, error) {
+	if m.activeSession() != nil {
+		return nil, dberr.ErrTransactionAlreadyStarted
+	}
+
+	sess, err := m.db.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	if err = sess.StartTransaction(); err != nil {
+		sess.EndSession(ctx)
+		return nil, err
+	}
+
+	m.tx = &mongoTx{session: sess}
+	return m, nil
+}
+
+// Commit commits the transaction and ends its session. Later calls to Commit
+// or Rollback return dberr.ErrTransactionNotStarted.
+func (m MongoDB) Commit() error {
+	sess := m.activeSession()
+	if sess == nil {
+		return dberr.ErrTransactionNotStarted
+	}
+	m.tx.session = nil
+
+	ctx := context.Background()
+	defer sess.EndSession(ctx)
+	return translateError(sess.CommitTransaction(ctx))
+}
+
+// Rollback aborts the transaction and ends its session. Later calls to Commit
+// or Rollback return dberr.ErrTransactionNotStarted.
+func (m MongoDB) Rollback() error {
+	sess := m.activeSession()
+	if sess == nil {
+		return dberr.ErrTransactionNotStarted
+	}
+	m.tx.session = nil
+
+	ctx := context.Background()
+	defer sess.EndSession(ctx)
+	return sess.AbortTransaction(ctx)
+}
+
+// activeSession returns the session of the active transaction, if any.
+func (m MongoDB) activeSession() session {
+	if m.tx == nil {
+		return nil
+	}
+	return m.tx.session
+}
+
+// txContext binds ctx to the active session, if any.
+func (m MongoDB) txContext(ctx context.Context) context.Context {
+	sess := m.activeSession()
+	if sess == nil {
+		return ctx
+	}
+	return sess.WithContext(ctx)
+}
+
+func (m MongoDB) Collection(collection string) nosql.Engine {
+	m.collectionName = collection
+	return m
+}
+
+func (m MongoDB) ID(id string) nosql.Engine {
+	m.id = id
+	return m
+}
+
+func (m MongoDB) collection() (collection, error) {
+	if err := dberr.CheckEntityNameNonEmpty(m.collectionName); err != nil {
+		return nil, err
+	}
+	return m.db.Collection(m.collectionName), nil
+}
+
+func (m MongoDB) FindOne(ctx context.Context, document interface{}, filter ...interface{}) (bool, error) {
+	ctx = m.txContext(ctx)
+	if len(filter) == 0 {
+		if err := dberr.CheckIDNonEmpty(m.id); err != nil {
+			return false, err
+		}
+	}
+
+	coll, err := m.collection()
+	if err != nil {
+		return false, err
+	}
+
+	query := idFilter(m.id)
+	if filter != nil {
+		query = generateMongoFilter(filter[0])
+	}
+
+	err = coll.FindOne(ctx, query).Decode(document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m MongoDB) FindMany(ctx context.Context, documents interface{}, filter interface{}) error {
+	ctx = m.txContext(ctx)
+	coll, err := m.collection()
+	if err != nil {
+		return err
+	}
+
+	cursor, err := coll.Find(ctx, generateMongoFilter(filter))
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, documents)
+}
+
+func (m MongoDB) InsertOne(ctx context.Context, document interface{}) (id string, err error) {
+	ctx = m.txContext(ctx)
+	coll, err := m.collection()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := coll.InsertOne(ctx, document)
+	if err != nil {
+		return "", translateError(err)
+	}
+
+	return idString(result.InsertedID), nil
+}
+
+func (m MongoDB) InsertMany(ctx context.Context, documents []interface{}) ([]string, error) {
+	ctx = m.txContext(ctx)
+	coll, err := m.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := coll.InsertMany(ctx, documents)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	ids := make([]string, len(result.InsertedIDs))
+	for i, id := range result.InsertedIDs {
+		ids[i] = idString(id)
+	}
+
+	return ids, nil
+}
+
+func (m MongoDB) UpdateOne(ctx context.Context, document interface{}) error {
+	ctx = m.txContext(ctx)
+	if err := dberr.CheckIDNonEmpty(m.id); err != nil {
+		return err
+	}
+
+	coll, err := m.collection()
+	if err != nil {
+		return err
+	}
+
+	result, err := coll.UpdateOne(ctx, idFilter(m.id), bson.M{"$set": document})
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return dberr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (m MongoDB) DeleteOne(ctx context.Context, filter ...interface{}) error {
+	ctx = m.txContext(ctx)
+	if len(filter) == 0 {
+		if err := dberr.CheckIDNonEmpty(m.id); err != nil {
+			return err
+		}
+	}
+
+	coll, err := m.collection()
+	if err != nil {
+		return err
+	}
+
+	query := idFilter(m.id)
+	if filter != nil {
+		query = generateMongoFilter(filter[0])
+	}
+
+	result, err := coll.DeleteOne(ctx, query)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.DeletedCount == 0 {
+		return dberr.ErrNotFound
+	}
+
+	return nil
+}
+
+// Query runs an aggregation pipeline, written as relaxed extended JSON, against
+// the current collection. String values of the form "@name" are replaced by the
+// matching entry in bindParams.
+func (m MongoDB) Query(ctx context.Context, query string, bindParams map[string]interface{}) (interface{}, error) {
+	ctx = m.txContext(ctx)
+	coll, err := m.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := parseMongoPipeline(query, bindParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return executeMongoQuery(ctx, coll, pipeline)
+}
+
+// translateError maps driver write errors onto dberr sentinels.
+func translateError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %v", dberr.ErrDuplicateEntry, err)
+	}
+	return err
+}
@@ -0,0 +1,216 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoConfig struct {
+	Name     string `json:"name" yaml:"name"`
+	Host     string `json:"host" yaml:"host"`
+	Port     string `json:"port" yaml:"port"`
+	User     string `json:"user" yaml:"user"`
+	Password string `json:"password" yaml:"password"`
+}
+
+func (cfg MongoConfig) URI() string {
+	if cfg.User == "" {
+		return fmt.Sprintf("mongodb://%s:%s", cfg.Host, cfg.Port)
+	}
+	return fmt.Sprintf("mongodb://%s:%s@%s:%s", cfg.User, cfg.Password, cfg.Host, cfg.Port)
+}
+
+func InitializeMongoDB(ctx context.Context, cfg MongoConfig) (*mongo.Database, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI()))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client.Database(cfg.Name), nil
+}
+
+// database is the subset of *mongo.Database used by MongoDB. It exists so the
+// engine can be exercised against an in-process fake.
+type database interface {
+	Collection(name string) collection
+	StartSession() (session, error)
+}
+
+// session is the subset of mongo.Session used for transactions.
+type session interface {
+	StartTransaction(opts ...*options.TransactionOptions) error
+	CommitTransaction(ctx context.Context) error
+	AbortTransaction(ctx context.Context) error
+	EndSession(ctx context.Context)
+	// WithContext returns ctx bound to the session so operations join its transaction.
+	WithContext(ctx context.Context) context.Context
+}
+
+// collection is the subset of *mongo.Collection used by MongoDB.
+type collection interface {
+	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error)
+	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
+	InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error)
+}
+
+type driverDatabase struct {
+	db *mongo.Database
+}
+
+func (d driverDatabase) Collection(name string) collection {
+	return d.db.Collection(name)
+}
+
+func (d driverDatabase) StartSession() (session, error) {
+	sess, err := d.db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	return driverSession{Session: sess}, nil
+}
+
+type driverSession struct {
+	mongo.Session
+}
+
+func (s driverSession) WithContext(ctx context.Context) context.Context {
+	return mongo.NewSessionContext(ctx, s.Session)
+}
+
+// idFilter matches a document by its _id. Hex strings produced by InsertOne are
+// converted back to ObjectIDs; any other value is matched as a plain string.
+func idFilter(id string) bson.M {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		return bson.M{"_id": oid}
+	}
+	return bson.M{"_id": id}
+}
+
+// idString converts an inserted _id into the string form returned by the engine.
+func idString(id interface{}) string {
+	if oid, ok := id.(primitive.ObjectID); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprintf("%v", id)
+}
+
+// generateMongoFilter builds an equality filter from the non-zero fields of a
+// struct, mirroring generateArangoQuery. Keys follow the bson encoding rules
+// (bson tag, otherwise the lowercased field name) so they match stored documents.
+func generateMongoFilter(filter interface{}) bson.M {
+	query := bson.M{}
+	if filter == nil {
+		return query
+	}
+
+	val := reflect.ValueOf(filter)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Map {
+		for _, key := range val.MapKeys() {
+			query[fmt.Sprintf("%v", key.Interface())] = val.MapIndex(key).Interface()
+		}
+		return query
+	}
+
+	for idx := 0; idx < val.NumField(); idx++ {
+		field := val.Type().Field(idx)
+		if !field.IsExported() || val.Field(idx).IsZero() {
+			continue
+		}
+
+		fieldName := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if fieldName == "-" {
+			continue
+		}
+		if fieldName == "" {
+			fieldName = strings.ToLower(field.Name)
+		}
+
+		value := val.Field(idx).Interface()
+		if fieldName == "_id" {
+			if id, ok := value.(string); ok {
+				value = idFilter(id)["_id"]
+			}
+		}
+		query[fieldName] = value
+	}
+
+	return query
+}
+
+// parseMongoPipeline parses a relaxed extended JSON aggregation pipeline and
+// substitutes "@name" string values with the matching bind parameter, following
+// the AQL bind variable convention used by the ArangoDB engine.
+func parseMongoPipeline(query string, bindParams map[string]interface{}) (bson.A, error) {
+	var wrapper struct {
+		Pipeline bson.A `bson:"pipeline"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"pipeline":`+query+`}`), false, &wrapper); err != nil {
+		return nil, fmt.Errorf("invalid aggregation pipeline: %w", err)
+	}
+
+	return bindPipelineParams(wrapper.Pipeline, bindParams).(bson.A), nil
+}
+
+func bindPipelineParams(val interface{}, bindParams map[string]interface{}) interface{} {
+	switch v := val.(type) {
+	case bson.A:
+		for i := range v {
+			v[i] = bindPipelineParams(v[i], bindParams)
+		}
+		return v
+	case bson.D:
+		for i := range v {
+			v[i].Value = bindPipelineParams(v[i].Value, bindParams)
+		}
+		return v
+	case bson.M:
+		for key := range v {
+			v[key] = bindPipelineParams(v[key], bindParams)
+		}
+		return v
+	case string:
+		if strings.HasPrefix(v, "@") {
+			if param, ok := bindParams[strings.TrimPrefix(v, "@")]; ok {
+				return param
+			}
+		}
+	}
+	return val
+}
+
+func executeMongoQuery(ctx context.Context, coll collection, pipeline bson.A) ([]interface{}, error) {
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []interface{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err = cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		results = append(results, doc)
+	}
+
+	return results, cursor.Err()
+}
@@ -0,0 +1,381 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/masudur-rahman/styx/dberr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeDatabase is an in-process stand-in for *mongo.Database that supports the
+// equality filters, $set updates and $match pipelines produced by the engine.
+type fakeDatabase struct {
+	collections map[string]*fakeCollection
+}
+
+func newFakeDatabase() *fakeDatabase {
+	return &fakeDatabase{collections: map[string]*fakeCollection{}}
+}
+
+func (f *fakeDatabase) Collection(name string) collection {
+	if _, ok := f.collections[name]; !ok {
+		f.collections[name] = &fakeCollection{}
+	}
+	return f.collections[name]
+}
+
+func (f *fakeDatabase) StartSession() (session, error) {
+	return &fakeSession{db: f}, nil
+}
+
+// fakeSession snapshots every collection when a transaction starts and
+// restores the snapshot on abort.
+type fakeSession struct {
+	db       *fakeDatabase
+	snapshot map[string][]bson.M
+	ended    bool
+}
+
+func (s *fakeSession) StartTransaction(opts ...*options.TransactionOptions) error {
+	s.snapshot = map[string][]bson.M{}
+	for name, coll := range s.db.collections {
+		docs := make([]bson.M, len(coll.docs))
+		for i, doc := range coll.docs {
+			docs[i] = toBsonM(doc)
+		}
+		s.snapshot[name] = docs
+	}
+	return nil
+}
+
+func (s *fakeSession) CommitTransaction(ctx context.Context) error {
+	s.snapshot = nil
+	return nil
+}
+
+func (s *fakeSession) AbortTransaction(ctx context.Context) error {
+	for name := range s.db.collections {
+		s.db.collections[name].docs = s.snapshot[name]
+	}
+	s.snapshot = nil
+	return nil
+}
+
+func (s *fakeSession) EndSession(ctx context.Context) {
+	s.ended = true
+}
+
+func (s *fakeSession) WithContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+type fakeCollection struct {
+	docs []bson.M
+}
+
+func toBsonM(v interface{}) bson.M {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var m bson.M
+	if err = bson.Unmarshal(data, &m); err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func (c *fakeCollection) matches(doc bson.M, filter interface{}) bool {
+	for key, want := range toBsonM(filter) {
+		if !reflect.DeepEqual(doc[key], want) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *fakeCollection) find(filter interface{}) []interface{} {
+	var out []interface{}
+	for _, doc := range c.docs {
+		if c.matches(doc, filter) {
+			out = append(out, doc)
+		}
+	}
+	return out
+}
+
+func (c *fakeCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	docs := c.find(filter)
+	if len(docs) == 0 {
+		return mongo.NewSingleResultFromDocument(bson.M{}, mongo.ErrNoDocuments, nil)
+	}
+	return mongo.NewSingleResultFromDocument(docs[0], nil, nil)
+}
+
+func (c *fakeCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	return mongo.NewCursorFromDocuments(c.find(filter), nil, nil)
+}
+
+func (c *fakeCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	doc := toBsonM(document)
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = primitive.NewObjectID()
+	}
+	if len(c.find(bson.M{"_id": doc["_id"]})) > 0 {
+		return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+	}
+	c.docs = append(c.docs, doc)
+	return &mongo.InsertOneResult{InsertedID: doc["_id"]}, nil
+}
+
+func (c *fakeCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	result := &mongo.InsertManyResult{}
+	for _, document := range documents {
+		res, err := c.InsertOne(ctx, document)
+		if err != nil {
+			return nil, err
+		}
+		result.InsertedIDs = append(result.InsertedIDs, res.InsertedID)
+	}
+	return result, nil
+}
+
+func (c *fakeCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	set := toBsonM(toBsonM(update)["$set"])
+	for _, doc := range c.docs {
+		if c.matches(doc, filter) {
+			for key, val := range set {
+				doc[key] = val
+			}
+			return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+		}
+	}
+	return &mongo.UpdateResult{}, nil
+}
+
+func (c *fakeCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	for i, doc := range c.docs {
+		if c.matches(doc, filter) {
+			c.docs = append(c.docs[:i], c.docs[i+1:]...)
+			return &mongo.DeleteResult{DeletedCount: 1}, nil
+		}
+	}
+	return &mongo.DeleteResult{}, nil
+}
+
+func (c *fakeCollection) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	var match interface{} = bson.M{}
+	for _, stage := range pipeline.(bson.A) {
+		if m, ok := toBsonM(stage)["$match"]; ok {
+			match = m
+		}
+	}
+	return mongo.NewCursorFromDocuments(c.find(match), nil, nil)
+}
+
+type User struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Name  string             `bson:"name"`
+	Email string             `bson:"email"`
+	Age   int                `bson:"age,omitempty"`
+}
+
+func newTestMongoDB() MongoDB {
+	return MongoDB{db: newFakeDatabase()}
+}
+
+func TestMongoDB_InsertAndFind(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDB().Collection("user")
+
+	id, err := db.InsertOne(ctx, User{Name: "masud", Email: "masud@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	t.Run("find by id", func(t *testing.T) {
+		var user User
+		found, err := db.ID(id).FindOne(ctx, &user)
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "masud", user.Name)
+		assert.Equal(t, id, user.ID.Hex())
+	})
+
+	t.Run("find by filter", func(t *testing.T) {
+		var user User
+		found, err := db.FindOne(ctx, &user, User{Email: "masud@example.com"})
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, 30, user.Age)
+	})
+
+	t.Run("missing document", func(t *testing.T) {
+		var user User
+		found, err := db.FindOne(ctx, &user, User{Name: "nobody"})
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("id or filter required", func(t *testing.T) {
+		var user User
+		_, err := db.FindOne(ctx, &user)
+		assert.ErrorIs(t, err, dberr.ErrInvalidID)
+	})
+}
+
+func TestMongoDB_InsertMany(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDB().Collection("user")
+
+	ids, err := db.InsertMany(ctx, []interface{}{
+		User{Name: "a", Email: "a@e.c"},
+		User{Name: "b", Email: "b@e.c"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+
+	var users []User
+	err = db.FindMany(ctx, &users, nil)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	users = nil
+	err = db.FindMany(ctx, &users, User{Name: "b"})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, ids[1], users[0].ID.Hex())
+}
+
+func TestMongoDB_DuplicateEntry(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDB().Collection("user")
+
+	oid := primitive.NewObjectID()
+	_, err := db.InsertOne(ctx, User{ID: oid, Name: "a"})
+	require.NoError(t, err)
+
+	_, err = db.InsertOne(ctx, User{ID: oid, Name: "b"})
+	assert.True(t, dberr.IsDuplicate(err))
+}
+
+func TestMongoDB_UpdateOne(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDB().Collection("user")
+
+	id, err := db.InsertOne(ctx, User{Name: "old", Email: "u@e.c"})
+	require.NoError(t, err)
+
+	err = db.ID(id).UpdateOne(ctx, bson.M{"name": "new"})
+	assert.NoError(t, err)
+
+	var user User
+	_, err = db.ID(id).FindOne(ctx, &user)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", user.Name)
+	assert.Equal(t, "u@e.c", user.Email)
+
+	err = db.ID(primitive.NewObjectID().Hex()).UpdateOne(ctx, bson.M{"name": "ghost"})
+	assert.ErrorIs(t, err, dberr.ErrNotFound)
+}
+
+func TestMongoDB_DeleteOne(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDB().Collection("user")
+
+	id, err := db.InsertOne(ctx, User{Name: "del", Email: "del@e.c"})
+	require.NoError(t, err)
+	_, err = db.InsertOne(ctx, User{Name: "del2", Email: "del2@e.c"})
+	require.NoError(t, err)
+
+	assert.NoError(t, db.ID(id).DeleteOne(ctx))
+	assert.NoError(t, db.DeleteOne(ctx, User{Name: "del2"}))
+	assert.ErrorIs(t, db.DeleteOne(ctx, User{Name: "del2"}), dberr.ErrNotFound)
+}
+
+func TestMongoDB_Query(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDB().Collection("user")
+
+	_, err := db.InsertMany(ctx, []interface{}{
+		User{Name: "a", Email: "a@e.c"},
+		User{Name: "b", Email: "b@e.c"},
+	})
+	require.NoError(t, err)
+
+	result, err := db.Query(ctx, `[{"$match": {"name": "@name"}}]`, map[string]interface{}{"name": "b"})
+	assert.NoError(t, err)
+	docs := result.([]interface{})
+	assert.Len(t, docs, 1)
+	assert.Equal(t, "b@e.c", docs[0].(bson.M)["email"])
+}
+
+func TestMongoDB_Transaction(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDB()
+
+	t.Run("commit", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx)
+		require.NoError(t, err)
+
+		_, err = tx.Collection("user").InsertOne(ctx, User{Name: "kept"})
+		require.NoError(t, err)
+		sess := tx.(MongoDB).activeSession().(*fakeSession)
+		assert.NoError(t, tx.Commit())
+		assert.True(t, sess.ended)
+		assert.ErrorIs(t, tx.Rollback(), dberr.ErrTransactionNotStarted)
+
+		var user User
+		found, err := db.Collection("user").FindOne(ctx, &user, User{Name: "kept"})
+		assert.NoError(t, err)
+		assert.True(t, found)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx)
+		require.NoError(t, err)
+
+		_, err = tx.Collection("user").InsertOne(ctx, User{Name: "discarded"})
+		require.NoError(t, err)
+		assert.NoError(t, tx.Rollback())
+		assert.ErrorIs(t, tx.Commit(), dberr.ErrTransactionNotStarted)
+
+		var user User
+		found, err := db.Collection("user").FindOne(ctx, &user, User{Name: "discarded"})
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("state errors", func(t *testing.T) {
+		assert.ErrorIs(t, db.Commit(), dberr.ErrTransactionNotStarted)
+		assert.ErrorIs(t, db.Rollback(), dberr.ErrTransactionNotStarted)
+
+		tx, err := db.BeginTx(ctx)
+		require.NoError(t, err)
+		_, err = tx.BeginTx(ctx)
+		assert.ErrorIs(t, err, dberr.ErrTransactionAlreadyStarted)
+		assert.NoError(t, tx.Rollback())
+	})
+}
+
+func TestGenerateMongoFilter(t *testing.T) {
+	oid := primitive.NewObjectID()
+	type filterDoc struct {
+		ID       string `bson:"_id"`
+		FullName string
+		Email    string `bson:"email_address,omitempty"`
+		Ignored  string `bson:"-"`
+		Age      int
+	}
+
+	filter := generateMongoFilter(filterDoc{ID: oid.Hex(), FullName: "Masud", Ignored: "x"})
+
+	assert.Equal(t, bson.M{"_id": oid, "fullname": "Masud"}, filter)
+}
@@ -0,0 +1,135 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorPage holds keyset pagination metadata. Next and Prev are opaque
+// cursors for the rows after the last and before the first row of the page.
+type CursorPage struct {
+	Next    string
+	Prev    string
+	HasNext bool
+	HasPrev bool
+}
+
+// cursor is the decoded form of an opaque cursor: the ORDER BY columns and
+// the values the boundary row had in them.
+type cursor struct {
+	Cols   []string      `json:"c"`
+	Values []cursorValue `json:"v"`
+}
+
+// cursorValue keeps the Go type of a value so it binds exactly as scanned;
+// plain JSON would turn every number into a float64.
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+// EncodeCursor returns an opaque, URL-safe cursor for a row whose ORDER BY
+// columns cols hold values. Supported values are integers, floats,
+// strings, bools, time.Time, []byte and pointers to them.
+func EncodeCursor(cols []string, values []any) (string, error) {
+	if len(cols) != len(values) {
+		return "", fmt.Errorf("cursor has %d columns but %d values", len(cols), len(values))
+	}
+	c := cursor{Cols: cols, Values: make([]cursorValue, len(values))}
+	for i, v := range values {
+		cv, err := encodeValue(v)
+		if err != nil {
+			return "", fmt.Errorf("cursor column %s: %w", cols[i], err)
+		}
+		c.Values[i] = cv
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor returns the columns and values encoded by EncodeCursor.
+// Integers come back as int64 or uint64 and floats as float64.
+func DecodeCursor(s string) (cols []string, values []any, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil || len(c.Cols) != len(c.Values) || len(c.Cols) == 0 {
+		return nil, nil, ErrInvalidCursor
+	}
+	values = make([]any, len(c.Values))
+	for i, cv := range c.Values {
+		if values[i], err = decodeValue(cv); err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+	return c.Cols, values, nil
+}
+
+func encodeValue(v any) (cursorValue, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return cursorValue{Type: "null"}, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return cursorValue{Type: "null"}, nil
+	}
+	if t, ok := rv.Interface().(time.Time); ok {
+		return cursorValue{Type: "time", Value: t.Format(time.RFC3339Nano)}, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.String:
+		return cursorValue{Type: "string", Value: rv.String()}, nil
+	case reflect.Bool:
+		return cursorValue{Type: "bool", Value: strconv.FormatBool(rv.Bool())}, nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return cursorValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(rv.Bytes())}, nil
+		}
+	}
+	return cursorValue{}, fmt.Errorf("unsupported cursor value of type %T", v)
+}
+
+func decodeValue(cv cursorValue) (any, error) {
+	switch cv.Type {
+	case "null":
+		return nil, nil
+	case "int":
+		return strconv.ParseInt(cv.Value, 10, 64)
+	case "uint":
+		return strconv.ParseUint(cv.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(cv.Value, 64)
+	case "string":
+		return cv.Value, nil
+	case "bool":
+		return strconv.ParseBool(cv.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, cv.Value)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(cv.Value)
+	}
+	return nil, fmt.Errorf("unknown cursor value type %q", cv.Type)
+}
@@ -0,0 +1,35 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_roundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	name := "o'neil, jr."
+	cols := []string{"id", "score", "name", "created_at", "active", "ref", "raw"}
+
+	cursor, err := EncodeCursor(cols, []any{int32(42), 1.5, &name, at, true, uint8(7), []byte{0, 1}})
+	assert.NoError(t, err)
+	assert.NotContains(t, cursor, "=", "cursors are URL-safe")
+
+	gotCols, values, err := DecodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, cols, gotCols)
+	assert.Equal(t, []any{int64(42), 1.5, name, at, true, uint64(7), []byte{0, 1}}, values)
+}
+
+func TestCursor_invalid(t *testing.T) {
+	_, err := EncodeCursor([]string{"id"}, []any{struct{}{}})
+	assert.Error(t, err)
+	_, err = EncodeCursor([]string{"id"}, nil)
+	assert.Error(t, err)
+
+	for _, c := range []string{"", "not base64!", "bm90IGpzb24", "e30"} {
+		_, _, err = DecodeCursor(c)
+		assert.ErrorIs(t, err, ErrInvalidCursor, c)
+	}
+}
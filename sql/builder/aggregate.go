@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+)
+
+// FilterDoc returns the struct filter of a Scalar, FindMaps or CountRows
+// call, or nil. Besides adding its non-zero, req and MustFilterCols fields
+// to WHERE, it names the table when Table was not called and carries the
+// soft-delete column.
+func FilterDoc(filter ...any) any {
+	if len(filter) == 0 || filter[0] == nil {
+		return nil
+	}
+	t := reflect.TypeOf(filter[0])
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return filter[0]
+}
+
+// prepareAggregate is prepareRead for queries without a destination struct;
+// doc, which may be nil, only supplies the table name.
+func (stmt *Statement) prepareAggregate(doc any) error {
+	if stmt.table == "" && doc == nil {
+		return fmt.Errorf("%w: no table; call Table or pass a filter struct", dberr.ErrInvalidQuery)
+	}
+	stmt.prepareRead(doc)
+	return nil
+}
+
+// ExecuteScalarQuery runs the read query and scans the single column of its
+// first row into dest, with database/sql conversions; a pointer-to-pointer
+// dest is set to nil for NULL, such as the SUM of no rows. It returns
+// sql.ErrNoRows when nothing matches.
+func (stmt *Statement) ExecuteScalarQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, doc, dest any) error {
+	if err := stmt.prepareAggregate(doc); err != nil {
+		return err
+	}
+	query := stmt.renderSelect(stmt.selectColumns(), true)
+	if stmt.showSQL {
+		log.Printf("Scalar Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, query, stmt.args...)
+	} else {
+		row = conn.QueryRowContext(ctx, query, stmt.args...)
+	}
+	if err := row.Scan(dest); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return stmt.translate(err)
+	}
+	return nil
+}
+
+// ExecuteCountQuery returns the number of rows the statement selects,
+// ignoring ORDER BY and LIMIT/OFFSET.
+func (stmt *Statement) ExecuteCountQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, doc any) (int64, error) {
+	if err := stmt.prepareAggregate(doc); err != nil {
+		return 0, err
+	}
+	query := stmt.renderCount()
+	if stmt.showSQL {
+		log.Printf("Count Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	var (
+		n   int64
+		row *sql.Row
+	)
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, query, stmt.args...)
+	} else {
+		row = conn.QueryRowContext(ctx, query, stmt.args...)
+	}
+	return n, stmt.translate(row.Scan(&n))
+}
+
+// ExecuteMapQuery runs the read query and returns every row as a map from
+// column name to value. Numeric columns that the driver returns as text are
+// converted to int64 or float64, other text to string.
+func (stmt *Statement) ExecuteMapQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, doc any) ([]map[string]any, error) {
+	if err := stmt.prepareAggregate(doc); err != nil {
+		return nil, err
+	}
+	query := stmt.renderSelect(stmt.selectColumns(), true)
+	if stmt.showSQL {
+		log.Printf("Map Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	rows, err := stmt.query(ctx, conn, tx, query)
+	if err != nil {
+		return nil, stmt.translate(err)
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, stmt.translate(err)
+	}
+	out := []map[string]any{}
+	for rows.Next() {
+		scans := make([]any, len(colTypes))
+		for i := range scans {
+			scans[i] = &scans[i]
+		}
+		if err = rows.Scan(scans...); err != nil {
+			return nil, stmt.translate(err)
+		}
+		m := make(map[string]any, len(colTypes))
+		for i, ct := range colTypes {
+			m[ct.Name()] = mapValue(scans[i], ct.DatabaseTypeName())
+		}
+		out = append(out, m)
+	}
+	return out, stmt.translate(rows.Err())
+}
+
+// mapValue converts the text drivers return for some columns, MySQL's
+// numbers among them, into a Go value by the column's database type.
+func mapValue(v any, dbType string) any {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	s := string(b)
+	switch dbType := strings.ToUpper(dbType); {
+	case strings.Contains(dbType, "INT"):
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case strings.Contains(dbType, "DECIMAL"), strings.Contains(dbType, "NUMERIC"),
+		strings.Contains(dbType, "FLOAT"), strings.Contains(dbType, "DOUBLE"), dbType == "REAL":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case strings.Contains(dbType, "BLOB"), dbType == "BYTEA", strings.Contains(dbType, "BINARY"):
+		return b
+	}
+	return s
+}
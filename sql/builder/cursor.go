@@ -0,0 +1,226 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/pagination"
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// DefaultCursorLimit is the page size of a cursor query without a Limit.
+const DefaultCursorLimit = 20
+
+// After makes the next cursor query return the rows following cursor.
+func (stmt *Statement) After(cursor string) *Statement {
+	stmt.after, stmt.before = cursor, ""
+	return stmt
+}
+
+// Before makes the next cursor query return the rows preceding cursor.
+func (stmt *Statement) Before(cursor string) *Statement {
+	stmt.before, stmt.after = cursor, ""
+	return stmt
+}
+
+// OrderTerm is one ORDER BY column and its direction.
+type OrderTerm struct {
+	Col  string
+	Desc bool
+}
+
+// OrderTerms returns the statement's ORDER BY columns in order.
+func (stmt *Statement) OrderTerms() []OrderTerm {
+	terms := make([]OrderTerm, 0, len(stmt.orderBy))
+	for _, o := range stmt.orderBy {
+		idx := strings.LastIndex(o, " ")
+		terms = append(terms, OrderTerm{Col: o[:idx], Desc: o[idx+1:] == "DESC"})
+	}
+	return terms
+}
+
+// KeysetOrder returns the ORDER BY terms of a cursor query: the statement's
+// own, followed by pkCol as a tie-breaker so the order is total.
+func (stmt *Statement) KeysetOrder(pkCol string) []OrderTerm {
+	return WithTieBreaker(stmt.OrderTerms(), pkCol)
+}
+
+// WithTieBreaker appends pkCol to terms unless they already order by it.
+func WithTieBreaker(terms []OrderTerm, pkCol string) []OrderTerm {
+	for _, t := range terms {
+		if columnName(t.Col) == pkCol {
+			return terms
+		}
+	}
+	return append(terms[:len(terms):len(terms)], OrderTerm{Col: pkCol})
+}
+
+// ExecuteCursorQuery fills docs, a pointer to a slice, with the page of
+// rows after (or before) the statement's cursor in keyset order and
+// returns the cursors of its neighbours; an empty page has none. The
+// ORDER BY columns must be non-NULL; pkCol is appended to them as a
+// tie-breaker.
+func (stmt *Statement) ExecuteCursorQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, docs any, pkCol string) (pagination.CursorPage, error) {
+	terms := stmt.KeysetOrder(pkCol)
+	cols := make([]string, len(terms))
+	for i, t := range terms {
+		cols[i] = t.Col
+	}
+
+	backward := stmt.before != ""
+	if cursor := stmt.after + stmt.before; cursor != "" {
+		values, err := CursorValues(cursor, cols)
+		if err != nil {
+			return pagination.CursorPage{}, err
+		}
+		pred, args := KeysetPredicate(terms, values, !backward)
+		stmt.Where(pred, args...)
+	}
+
+	stmt.orderBy = stmt.orderBy[:0:0]
+	for _, t := range terms {
+		desc := t.Desc != backward
+		dir := "ASC"
+		if desc {
+			dir = "DESC"
+		}
+		stmt.OrderBy(t.Col, dir)
+	}
+	limit := stmt.limit
+	if limit <= 0 {
+		limit = DefaultCursorLimit
+	}
+	stmt.limit, stmt.offset = limit+1, 0
+
+	reflect.ValueOf(docs).Elem().SetLen(0)
+	query := stmt.GenerateReadQuery(docs)
+	if err := stmt.ExecuteReadQuery(ctx, conn, tx, query, docs); err != nil {
+		return pagination.CursorPage{}, err
+	}
+
+	return FinishCursorPage(docs, cols, limit, stmt.after, stmt.before)
+}
+
+// CursorValues decodes cursor and checks that it was issued for a query
+// ordered by cols.
+func CursorValues(cursor string, cols []string) ([]any, error) {
+	cursorCols, values, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", dberr.ErrInvalidQuery, err)
+	}
+	if strings.Join(cursorCols, ",") != strings.Join(cols, ",") {
+		return nil, fmt.Errorf("%w: cursor is for ORDER BY %s, not %s", dberr.ErrInvalidQuery,
+			strings.Join(cursorCols, ", "), strings.Join(cols, ", "))
+	}
+	return values, nil
+}
+
+// FinishCursorPage turns the rows of a cursor query, fetched with limit+1
+// rows in keyset order (reversed when paging before a cursor), into the
+// page: it trims the extra row, restores the natural order and encodes
+// the cursors of the neighbouring pages from the boundary rows' cols.
+func FinishCursorPage(docs any, cols []string, limit int64, after, before string) (pagination.CursorPage, error) {
+	var page pagination.CursorPage
+	rows := reflect.ValueOf(docs).Elem()
+	more := int64(rows.Len()) > limit
+	if more {
+		rows.SetLen(int(limit))
+	}
+	if rows.Len() == 0 {
+		return page, nil
+	}
+	if before != "" {
+		swap := reflect.Swapper(rows.Interface())
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+		page.HasPrev, page.HasNext = more, true
+	} else {
+		page.HasNext, page.HasPrev = more, after != ""
+	}
+
+	var err error
+	if page.HasNext {
+		if page.Next, err = rowCursor(rows.Index(rows.Len()-1), cols); err != nil {
+			return page, err
+		}
+	}
+	if page.HasPrev {
+		if page.Prev, err = rowCursor(rows.Index(0), cols); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
+
+// KeysetPredicate returns the WHERE condition, with ? placeholders, that
+// selects the rows strictly after values in the order of terms (or before
+// them when forward is false). A single row comparison such as
+// (a, b) > (?, ?) is used when every term sorts the same way; mixed
+// ASC/DESC orders expand to a > ? OR (a = ? AND b < ?).
+func KeysetPredicate(terms []OrderTerm, values []any, forward bool) (string, []any) {
+	ops := make([]string, len(terms))
+	uniform := true
+	for i, t := range terms {
+		ops[i] = ">"
+		if t.Desc == forward {
+			ops[i] = "<"
+		}
+		uniform = uniform && ops[i] == ops[0]
+	}
+
+	if uniform {
+		if len(terms) == 1 {
+			return fmt.Sprintf("%s %s ?", terms[0].Col, ops[0]), values
+		}
+		cols := make([]string, len(terms))
+		for i, t := range terms {
+			cols[i] = t.Col
+		}
+		marks := strings.TrimSuffix(strings.Repeat("?, ", len(terms)), ", ")
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(cols, ", "), ops[0], marks), values
+	}
+
+	var (
+		ors  []string
+		args []any
+	)
+	for i := range terms {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, terms[j].Col+" = ?")
+			args = append(args, values[j])
+		}
+		ands = append(ands, fmt.Sprintf("%s %s ?", terms[i].Col, ops[i]))
+		args = append(args, values[i])
+		if len(ands) == 1 {
+			ors = append(ors, ands[0])
+		} else {
+			ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		}
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// rowCursor encodes the values row holds in cols.
+func rowCursor(row reflect.Value, cols []string) (string, error) {
+	fields := isql.GetDBFieldMap(row.Interface())
+	values := make([]any, len(cols))
+	for i, col := range cols {
+		idx, ok := fields[columnName(col)]
+		if !ok {
+			return "", fmt.Errorf("%w: cursor column %s is not a field of %s", dberr.ErrInvalidQuery, col, row.Type())
+		}
+		values[i] = row.Field(idx).Interface()
+	}
+	return pagination.EncodeCursor(cols, values)
+}
+
+// columnName strips a table qualifier from col.
+func columnName(col string) string {
+	return col[strings.LastIndex(col, ".")+1:]
+}
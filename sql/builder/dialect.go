@@ -0,0 +1,48 @@
+package builder
+
+import (
+	"reflect"
+)
+
+// Dialect captures the SQL syntax that differs between database engines.
+// Statement delegates every engine-specific fragment to it, so supporting a
+// new engine mostly means implementing this interface.
+type Dialect interface {
+	// Name identifies the dialect, e.g. "postgres" or "sqlite".
+	Name() string
+
+	// Placeholder returns the bind parameter marker for the n-th (1-based)
+	// argument, e.g. "$3" or "?".
+	Placeholder(n int) string
+	// ShiftPlaceholders renumbers the placeholders in query by offset.
+	// Dialects with positional placeholders return query unchanged.
+	ShiftPlaceholders(query string, offset int) string
+	// MaxParams is the maximum number of bind parameters in one statement.
+	MaxParams() int
+
+	// QuoteIdent quotes a table or column identifier.
+	QuoteIdent(name string) string
+	// SQLType maps a Go type to a column type. autoincr marks an
+	// auto-incrementing primary key.
+	SQLType(t reflect.Type, autoincr bool) string
+
+	// SupportsReturning reports whether INSERT/UPDATE/DELETE accept a
+	// RETURNING clause. Without it inserted keys come from LastInsertId.
+	SupportsReturning() bool
+	// SupportsWindowFunctions reports whether SELECT accepts window
+	// functions such as COUNT(*) OVER(). Without them FindPage counts the
+	// total with a separate query.
+	SupportsWindowFunctions() bool
+	// UpsertClause returns the clause appended to an INSERT so that a row
+	// conflicting on conflictCols has updateCols overwritten instead. pkCol is
+	// the table's primary key column when the database generates it, an
+	// integer autoincr key, and empty otherwise.
+	UpsertClause(pkCol string, conflictCols, updateCols []string) string
+	// LimitOffset renders the row limiting clause. Zero values mean unset;
+	// an empty string means no clause.
+	LimitOffset(limit, offset int64) string
+
+	// TranslateError wraps a driver error into a *dberr.DBError carrying the
+	// matching dberr sentinel, or returns it unchanged when none applies.
+	TranslateError(err error) error
+}
@@ -0,0 +1,917 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/pagination"
+	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/cond"
+	"github.com/masudur-rahman/styx/validation"
+)
+
+// Engine implements isql.Engine on database/sql. The SQL it runs comes from
+// its Statement's Dialect; what else differs between databases comes from
+// its Driver. The postgres, sqlite and mysql packages are Engines with their
+// own Dialect and Driver.
+type Engine struct {
+	conn      *sql.DB
+	tx        *sql.Tx
+	txDepth   int
+	statement Statement
+	driver    Driver
+}
+
+// Driver holds the database specifics an Engine needs beyond its Dialect.
+type Driver struct {
+	// IsRetryable reports whether RunInTx may retry a transaction that
+	// failed with err.
+	IsRetryable func(err error) bool
+	// Cursor streams the rows of query for Rows and Iterate, such as
+	// through a server-side cursor. Nil streams one plain result set.
+	Cursor func(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string, args ...any) (isql.RowScanner, error)
+	// ConsecutiveIDs reports whether a multi-row INSERT gets consecutive
+	// generated keys, for dialects without RETURNING, whose keys InsertMany
+	// derives from LastInsertId. When it reports false, InsertMany inserts
+	// one row per statement. Nil assumes consecutive keys.
+	ConsecutiveIDs func(ctx context.Context, conn *sql.DB, tx *sql.Tx) (bool, error)
+
+	// SyncTable, PlanTable and DropTable manage the schema for Sync, Plan
+	// and DropTable.
+	SyncTable func(ctx context.Context, conn *sql.DB, table any) error
+	PlanTable func(ctx context.Context, conn *sql.DB, table any) ([]isql.SchemaChange, error)
+	DropTable func(ctx context.Context, conn *sql.DB, name string) error
+}
+
+// NewEngine returns an Engine on conn building its queries with stmt.
+func NewEngine(conn *sql.DB, stmt Statement, driver Driver) Engine {
+	return Engine{conn: conn, statement: stmt, driver: driver}
+}
+
+var _ isql.Engine = Engine{}
+
+// BeginTx starts a transaction. Called on an engine that is already inside
+// one, it opens a savepoint instead; Commit and Rollback on the returned
+// engine then release or roll back to that savepoint only.
+func (e Engine) BeginTx(ctx context.Context) (isql.Engine, error) {
+	return e.BeginTxWithOptions(ctx, nil)
+}
+
+// BeginTxWithOptions is BeginTx with an isolation level and read-only flag.
+// A savepoint always shares the options of the enclosing transaction.
+func (e Engine) BeginTxWithOptions(ctx context.Context, opts *sql.TxOptions) (isql.Engine, error) {
+	if e.tx != nil {
+		if err := Savepoint(ctx, e.tx, e.txDepth+1); err != nil {
+			return nil, err
+		}
+		e.txDepth++
+		return e, nil
+	}
+	tx, err := e.conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, e.statement.translate(err)
+	}
+	e.tx = tx
+	return e, nil
+}
+
+// RunInTx runs fn in a transaction and retries it on the failures
+// Driver.IsRetryable accepts, such as serialization failures and deadlocks.
+// Inside an enclosing transaction fn runs in a savepoint and is not retried:
+// the savepoint shares the outer transaction's snapshot, so retrying inside
+// it cannot clear a serialization failure; only the outermost transaction
+// can be retried.
+func (e Engine) RunInTx(ctx context.Context, opts isql.TxOptions, fn func(isql.Engine) error) error {
+	if e.tx != nil {
+		opts.MaxRetries = 0
+	}
+	return isql.RunInTx(ctx, e, opts, e.driver.IsRetryable, fn)
+}
+
+func (e Engine) Commit() error {
+	if e.tx == nil {
+		return dberr.ErrTransactionNotStarted
+	}
+	if e.txDepth > 0 {
+		return ReleaseSavepoint(context.Background(), e.tx, e.txDepth)
+	}
+	err := e.tx.Commit()
+	e.tx = nil
+	return e.statement.translate(err)
+}
+
+func (e Engine) Rollback() error {
+	if e.tx == nil {
+		return dberr.ErrTransactionNotStarted
+	}
+	if e.txDepth > 0 {
+		return RollbackToSavepoint(context.Background(), e.tx, e.txDepth)
+	}
+	err := e.tx.Rollback()
+	e.tx = nil
+	return err
+}
+
+func (e Engine) Table(name string) isql.Engine {
+	e.statement.Table(name)
+	return e
+}
+
+func (e Engine) ID(id any) isql.Engine {
+	e.statement.ID(id)
+	return e
+}
+
+func (e Engine) In(col string, values ...any) isql.Engine {
+	e.statement.In(col, values...)
+	return e
+}
+
+func (e Engine) Where(cond string, args ...any) isql.Engine {
+	e.statement.Where(cond, args...)
+	return e
+}
+
+func (e Engine) WhereCond(c cond.Cond) isql.Engine {
+	e.statement.WhereCond(c)
+	return e
+}
+
+func (e Engine) Columns(cols ...string) isql.Engine {
+	e.statement.Columns(cols...)
+	return e
+}
+
+func (e Engine) AllCols() isql.Engine {
+	e.statement.AllCols()
+	return e
+}
+
+func (e Engine) MustCols(cols ...string) isql.Engine {
+	e.statement.MustCols(cols...)
+	return e
+}
+
+func (e Engine) MustFilterCols(cols ...string) isql.Engine {
+	e.statement.MustFilterCols(cols...)
+	return e
+}
+
+func (e Engine) ShowSQL(showSQL bool) isql.Engine {
+	e.statement.ShowSQL(showSQL)
+	return e
+}
+
+func (e Engine) OrderBy(col string, direction ...string) isql.Engine {
+	e.statement.OrderBy(col, direction...)
+	return e
+}
+
+func (e Engine) Limit(n int64) isql.Engine {
+	e.statement.Limit(n)
+	return e
+}
+
+func (e Engine) Offset(n int64) isql.Engine {
+	e.statement.Offset(n)
+	return e
+}
+
+func (e Engine) Distinct() isql.Engine {
+	e.statement.Distinct()
+	return e
+}
+
+func (e Engine) GroupBy(cols ...string) isql.Engine {
+	e.statement.GroupBy(cols...)
+	return e
+}
+
+func (e Engine) Having(cond string, args ...any) isql.Engine {
+	e.statement.Having(cond, args...)
+	return e
+}
+
+func (e Engine) Or(cond string, args ...any) isql.Engine {
+	e.statement.Or(cond, args...)
+	return e
+}
+
+func (e Engine) Like(col string, pattern string) isql.Engine {
+	e.statement.Like(col, pattern)
+	return e
+}
+
+func (e Engine) NotLike(col string, pattern string) isql.Engine {
+	e.statement.NotLike(col, pattern)
+	return e
+}
+
+func (e Engine) Exists(subquery any, args ...any) isql.Engine {
+	e.statement.Exists(subquery, args...)
+	return e
+}
+
+func (e Engine) NotExists(subquery any, args ...any) isql.Engine {
+	e.statement.NotExists(subquery, args...)
+	return e
+}
+
+func (e Engine) From(subquery any, alias string, args ...any) isql.Engine {
+	e.statement.From(subquery, alias, args...)
+	return e
+}
+
+func (e Engine) With(name string, subquery any, args ...any) isql.Engine {
+	e.statement.With(name, subquery, args...)
+	return e
+}
+
+func (e Engine) WithRecursive(name string, subquery any, args ...any) isql.Engine {
+	e.statement.WithRecursive(name, subquery, args...)
+	return e
+}
+
+// Subquery renders the SELECT built so far for embedding in another query
+// on this engine; proto, when given, supplies the table and soft-delete column.
+func (e Engine) Subquery(proto ...any) isql.Subquery {
+	doc := FilterDoc(proto...)
+	if doc != nil {
+		e = e.detectSoftDelete(doc)
+	}
+	e.statement.GenerateWhereClause()
+
+	return e.statement.GenerateSubquery(doc)
+}
+
+func (e Engine) Count(col string, alias ...string) isql.Engine {
+	e.statement.Count(col, alias...)
+	return e
+}
+
+func (e Engine) Sum(col string, alias ...string) isql.Engine {
+	e.statement.Sum(col, alias...)
+	return e
+}
+
+func (e Engine) Avg(col string, alias ...string) isql.Engine {
+	e.statement.Avg(col, alias...)
+	return e
+}
+
+func (e Engine) Min(col string, alias ...string) isql.Engine {
+	e.statement.Min(col, alias...)
+	return e
+}
+
+func (e Engine) Max(col string, alias ...string) isql.Engine {
+	e.statement.Max(col, alias...)
+	return e
+}
+
+func (e Engine) Paginate(page, perPage int64) isql.Engine {
+	e.statement.Paginate(page, perPage)
+	return e
+}
+
+func (e Engine) After(cursor string) isql.Engine {
+	e.statement.After(cursor)
+	return e
+}
+
+func (e Engine) Before(cursor string) isql.Engine {
+	e.statement.Before(cursor)
+	return e
+}
+
+func (e Engine) Join(table, condition string) isql.Engine {
+	e.statement.Join(table, condition)
+	return e
+}
+
+func (e Engine) LeftJoin(table, condition string) isql.Engine {
+	e.statement.LeftJoin(table, condition)
+	return e
+}
+
+func (e Engine) RightJoin(table, condition string) isql.Engine {
+	e.statement.RightJoin(table, condition)
+	return e
+}
+
+func (e Engine) InnerJoin(table, condition string) isql.Engine {
+	e.statement.InnerJoin(table, condition)
+	return e
+}
+
+func (e Engine) EnableValidation(enable bool) isql.Engine {
+	e.statement.EnableValidation(enable)
+	return e
+}
+
+func (e Engine) WithDeleted() isql.Engine {
+	e.statement.WithDeleted()
+	return e
+}
+
+func (e Engine) SoftDeleteCol(col string) isql.Engine {
+	e.statement.SoftDeleteCol(col)
+	return e
+}
+
+// detectSoftDelete sets soft delete column from struct tags if present.
+func (e Engine) detectSoftDelete(doc any) Engine {
+	if col := isql.ExtractSoftDeleteColumn(doc); col != "" {
+		e.statement.SoftDeleteCol(col)
+	}
+	return e
+}
+
+// detectDeleteTarget detects the soft delete column for a delete or restore
+// from the filter struct, like DeleteOne, and from out only when no filter
+// is given, so a call with RETURNING deletes the same way as one without.
+func (e Engine) detectDeleteTarget(out any, filter []any) Engine {
+	if len(filter) > 0 {
+		return e.detectSoftDelete(filter[0])
+	}
+	if out != nil {
+		return e.detectSoftDelete(out)
+	}
+	return e
+}
+
+func (e Engine) ForceDelete(ctx context.Context, filter ...any) error {
+	e.statement.SetForceDelete()
+	return e.DeleteOne(ctx, filter...)
+}
+
+func (e Engine) Restore(ctx context.Context, filter ...any) error {
+	e = e.detectDeleteTarget(nil, filter)
+	e.statement.GenerateWhereClause(filter...)
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return err
+	}
+
+	query := e.statement.GenerateRestoreQuery()
+	result, err := e.statement.ExecuteWriteQuery(ctx, e.conn, e.tx, query)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return dberr.ErrNotFound
+	}
+	return nil
+}
+
+func (e Engine) FindOne(ctx context.Context, document any, filter ...any) (bool, error) {
+	e = e.detectSoftDelete(document)
+	e.statement.GenerateWhereClause(filter...)
+
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return false, err
+	}
+
+	query := e.statement.GenerateReadQuery(document)
+	err := e.statement.ExecuteReadQuery(ctx, e.conn, e.tx, query, document)
+	if err == nil {
+		return true, nil
+	}
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return false, err
+}
+
+func (e Engine) FindMany(ctx context.Context, documents any, filter ...any) error {
+	e = e.detectSoftDelete(documents)
+	e.statement.GenerateWhereClause(filter...)
+
+	query := e.statement.GenerateReadQuery(documents)
+	return e.statement.ExecuteReadQuery(ctx, e.conn, e.tx, query, documents)
+}
+
+// FindPage fills documents with one page of matching rows and returns the
+// pagination metadata. The total is counted from the same WHERE/JOIN state.
+func (e Engine) FindPage(ctx context.Context, documents any, page, perPage int64, filter ...any) (pagination.Paginator, error) {
+	e = e.detectSoftDelete(documents)
+	e.statement.GenerateWhereClause(filter...)
+
+	return e.statement.ExecutePageQuery(ctx, e.conn, e.tx, documents, page, perPage)
+}
+
+// FindCursor fills documents with the page of rows after (or before) the
+// cursor set by After/Before, in ORDER BY order with the primary key as a
+// tie-breaker, and returns the cursors of the neighbouring pages.
+func (e Engine) FindCursor(ctx context.Context, documents any, filter ...any) (pagination.CursorPage, error) {
+	e = e.detectSoftDelete(documents)
+	e.statement.GenerateWhereClause(filter...)
+
+	return e.statement.ExecuteCursorQuery(ctx, e.conn, e.tx, documents, isql.GetPKColumn(documents))
+}
+
+// Rows returns the rows matching the statement and filter without loading
+// them, through Driver.Cursor when the driver has one; the caller must
+// Close them.
+func (e Engine) Rows(ctx context.Context, proto any, filter ...any) (isql.RowScanner, error) {
+	e = e.detectSoftDelete(proto)
+	e.statement.GenerateWhereClause(filter...)
+
+	query := e.statement.GenerateReadQuery(proto)
+	if e.driver.Cursor != nil {
+		return e.driver.Cursor(ctx, e.conn, e.tx, query, e.statement.Args()...)
+	}
+	return e.statement.ExecuteStreamQuery(ctx, e.conn, e.tx, query)
+}
+
+// Iterate calls fn with a pointer to each matching row, one at a time.
+func (e Engine) Iterate(ctx context.Context, proto any, fn func(row any) error, filter ...any) error {
+	rows, err := e.Rows(ctx, proto, filter...)
+	if err != nil {
+		return err
+	}
+	return isql.IterateRows(rows, proto, fn)
+}
+
+// Scalar scans the single value selected by the statement, typically one
+// aggregate, into dest. A struct filter also supplies the table and the
+// soft-delete column. Without one, name them with Table and SoftDeleteCol:
+// even a zero-valued struct filters by its req and MustFilterCols fields.
+func (e Engine) Scalar(ctx context.Context, dest any, filter ...any) error {
+	doc := FilterDoc(filter...)
+	if doc != nil {
+		e = e.detectSoftDelete(doc)
+	}
+	e.statement.GenerateWhereClause(filter...)
+
+	return e.statement.ExecuteScalarQuery(ctx, e.conn, e.tx, doc, dest)
+}
+
+// FindMaps returns the selected rows as maps from column name to value.
+func (e Engine) FindMaps(ctx context.Context, filter ...any) ([]map[string]any, error) {
+	doc := FilterDoc(filter...)
+	if doc != nil {
+		e = e.detectSoftDelete(doc)
+	}
+	e.statement.GenerateWhereClause(filter...)
+
+	return e.statement.ExecuteMapQuery(ctx, e.conn, e.tx, doc)
+}
+
+// CountRows returns the number of rows matching the statement and filter.
+func (e Engine) CountRows(ctx context.Context, filter ...any) (int64, error) {
+	doc := FilterDoc(filter...)
+	if doc != nil {
+		e = e.detectSoftDelete(doc)
+	}
+	e.statement.GenerateWhereClause(filter...)
+
+	return e.statement.ExecuteCountQuery(ctx, e.conn, e.tx, doc)
+}
+
+func (e Engine) InsertOne(ctx context.Context, document any) (id any, err error) {
+	if e.statement.ShouldValidate() {
+		if err := validation.Validate(document); err != nil {
+			return nil, err
+		}
+	}
+	pkCol := isql.GetPKColumn(document)
+	e.statement.PKColumn(pkCol)
+	query := e.statement.GenerateInsertQuery(document)
+	id, err = e.statement.ExecuteInsertQuery(ctx, e.conn, e.tx, query)
+	if err != nil {
+		return nil, err
+	}
+	return assignID(document, generatedID(document, id))
+}
+
+// Upsert inserts document or, when it conflicts on conflictCols, updates the
+// existing row. conflictCols defaults to isql.GetConflictColumns(document).
+func (e Engine) Upsert(ctx context.Context, document any, conflictCols []string, updateCols ...string) (id any, err error) {
+	if e.statement.ShouldValidate() {
+		if err := validation.Validate(document); err != nil {
+			return nil, err
+		}
+	}
+	if len(conflictCols) == 0 {
+		conflictCols = isql.GetConflictColumns(document)
+	}
+	e.statement.PKColumn(isql.GetPKColumn(document))
+	query, err := e.statement.GenerateUpsertQuery(document, conflictCols, updateCols)
+	if err != nil {
+		return nil, err
+	}
+	id, err = e.statement.ExecuteInsertQuery(ctx, e.conn, e.tx, query)
+	if err != nil {
+		return nil, err
+	}
+	return assignID(document, generatedID(document, id))
+}
+
+// InsertMany writes documents with as few multi-row INSERT statements as
+// possible and assigns the generated IDs back in input order.
+func (e Engine) InsertMany(ctx context.Context, documents []any) ([]any, error) {
+	batches := e.statement.InsertBatches(documents)
+	if !e.statement.dialect.SupportsReturning() && e.driver.ConsecutiveIDs != nil {
+		consecutive, err := e.driver.ConsecutiveIDs(ctx, e.conn, e.tx)
+		if err != nil {
+			return nil, e.statement.translate(err)
+		}
+		if !consecutive {
+			batches = make([][]any, len(documents))
+			for i, doc := range documents {
+				batches[i] = []any{doc}
+			}
+		}
+	}
+
+	var ids []any
+	for _, batch := range batches {
+		stmt := e.statement
+		stmt.PKColumn(isql.GetPKColumn(batch[0]))
+		query := stmt.GenerateInsertManyQuery(batch)
+		batchIDs, err := stmt.ExecuteInsertManyQuery(ctx, e.conn, e.tx, query)
+		if err != nil {
+			return nil, err
+		}
+		if len(batchIDs) != len(batch) {
+			return nil, fmt.Errorf("expected %d inserted ids, got %d", len(batch), len(batchIDs))
+		}
+
+		for i, doc := range batch {
+			batchIDs[i] = generatedID(doc, batchIDs[i])
+			if _, err = assignID(doc, batchIDs[i]); err != nil {
+				return nil, err
+			}
+		}
+		ids = append(ids, batchIDs...)
+	}
+
+	return ids, nil
+}
+
+// generatedID returns id unless the driver reported no generated key, as
+// LastInsertId does when the document carried its own primary key; that key
+// is returned instead.
+func generatedID(document any, id any) any {
+	if n, ok := id.(int64); !ok || n != 0 {
+		return id
+	}
+	val := reflect.Indirect(reflect.ValueOf(document))
+	if val.Kind() != reflect.Struct {
+		return id
+	}
+	idField := fetchIDField(val)
+	if !idField.IsValid() || idField.IsZero() {
+		return id
+	}
+	return reflect.Indirect(idField).Interface()
+}
+
+// assignID sets the primary key field of document to id, the key the
+// database generated. Only integer keys are generated; a key of any other
+// type is the document's own and is left as it is.
+func assignID(document any, id any) (any, error) {
+	val := reflect.ValueOf(document)
+	if val.Kind() != reflect.Ptr {
+		return document, nil
+		// first make it backward compatible
+		// return id, fmt.Errorf("document must be a pointer to a struct")
+	}
+
+	valElem := val.Elem()
+	if valElem.Kind() != reflect.Struct {
+		return id, fmt.Errorf("document must be a pointer to a struct")
+	}
+
+	var idField = fetchIDField(valElem)
+	if !idField.CanSet() {
+		return id, fmt.Errorf("ID field is not settable")
+	}
+	if !isIntegerKind(idField.Type()) {
+		return id, nil
+	}
+
+	idVal := reflect.ValueOf(id)
+	if idField.Kind() == reflect.Ptr {
+		elemType := idField.Type().Elem()
+		if !idVal.Type().AssignableTo(elemType) && !idVal.Type().ConvertibleTo(elemType) {
+			return id, fmt.Errorf("ID type %s cannot be assigned to pointer element type %s", idVal.Type(), elemType)
+		}
+		idValPtr := reflect.New(elemType)
+		if idVal.Type().AssignableTo(elemType) {
+			idValPtr.Elem().Set(idVal)
+		} else {
+			idValPtr.Elem().Set(idVal.Convert(elemType))
+		}
+		idField.Set(idValPtr)
+	} else {
+		if !idVal.Type().AssignableTo(idField.Type()) {
+			if idVal.Type().ConvertibleTo(idField.Type()) {
+				idVal = idVal.Convert(idField.Type())
+			} else {
+				return id, fmt.Errorf("ID type %s cannot be assigned or converted to field type %s", idVal.Type(), idField.Type())
+			}
+		}
+		idField.Set(idVal)
+	}
+
+	return id, nil
+}
+
+// isIntegerKind reports whether t, or the type t points to, is an integer.
+func isIntegerKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// fetchIDField returns the primary key field of valElem: the pk-tagged
+// column, else a field named or tagged id.
+func fetchIDField(valElem reflect.Value) (idField reflect.Value) {
+	doc := valElem.Interface()
+	if idx, ok := isql.GetDBFieldMap(doc)[isql.GetPKColumn(doc)]; ok {
+		return valElem.Field(idx)
+	}
+
+	for i := 0; i < valElem.NumField(); i++ {
+		field := valElem.Type().Field(i)
+		dbTag := field.Tag.Get("db")
+		if dbTag != "" {
+			dbTag = strings.Split(dbTag, ",")[0]
+		}
+		jsonTag := field.Tag.Get("json")
+		if dbTag == "id" || jsonTag == "id" {
+			idField = valElem.Field(i)
+			return idField
+		}
+	}
+
+	idFieldNames := []string{"ID", "Id"}
+	for _, name := range idFieldNames {
+		idField = valElem.FieldByName(name)
+		if idField.IsValid() {
+			return idField
+		}
+	}
+	return
+}
+
+func (e Engine) UpdateOne(ctx context.Context, document any) error {
+	if e.statement.ShouldValidate() {
+		if err := validation.Validate(document); err != nil {
+			return err
+		}
+	}
+	e.statement.GenerateWhereClause()
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return err
+	}
+
+	query := e.statement.GenerateUpdateQuery(document)
+	result, err := e.statement.ExecuteWriteQuery(ctx, e.conn, e.tx, query)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return dberr.ErrNotFound
+	}
+	return nil
+}
+
+// UpdateMany updates every row matching the WHERE clause with the non-zero
+// fields of document and returns the number of rows affected. Soft-deleted
+// rows are skipped unless WithDeleted is set.
+func (e Engine) UpdateMany(ctx context.Context, document any) (int64, error) {
+	if e.statement.ShouldValidate() {
+		if err := validation.Validate(document); err != nil {
+			return 0, err
+		}
+	}
+	e = e.detectSoftDelete(document)
+	e.statement.GenerateWhereClause()
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return 0, err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return 0, err
+	}
+	e.statement.ExcludeSoftDeleted()
+
+	query := e.statement.GenerateUpdateQuery(document)
+	result, err := e.statement.ExecuteWriteQuery(ctx, e.conn, e.tx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (e Engine) DeleteOne(ctx context.Context, filter ...any) error {
+	e = e.detectDeleteTarget(nil, filter)
+	e.statement.GenerateWhereClause(filter...)
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return err
+	}
+
+	var query string
+	if e.statement.IsSoftDelete() {
+		query = e.statement.GenerateSoftDeleteQuery()
+	} else {
+		query = e.statement.GenerateDeleteQuery()
+	}
+	result, err := e.statement.ExecuteWriteQuery(ctx, e.conn, e.tx, query)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return dberr.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteMany deletes every matching row and returns the number of rows
+// affected. Soft-delete tables get their marker set on rows not already
+// deleted.
+func (e Engine) DeleteMany(ctx context.Context, filter ...any) (int64, error) {
+	e = e.detectDeleteTarget(nil, filter)
+	e.statement.GenerateWhereClause(filter...)
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return 0, err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return 0, err
+	}
+
+	var query string
+	if e.statement.IsSoftDelete() {
+		e.statement.ExcludeSoftDeleted()
+		query = e.statement.GenerateSoftDeleteQuery()
+	} else {
+		query = e.statement.GenerateDeleteQuery()
+	}
+	result, err := e.statement.ExecuteWriteQuery(ctx, e.conn, e.tx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpdateOneReturning behaves like UpdateOne and scans the updated rows into
+// out. It returns the number of rows updated.
+func (e Engine) UpdateOneReturning(ctx context.Context, document any, out any) (int64, error) {
+	if e.statement.ShouldValidate() {
+		if err := validation.Validate(document); err != nil {
+			return 0, err
+		}
+	}
+	e.statement.GenerateWhereClause()
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return 0, err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return 0, err
+	}
+
+	query := e.statement.GenerateUpdateQuery(document)
+	return e.executeReturning(ctx, query, out)
+}
+
+// DeleteOneReturning behaves like DeleteOne and scans the deleted (or soft
+// deleted) rows into out. It returns the number of rows affected.
+func (e Engine) DeleteOneReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	e = e.detectDeleteTarget(out, filter)
+	e.statement.GenerateWhereClause(filter...)
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return 0, err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return 0, err
+	}
+
+	var query string
+	if e.statement.IsSoftDelete() {
+		query = e.statement.GenerateSoftDeleteQuery()
+	} else {
+		query = e.statement.GenerateDeleteQuery()
+	}
+	return e.executeReturning(ctx, query, out)
+}
+
+// ForceDeleteReturning permanently deletes matching rows and scans them into out.
+func (e Engine) ForceDeleteReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	e.statement.SetForceDelete()
+	return e.DeleteOneReturning(ctx, out, filter...)
+}
+
+// RestoreReturning clears the soft delete marker on matching rows and scans
+// the restored rows into out.
+func (e Engine) RestoreReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	e = e.detectDeleteTarget(out, filter)
+	e.statement.GenerateWhereClause(filter...)
+	if err := e.statement.CheckWhereClauseNotEmpty(); err != nil {
+		return 0, err
+	}
+	if err := e.statement.CheckSelectOnlyClauses(); err != nil {
+		return 0, err
+	}
+
+	query := e.statement.GenerateRestoreQuery()
+	return e.executeReturning(ctx, query, out)
+}
+
+// executeReturning runs query with RETURNING * and maps zero affected rows
+// to dberr.ErrNotFound, like the non-returning variants.
+func (e Engine) executeReturning(ctx context.Context, query string, out any) (int64, error) {
+	n, err := e.statement.ExecuteReturningQuery(ctx, e.conn, e.tx, query, out)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, dberr.ErrNotFound
+	}
+	return n, nil
+}
+
+func (e Engine) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return e.statement.Query(ctx, e.conn, e.tx, query, args...)
+}
+
+func (e Engine) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return e.statement.QueryRow(ctx, e.conn, e.tx, query, args...)
+}
+
+// QueryInto runs a raw query and scans the rows into dest, a pointer to a
+// struct or a slice of structs. A struct dest with no rows yields sql.ErrNoRows.
+func (e Engine) QueryInto(ctx context.Context, dest any, query string, args ...any) error {
+	return e.statement.QueryInto(ctx, e.conn, e.tx, dest, query, args...)
+}
+
+func (e Engine) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return e.statement.Exec(ctx, e.conn, e.tx, query, args...)
+}
+
+func (e Engine) Sync(ctx context.Context, tables ...any) error {
+	for _, table := range tables {
+		if err := e.driver.SyncTable(ctx, e.conn, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e Engine) Plan(ctx context.Context, tables ...any) ([]isql.SchemaChange, error) {
+	var changes []isql.SchemaChange
+	for _, table := range tables {
+		tc, err := e.driver.PlanTable(ctx, e.conn, table)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tc...)
+	}
+
+	return changes, nil
+}
+
+func (e Engine) DropTable(ctx context.Context, name string) error {
+	return e.driver.DropTable(ctx, e.conn, name)
+}
+
+func (e Engine) Dialect() string {
+	return e.statement.Dialect().Name()
+}
+
+func (e Engine) Close() error {
+	return e.conn.Close()
+}
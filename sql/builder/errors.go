@@ -0,0 +1,14 @@
+package builder
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net"
+)
+
+// IsConnError reports whether err comes from a broken or unreachable
+// connection rather than from the statement itself.
+func IsConnError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)
+}
@@ -0,0 +1,145 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"reflect"
+	"strconv"
+
+	"github.com/masudur-rahman/styx/pagination"
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// totalColumn carries COUNT(*) OVER() alongside each row of a page query.
+const totalColumn = "styx_total"
+
+// GeneratePageQueries sets LIMIT/OFFSET for page and builds both the page
+// query and a COUNT query over the same FROM/JOIN/WHERE/GROUP BY state, so
+// they share Args. When windowed is true the page query also selects the
+// total row count as an extra column. It is false for dialects without
+// window functions and for DISTINCT queries, whose window would be computed
+// before duplicates are removed.
+func (stmt *Statement) GeneratePageQueries(doc any, page, perPage int64) (pageQuery, countQuery string, windowed bool) {
+	stmt.Paginate(page, perPage)
+	stmt.prepareRead(doc)
+
+	windowed = stmt.dialect.SupportsWindowFunctions() && !stmt.distinct
+	colParts := stmt.selectColumns()
+	if windowed {
+		colParts = append(colParts, "COUNT(*) OVER() AS "+totalColumn)
+	}
+	pageQuery = stmt.renderSelect(colParts, true)
+	return pageQuery, stmt.renderCount(), windowed
+}
+
+// renderCount renders a query counting the rows the statement selects,
+// ignoring ORDER BY and LIMIT/OFFSET. A grouped statement counts groups.
+func (stmt *Statement) renderCount() string {
+	// Selecting a constant keeps joined tables from producing duplicate
+	// column names in the derived table; DISTINCT needs the real columns.
+	inner := []string{"1"}
+	if stmt.distinct {
+		inner = stmt.selectColumns()
+	}
+	return "SELECT COUNT(*) FROM (" + stmt.renderSelect(inner, false) + ") styx_rows"
+}
+
+// ExecutePageQuery fills docs, a pointer to a slice, with one page of rows
+// and returns the pagination metadata. The total comes from the windowed
+// column of the page query when possible, which takes a single round trip;
+// a separate COUNT runs for dialects without window functions, for DISTINCT
+// queries and for pages past the end.
+func (stmt *Statement) ExecutePageQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, docs any, page, perPage int64) (pagination.Paginator, error) {
+	pageQuery, countQuery, windowed := stmt.GeneratePageQueries(docs, page, perPage)
+	p := pagination.NewPaginator(page, perPage, 0)
+
+	if stmt.showSQL {
+		log.Printf("Page Query: query: %v, args: %v\n", pageQuery, stmt.args)
+	}
+	rows, err := stmt.query(ctx, conn, tx, pageQuery)
+	if err != nil {
+		return p, stmt.translate(err)
+	}
+	n, total, err := scanPage(rows, docs, windowed)
+	rows.Close()
+	if err != nil {
+		return p, stmt.translate(err)
+	}
+
+	if !windowed || (n == 0 && stmt.offset > 0) {
+		if stmt.showSQL {
+			log.Printf("Count Query: query: %v, args: %v\n", countQuery, stmt.args)
+		}
+		var row *sql.Row
+		if tx != nil {
+			row = tx.QueryRowContext(ctx, countQuery, stmt.args...)
+		} else {
+			row = conn.QueryRowContext(ctx, countQuery, stmt.args...)
+		}
+		if err = row.Scan(&total); err != nil {
+			return p, stmt.translate(err)
+		}
+	}
+
+	return pagination.NewPaginator(p.Page, p.PerPage, total), nil
+}
+
+func (stmt *Statement) query(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string) (*sql.Rows, error) {
+	if tx != nil {
+		return tx.QueryContext(ctx, query, stmt.args...)
+	}
+	return conn.QueryContext(ctx, query, stmt.args...)
+}
+
+// scanPage appends every row to docs and, when windowed, reads the total
+// from the trailing totalColumn of the first row.
+func scanPage(rows *sql.Rows, docs any, windowed bool) (n, total int64, err error) {
+	if !windowed {
+		n, err = scanRows(rows, docs)
+		return n, 0, err
+	}
+
+	fields, err := rows.Columns()
+	if err != nil {
+		return 0, 0, err
+	}
+	elem := reflect.ValueOf(docs).Elem()
+	last := len(fields) - 1
+	for rows.Next() {
+		scans := make([]any, len(fields))
+		for i := range scans {
+			scans[i] = &scans[i]
+		}
+		if err = rows.Scan(scans...); err != nil {
+			return 0, 0, err
+		}
+		if n == 0 {
+			total = toInt64(scans[last])
+		}
+
+		rowElem := reflect.New(elem.Type().Elem()).Interface()
+		if err = isql.AssignRow(rowElem, fields[:last], scans[:last]); err != nil {
+			return 0, 0, err
+		}
+		elem.Set(reflect.Append(elem, reflect.ValueOf(rowElem).Elem()))
+		n++
+	}
+	return n, total, rows.Err()
+}
+
+// toInt64 converts a scanned COUNT value; drivers return int64, or the
+// decimal text for MySQL's text protocol.
+func toInt64(v any) int64 {
+	switch t := v.(type) {
+	case int64:
+		return t
+	case []byte:
+		n, _ := strconv.ParseInt(string(t), 10, 64)
+		return n
+	case string:
+		n, _ := strconv.ParseInt(t, 10, 64)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SavepointName returns the name of the savepoint opened at nesting depth n
+// (1 for the first transaction nested in a real one).
+func SavepointName(n int) string {
+	return fmt.Sprintf("sp_%d", n)
+}
+
+// Savepoint opens the savepoint for depth n inside tx.
+func Savepoint(ctx context.Context, tx *sql.Tx, n int) error {
+	_, err := tx.ExecContext(ctx, "SAVEPOINT "+SavepointName(n))
+	return err
+}
+
+// ReleaseSavepoint keeps the work done since the savepoint for depth n and
+// discards the savepoint.
+func ReleaseSavepoint(ctx context.Context, tx *sql.Tx, n int) error {
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+SavepointName(n))
+	return err
+}
+
+// RollbackToSavepoint undoes the work done since the savepoint for depth n
+// and then discards the savepoint, leaving the enclosing transaction usable.
+func RollbackToSavepoint(ctx context.Context, tx *sql.Tx, n int) error {
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+SavepointName(n)); err != nil {
+		return err
+	}
+	return ReleaseSavepoint(ctx, tx, n)
+}
@@ -0,0 +1,1031 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/cond"
+)
+
+// Statement accumulates the parts of a query and renders them for its Dialect.
+type Statement struct {
+	dialect          Dialect
+	table            string
+	id               any
+	columns          []string
+	allCols          bool
+	mustCols         []string
+	mustColMap       map[string]bool
+	mustFilterCols   []string
+	mustFilterColMap map[string]bool
+	where            string
+	args             []any
+	argCounter       int
+	showSQL          bool
+	pkColumn         string
+	orderBy          []string
+	limit            int64
+	offset           int64
+	groupBy          []string
+	having           string
+	distinct         bool
+	aggregates       []string
+	softDeleteCol    string
+	withDeleted      bool
+	forceDelete      bool
+	validate         bool
+	joins            []string
+	after            string
+	before           string
+	ctes             []string
+	recursive        bool
+	cteArgs          int
+	from             string
+	fromArgs         int
+}
+
+// NewStatement returns an empty Statement that renders SQL for d.
+func NewStatement(d Dialect) Statement {
+	return Statement{dialect: d}
+}
+
+// Dialect returns the dialect the statement renders for.
+func (stmt *Statement) Dialect() Dialect {
+	return stmt.dialect
+}
+
+// Args returns the bind arguments collected so far, in placeholder order.
+func (stmt *Statement) Args() []any {
+	return stmt.args
+}
+
+func (stmt *Statement) Table(name string) *Statement {
+	stmt.table = name
+	return stmt
+}
+
+func (stmt *Statement) ID(id any) *Statement {
+	if stmt.where != "" {
+		stmt.where += " AND "
+	}
+
+	stmt.id = id
+	return stmt
+}
+
+// In adds col IN (values...) to the WHERE clause. A single isql.Engine or
+// isql.Subquery value is embedded as a subquery instead.
+func (stmt *Statement) In(col string, values ...any) *Statement {
+	if len(values) == 1 {
+		switch values[0].(type) {
+		case isql.Engine, isql.Subquery:
+			return stmt.InSubquery(col, values[0])
+		}
+	}
+
+	if stmt.where != "" {
+		stmt.where += " AND "
+	}
+
+	// Use parameterized placeholders instead of direct string formatting
+	placeholders := make([]string, len(values))
+	for i := range values {
+		placeholders[i] = stmt.nextPlaceholder()
+	}
+	stmt.args = append(stmt.args, values...)
+	stmt.where += fmt.Sprintf("%s IN (%s)", col, strings.Join(placeholders, ", "))
+	return stmt
+}
+
+// Where adds a condition with ? placeholders for args to the WHERE clause.
+func (stmt *Statement) Where(cond string, args ...any) *Statement {
+	cond = stmt.bindPlaceholders(cond, len(args))
+	stmt.where = stmt.AddWhereClause(cond)
+	if len(args) > 0 {
+		// Create a new slice to avoid sharing underlying array
+		newArgs := make([]any, len(args))
+		copy(newArgs, args)
+		stmt.args = append(stmt.args, newArgs...)
+	}
+	return stmt
+}
+
+// WhereCond adds a condition built with package cond to the WHERE clause.
+func (stmt *Statement) WhereCond(c cond.Cond) *Statement {
+	return stmt.Where(c.SQL(), c.Args()...)
+}
+
+// nextPlaceholder reserves the next argument position and returns its marker.
+func (stmt *Statement) nextPlaceholder() string {
+	stmt.argCounter++
+	return stmt.dialect.Placeholder(stmt.argCounter)
+}
+
+// bindPlaceholders rewrites the first n "?" markers in cond to the dialect's
+// placeholders, reserving an argument position for each.
+func (stmt *Statement) bindPlaceholders(cond string, n int) string {
+	for i := 0; i < n; i++ {
+		cond = strings.Replace(cond, "?", stmt.nextPlaceholder(), 1)
+	}
+	return cond
+}
+
+func (stmt *Statement) generateWhereClauseFromID() string {
+	if isql.IsZeroValue(stmt.id) {
+		return ""
+	}
+	stmt.args = append(stmt.args, stmt.id)
+	return "id = " + stmt.nextPlaceholder()
+}
+
+func (stmt *Statement) GenerateWhereClauseFromFilter(filter any) string {
+	stmt.mustFilterColMap = stmt.generateMustFilterColMap()
+	var conditions []string
+
+	val := reflect.ValueOf(filter)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	for idx := 0; idx < val.NumField(); idx++ {
+		field := val.Type().Field(idx)
+		col := isql.GetFieldName(field)
+
+		if !(stmt.allCols || stmt.mustFilterColMap[col] || isql.HasReqTag(field) || !val.Field(idx).IsZero()) {
+			continue
+		}
+
+		conditions = append(conditions, col+" = "+stmt.nextPlaceholder())
+		stmt.args = append(stmt.args, isql.SQLArgValue(field, val.Field(idx)))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+func (stmt *Statement) GenerateWhereClause(filter ...any) *Statement {
+	stmt.where = stmt.AddWhereClause(stmt.generateWhereClauseFromID())
+	if len(filter) > 0 {
+		stmt.where = stmt.AddWhereClause(stmt.GenerateWhereClauseFromFilter(filter[0]))
+	}
+	return stmt
+}
+
+func (stmt *Statement) CheckWhereClauseNotEmpty() error {
+	if stmt.where == "" {
+		return dberr.ErrMissingWhereClause
+	}
+	return nil
+}
+
+func (stmt *Statement) AddWhereClause(cond string) string {
+	if stmt.where != "" && cond != "" {
+		stmt.where += " AND "
+	}
+
+	stmt.where += cond
+	return stmt.where
+}
+
+func (stmt *Statement) Columns(cols ...string) *Statement {
+	stmt.columns = cols
+	return stmt
+}
+
+func (stmt *Statement) AllCols() *Statement {
+	stmt.allCols = true
+	return stmt
+}
+
+func (stmt *Statement) MustCols(cols ...string) *Statement {
+	stmt.mustCols = cols
+	return stmt
+}
+
+// MustFilterCols marks columns that must be included in WHERE clauses even when zero-valued.
+func (stmt *Statement) MustFilterCols(cols ...string) *Statement {
+	stmt.mustFilterCols = cols
+	return stmt
+}
+
+func (stmt *Statement) ShowSQL(showSQL bool) *Statement {
+	stmt.showSQL = showSQL
+	return stmt
+}
+
+// PKColumn sets the primary key column name for RETURNING clause in INSERT queries.
+func (stmt *Statement) PKColumn(col string) *Statement {
+	stmt.pkColumn = col
+	return stmt
+}
+
+// OrderBy adds an ORDER BY clause. Default direction is ASC.
+func (stmt *Statement) OrderBy(col string, direction ...string) *Statement {
+	dir := "ASC"
+	if len(direction) > 0 && strings.ToUpper(direction[0]) == "DESC" {
+		dir = "DESC"
+	}
+	stmt.orderBy = append(stmt.orderBy, fmt.Sprintf("%s %s", col, dir))
+	return stmt
+}
+
+// Limit sets the maximum number of rows to return.
+func (stmt *Statement) Limit(n int64) *Statement {
+	stmt.limit = n
+	return stmt
+}
+
+// Offset sets the number of rows to skip.
+func (stmt *Statement) Offset(n int64) *Statement {
+	stmt.offset = n
+	return stmt
+}
+
+// Distinct enables SELECT DISTINCT.
+func (stmt *Statement) Distinct() *Statement {
+	stmt.distinct = true
+	return stmt
+}
+
+// GroupBy adds GROUP BY columns.
+func (stmt *Statement) GroupBy(cols ...string) *Statement {
+	stmt.groupBy = append(stmt.groupBy, cols...)
+	return stmt
+}
+
+// Having sets the HAVING clause for GROUP BY filtering.
+func (stmt *Statement) Having(cond string, args ...any) *Statement {
+	cond = stmt.bindPlaceholders(cond, len(args))
+	stmt.having = cond
+	if len(args) > 0 {
+		newArgs := make([]any, len(args))
+		copy(newArgs, args)
+		stmt.args = append(stmt.args, newArgs...)
+	}
+	return stmt
+}
+
+// Or adds an OR condition to the WHERE clause.
+func (stmt *Statement) Or(cond string, args ...any) *Statement {
+	cond = stmt.bindPlaceholders(cond, len(args))
+	if stmt.where != "" {
+		stmt.where += " OR " + cond
+	} else {
+		stmt.where = cond
+	}
+	if len(args) > 0 {
+		newArgs := make([]any, len(args))
+		copy(newArgs, args)
+		stmt.args = append(stmt.args, newArgs...)
+	}
+	return stmt
+}
+
+// Like adds a LIKE condition to the WHERE clause.
+func (stmt *Statement) Like(col string, pattern string) *Statement {
+	cond := fmt.Sprintf("%s LIKE %s", col, stmt.nextPlaceholder())
+	stmt.where = stmt.AddWhereClause(cond)
+	stmt.args = append(stmt.args, pattern)
+	return stmt
+}
+
+// NotLike adds a NOT LIKE condition to the WHERE clause.
+func (stmt *Statement) NotLike(col string, pattern string) *Statement {
+	cond := fmt.Sprintf("%s NOT LIKE %s", col, stmt.nextPlaceholder())
+	stmt.where = stmt.AddWhereClause(cond)
+	stmt.args = append(stmt.args, pattern)
+	return stmt
+}
+
+// Exists adds an EXISTS subquery condition to the WHERE clause. subquery is
+// a string with ? placeholders for args, or an isql.Engine or isql.Subquery,
+// whose placeholders are renumbered to follow the statement's.
+func (stmt *Statement) Exists(subquery any, args ...any) *Statement {
+	stmt.where = stmt.AddWhereClause(fmt.Sprintf("EXISTS (%s)", stmt.embed(subquery, args)))
+	return stmt
+}
+
+// NotExists adds a NOT EXISTS subquery condition to the WHERE clause.
+func (stmt *Statement) NotExists(subquery any, args ...any) *Statement {
+	stmt.where = stmt.AddWhereClause(fmt.Sprintf("NOT EXISTS (%s)", stmt.embed(subquery, args)))
+	return stmt
+}
+
+// Count adds a COUNT aggregate to the SELECT clause.
+func (stmt *Statement) Count(col string, alias ...string) *Statement {
+	stmt.aggregates = append(stmt.aggregates, formatAggregate("COUNT", col, alias...))
+	return stmt
+}
+
+// Sum adds a SUM aggregate to the SELECT clause.
+func (stmt *Statement) Sum(col string, alias ...string) *Statement {
+	stmt.aggregates = append(stmt.aggregates, formatAggregate("SUM", col, alias...))
+	return stmt
+}
+
+// Avg adds an AVG aggregate to the SELECT clause.
+func (stmt *Statement) Avg(col string, alias ...string) *Statement {
+	stmt.aggregates = append(stmt.aggregates, formatAggregate("AVG", col, alias...))
+	return stmt
+}
+
+// Min adds a MIN aggregate to the SELECT clause.
+func (stmt *Statement) Min(col string, alias ...string) *Statement {
+	stmt.aggregates = append(stmt.aggregates, formatAggregate("MIN", col, alias...))
+	return stmt
+}
+
+// Max adds a MAX aggregate to the SELECT clause.
+func (stmt *Statement) Max(col string, alias ...string) *Statement {
+	stmt.aggregates = append(stmt.aggregates, formatAggregate("MAX", col, alias...))
+	return stmt
+}
+
+func formatAggregate(fn, col string, alias ...string) string {
+	expr := fmt.Sprintf("%s(%s)", fn, col)
+	if len(alias) > 0 && alias[0] != "" {
+		expr += " as " + alias[0]
+	}
+	return expr
+}
+
+// Paginate sets LIMIT and OFFSET for page-based pagination.
+func (stmt *Statement) Paginate(page, perPage int64) *Statement {
+	if perPage <= 0 {
+		perPage = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	stmt.limit = perPage
+	stmt.offset = (page - 1) * perPage
+	return stmt
+}
+
+// EnableValidation enables or disables struct validation before writes.
+func (stmt *Statement) EnableValidation(enable bool) *Statement {
+	stmt.validate = enable
+	return stmt
+}
+
+// ShouldValidate returns true if validation is enabled.
+func (stmt *Statement) ShouldValidate() bool {
+	return stmt.validate
+}
+
+// Join adds a JOIN clause.
+func (stmt *Statement) Join(table string, on string, args ...any) *Statement {
+	return stmt.addJoin("JOIN", table, on, args...)
+}
+
+// LeftJoin adds a LEFT JOIN clause.
+func (stmt *Statement) LeftJoin(table string, on string, args ...any) *Statement {
+	return stmt.addJoin("LEFT JOIN", table, on, args...)
+}
+
+// RightJoin adds a RIGHT JOIN clause.
+func (stmt *Statement) RightJoin(table string, on string, args ...any) *Statement {
+	return stmt.addJoin("RIGHT JOIN", table, on, args...)
+}
+
+// InnerJoin adds an INNER JOIN clause.
+func (stmt *Statement) InnerJoin(table string, on string, args ...any) *Statement {
+	return stmt.addJoin("INNER JOIN", table, on, args...)
+}
+
+func (stmt *Statement) addJoin(joinType, table, on string, args ...any) *Statement {
+	on = stmt.bindPlaceholders(on, len(args))
+	stmt.joins = append(stmt.joins, fmt.Sprintf("%s %s ON %s", joinType, stmt.dialect.QuoteIdent(table), on))
+	if len(args) > 0 {
+		newArgs := make([]any, len(args))
+		copy(newArgs, args)
+		stmt.args = append(stmt.args, newArgs...)
+	}
+	return stmt
+}
+
+// SoftDeleteCol sets the soft delete column name for the current query.
+func (stmt *Statement) SoftDeleteCol(col string) *Statement {
+	stmt.softDeleteCol = col
+	return stmt
+}
+
+// WithDeleted disables the automatic soft delete filter.
+func (stmt *Statement) WithDeleted() *Statement {
+	stmt.withDeleted = true
+	return stmt
+}
+
+// SetForceDelete marks the next delete as a hard delete even with soft delete enabled.
+func (stmt *Statement) SetForceDelete() *Statement {
+	stmt.forceDelete = true
+	return stmt
+}
+
+// IsSoftDelete returns true if soft delete is enabled and not force-deleting.
+func (stmt *Statement) IsSoftDelete() bool {
+	return stmt.softDeleteCol != "" && !stmt.forceDelete
+}
+
+// ExcludeSoftDeleted restricts the WHERE clause to rows that are not soft
+// deleted, unless WithDeleted is set.
+func (stmt *Statement) ExcludeSoftDeleted() *Statement {
+	if stmt.softDeleteCol != "" && !stmt.withDeleted {
+		stmt.where = stmt.AddWhereClause(stmt.softDeleteCol + " IS NULL")
+	}
+	return stmt
+}
+
+// GenerateSoftDeleteQuery generates an UPDATE query that sets the soft delete column.
+func (stmt *Statement) GenerateSoftDeleteQuery() string {
+	return fmt.Sprintf("UPDATE %s SET %s = CURRENT_TIMESTAMP WHERE %s", stmt.dialect.QuoteIdent(stmt.table), stmt.softDeleteCol, stmt.where)
+}
+
+// GenerateRestoreQuery generates an UPDATE that clears the soft delete column.
+func (stmt *Statement) GenerateRestoreQuery() string {
+	return fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s", stmt.dialect.QuoteIdent(stmt.table), stmt.softDeleteCol, stmt.where)
+}
+
+// GenerateReadQuery builds a SELECT query from the current statement state.
+func (stmt *Statement) GenerateReadQuery(doc any) string {
+	stmt.prepareRead(doc)
+	return stmt.renderSelect(stmt.selectColumns(), true)
+}
+
+// prepareRead resolves the table from doc and adds the soft-delete filter.
+// It must run once per statement, before renderSelect.
+func (stmt *Statement) prepareRead(doc any) {
+	if stmt.table == "" {
+		stmt.table = isql.GetTableName(doc)
+	}
+	if stmt.softDeleteCol != "" && !stmt.withDeleted {
+		stmt.where = stmt.AddWhereClause(stmt.softDeleteCol + " IS NULL")
+	}
+}
+
+// selectColumns returns the SELECT list: aggregates and chosen columns, or *.
+func (stmt *Statement) selectColumns() []string {
+	var colParts []string
+	if len(stmt.aggregates) > 0 {
+		colParts = append(colParts, stmt.aggregates...)
+	}
+	if len(stmt.columns) > 0 && !stmt.allCols {
+		colParts = append(colParts, stmt.columns...)
+	}
+	if len(colParts) == 0 {
+		colParts = []string{"*"}
+	}
+	return colParts
+}
+
+// renderSelect renders the SELECT of colParts. ORDER BY and LIMIT/OFFSET
+// are left out unless paged is set.
+func (stmt *Statement) renderSelect(colParts []string, paged bool) string {
+	selectKeyword := "SELECT"
+	if stmt.distinct {
+		selectKeyword = "SELECT DISTINCT"
+	}
+
+	var b strings.Builder
+	source := stmt.from
+	if source == "" {
+		source = stmt.dialect.QuoteIdent(stmt.table)
+	}
+	fmt.Fprintf(&b, "%s%s %s FROM %s", stmt.renderWith(), selectKeyword, strings.Join(colParts, ", "), source)
+
+	for _, join := range stmt.joins {
+		b.WriteString(" ")
+		b.WriteString(join)
+	}
+
+	if stmt.where != "" {
+		b.WriteString(" WHERE ")
+		b.WriteString(stmt.where)
+	}
+	if len(stmt.groupBy) > 0 {
+		b.WriteString(" GROUP BY ")
+		b.WriteString(strings.Join(stmt.groupBy, ", "))
+	}
+	if stmt.having != "" {
+		b.WriteString(" HAVING ")
+		b.WriteString(stmt.having)
+	}
+	if !paged {
+		return b.String()
+	}
+	if len(stmt.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(stmt.orderBy, ", "))
+	}
+	if clause := stmt.dialect.LimitOffset(stmt.limit, stmt.offset); clause != "" {
+		b.WriteString(" ")
+		b.WriteString(clause)
+	}
+
+	return b.String()
+}
+
+func (stmt *Statement) ExecuteReadQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string, doc any) error {
+	//defer  stmt.cleanup()
+
+	if stmt.showSQL {
+		log.Printf("Read Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	var (
+		err  error
+		rows *sql.Rows
+	)
+
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, stmt.args...)
+	} else {
+		rows, err = conn.QueryContext(ctx, query, stmt.args...)
+	}
+	if err != nil {
+		return stmt.translate(err)
+	}
+	defer rows.Close()
+
+	n, err := scanRows(rows, doc)
+	if err != nil {
+		return stmt.translate(err)
+	}
+	if n == 0 && reflect.ValueOf(doc).Elem().Kind() != reflect.Slice {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// scanRows scans rows into doc, which must point to a struct (first row) or a
+// slice (every row), and returns the number of rows scanned.
+func scanRows(rows *sql.Rows, doc any) (int64, error) {
+	var n int64
+	elem := reflect.ValueOf(doc).Elem()
+	switch elem.Kind() {
+	case reflect.Struct:
+		if rows.Next() {
+			if err := isql.ScanRow(rows, doc); err != nil {
+				return 0, err
+			}
+			n++
+		}
+	case reflect.Slice:
+		for rows.Next() {
+			rowElem := reflect.New(elem.Type().Elem()).Interface()
+			if err := isql.ScanRow(rows, rowElem); err != nil {
+				return 0, err
+			}
+			elem.Set(reflect.Append(elem, reflect.ValueOf(rowElem).Elem()))
+			n++
+		}
+	}
+
+	return n, rows.Err()
+}
+
+// ExecuteReturningQuery runs a write query with RETURNING * appended and scans
+// the affected rows into out, a pointer to a struct or a slice. The returned
+// count covers every affected row, even when out only holds the first.
+func (stmt *Statement) ExecuteReturningQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string, out any) (int64, error) {
+	if !stmt.dialect.SupportsReturning() {
+		return 0, fmt.Errorf("%w: %s does not support RETURNING", dberr.ErrNotSupported, stmt.dialect.Name())
+	}
+
+	query += " RETURNING *"
+	if stmt.showSQL {
+		log.Printf("Write Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	var (
+		err  error
+		rows *sql.Rows
+	)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, stmt.args...)
+	} else {
+		rows, err = conn.QueryContext(ctx, query, stmt.args...)
+	}
+	if err != nil {
+		return 0, stmt.translate(err)
+	}
+	defer rows.Close()
+
+	n, err := scanRows(rows, out)
+	if err != nil {
+		return 0, stmt.translate(err)
+	}
+	for rows.Next() {
+		n++
+	}
+	return n, stmt.translate(rows.Err())
+}
+
+func (stmt *Statement) GenerateInsertQuery(doc any) string {
+	cols, args := stmt.insertColumns(doc)
+
+	if stmt.table == "" {
+		stmt.table = isql.GetTableName(doc)
+	}
+
+	placeholders := make([]string, len(cols))
+	for i := range cols {
+		placeholders[i] = stmt.nextPlaceholder()
+	}
+	stmt.args = append(stmt.args, args...)
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		stmt.dialect.QuoteIdent(stmt.table), strings.Join(cols, ", "), strings.Join(placeholders, ", "))
+}
+
+// insertColumns returns the columns written by an INSERT of doc along with
+// their argument values.
+func (stmt *Statement) insertColumns(doc any) ([]string, []any) {
+	stmt.mustColMap = stmt.generateMustColMap()
+	rvalue := reflect.ValueOf(doc)
+	if reflect.TypeOf(doc).Kind() == reflect.Pointer {
+		rvalue = rvalue.Elem()
+	}
+	var cols []string
+	var args []any
+	for idx := 0; idx < rvalue.NumField(); idx++ {
+		field := rvalue.Type().Field(idx)
+		col := isql.GetFieldName(field)
+
+		if !(stmt.allCols || stmt.mustColMap[col] || isql.HasReqTag(field) || !rvalue.Field(idx).IsZero()) {
+			continue
+		}
+
+		cols = append(cols, col)
+		args = append(args, isql.SQLArgValue(field, rvalue.Field(idx)))
+	}
+	return cols, args
+}
+
+// InsertBatches splits docs into batches that can each be written by a single
+// multi-row INSERT: consecutive documents with the same table and column set,
+// capped so no batch exceeds the dialect's MaxParams. Order is preserved.
+func (stmt *Statement) InsertBatches(docs []any) [][]any {
+	var (
+		batches   [][]any
+		key       string
+		size      int
+		maxParams = stmt.dialect.MaxParams()
+	)
+	for _, doc := range docs {
+		cols, _ := stmt.insertColumns(doc)
+		table := stmt.table
+		if table == "" {
+			table = isql.GetTableName(doc)
+		}
+		docKey := table + "\x00" + strings.Join(cols, ",")
+		if len(batches) == 0 || docKey != key || (size+1)*len(cols) > maxParams {
+			batches = append(batches, nil)
+			key, size = docKey, 0
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], doc)
+		size++
+	}
+	return batches
+}
+
+// GenerateInsertManyQuery builds a single INSERT with one VALUES row per
+// document. All docs must share the column set, as grouped by InsertBatches.
+func (stmt *Statement) GenerateInsertManyQuery(docs []any) string {
+	if stmt.table == "" {
+		stmt.table = isql.GetTableName(docs[0])
+	}
+
+	var cols []string
+	rows := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		var args []any
+		cols, args = stmt.insertColumns(doc)
+		placeholders := make([]string, len(cols))
+		for i := range cols {
+			placeholders[i] = stmt.nextPlaceholder()
+		}
+		rows = append(rows, "("+strings.Join(placeholders, ", ")+")")
+		stmt.args = append(stmt.args, args...)
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		stmt.dialect.QuoteIdent(stmt.table), strings.Join(cols, ", "), strings.Join(rows, ", "))
+}
+
+// GenerateUpsertQuery builds an INSERT that updates the existing row when it
+// conflicts on conflictCols. Without updateCols every inserted column except
+// the conflict and pk columns is updated. The update is never empty so that
+// RETURNING yields the id of an existing row as well. It fails with
+// dberr.ErrInvalidQuery when conflictCols is empty or names a blank column.
+func (stmt *Statement) GenerateUpsertQuery(doc any, conflictCols, updateCols []string) (string, error) {
+	if len(conflictCols) == 0 {
+		return "", fmt.Errorf("%w: upsert needs at least one conflict column", dberr.ErrInvalidQuery)
+	}
+	for _, col := range conflictCols {
+		if strings.TrimSpace(col) == "" {
+			return "", fmt.Errorf("%w: upsert conflict column is blank", dberr.ErrInvalidQuery)
+		}
+	}
+	query := stmt.GenerateInsertQuery(doc)
+
+	if len(updateCols) == 0 {
+		skip := map[string]bool{stmt.pkColumn: true}
+		for _, col := range conflictCols {
+			skip[col] = true
+		}
+		cols, _ := stmt.insertColumns(doc)
+		for _, col := range cols {
+			if !skip[col] {
+				updateCols = append(updateCols, col)
+			}
+		}
+	}
+	if len(updateCols) == 0 {
+		updateCols = conflictCols[:1]
+	}
+
+	return query + " " + stmt.dialect.UpsertClause(generatedPK(doc), conflictCols, updateCols), nil
+}
+
+// generatedPK returns the primary key column of doc when the database
+// generates its values, that is an integer key tagged autoincr, or "".
+func generatedPK(doc any) string {
+	pkCol := isql.GetPKColumn(doc)
+	idx, ok := isql.GetDBFieldMap(doc)[pkCol]
+	if !ok {
+		return ""
+	}
+	field := reflect.Indirect(reflect.ValueOf(doc)).Type().Field(idx)
+	if !isIntegerKind(field.Type) {
+		return ""
+	}
+	_, opts, _ := strings.Cut(field.Tag.Get("db"), ",")
+	for _, opt := range strings.Fields(opts) {
+		if strings.EqualFold(opt, "autoincr") {
+			return pkCol
+		}
+	}
+	return ""
+}
+
+// ExecuteInsertQuery runs an INSERT and returns the primary key of the written
+// row. Dialects without RETURNING report the driver's LastInsertId instead,
+// which is 0 when the key was not generated by the database.
+func (stmt *Statement) ExecuteInsertQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string) (any, error) {
+	if !stmt.dialect.SupportsReturning() {
+		result, err := stmt.ExecuteWriteQuery(ctx, conn, tx, query)
+		if err != nil {
+			return nil, err
+		}
+		return result.LastInsertId()
+	}
+
+	pkCol := stmt.pkColumn
+	if pkCol == "" {
+		pkCol = "id"
+	}
+	query += fmt.Sprintf(" RETURNING %s;", pkCol)
+	if stmt.showSQL {
+		log.Printf("Insert Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	var (
+		id  any
+		err error
+	)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, query, stmt.args...).Scan(&id)
+	} else {
+		err = conn.QueryRowContext(ctx, query, stmt.args...).Scan(&id)
+	}
+	return id, stmt.translate(err)
+}
+
+// ExecuteInsertManyQuery runs a query built by GenerateInsertManyQuery and
+// returns the generated primary keys in VALUES order. Dialects without
+// RETURNING derive them from LastInsertId, which is the key of the first row,
+// assuming the rest follow consecutively; Engine.InsertMany checks that with
+// its Driver's ConsecutiveIDs before batching rows.
+// A LastInsertId of 0 means no key was generated and every id is reported as 0.
+func (stmt *Statement) ExecuteInsertManyQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string) ([]any, error) {
+	if !stmt.dialect.SupportsReturning() {
+		result, err := stmt.ExecuteWriteQuery(ctx, conn, tx, query)
+		if err != nil {
+			return nil, err
+		}
+		first, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]any, n)
+		for i := range ids {
+			if first == 0 {
+				ids[i] = int64(0)
+			} else {
+				ids[i] = first + int64(i)
+			}
+		}
+		return ids, nil
+	}
+
+	pkCol := stmt.pkColumn
+	if pkCol == "" {
+		pkCol = "id"
+	}
+	query += fmt.Sprintf(" RETURNING %s;", pkCol)
+	if stmt.showSQL {
+		log.Printf("Insert Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, stmt.args...)
+	} else {
+		rows, err = conn.QueryContext(ctx, query, stmt.args...)
+	}
+	if err != nil {
+		return nil, stmt.translate(err)
+	}
+	defer rows.Close()
+
+	var ids []any
+	for rows.Next() {
+		var id any
+		if err = rows.Scan(&id); err != nil {
+			return nil, stmt.translate(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, stmt.translate(rows.Err())
+}
+
+func (stmt *Statement) ExecuteWriteQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string) (sql.Result, error) {
+	if stmt.showSQL {
+		log.Printf("Write Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	var (
+		result sql.Result
+		err    error
+	)
+	if tx != nil {
+		result, err = tx.ExecContext(ctx, query, stmt.args...)
+	} else {
+		result, err = conn.ExecContext(ctx, query, stmt.args...)
+	}
+	return result, stmt.translate(err)
+}
+
+// translate maps a driver error onto the dberr sentinels through the dialect.
+func (stmt *Statement) translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	return stmt.dialect.TranslateError(err)
+}
+
+// Query runs a raw query on tx when a transaction is active, otherwise on conn.
+func (stmt *Statement) Query(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string, args ...any) (*sql.Rows, error) {
+	if stmt.showSQL {
+		log.Printf("Raw Query: query: %v, args: %v\n", query, args)
+	}
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = conn.QueryContext(ctx, query, args...)
+	}
+	return rows, stmt.translate(err)
+}
+
+// QueryRow runs a raw query expected to return at most one row on tx when a
+// transaction is active, otherwise on conn.
+func (stmt *Statement) QueryRow(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string, args ...any) *sql.Row {
+	if stmt.showSQL {
+		log.Printf("Raw Query: query: %v, args: %v\n", query, args)
+	}
+
+	if tx != nil {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+	return conn.QueryRowContext(ctx, query, args...)
+}
+
+// Exec runs a raw statement on tx when a transaction is active, otherwise on conn.
+func (stmt *Statement) Exec(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string, args ...any) (sql.Result, error) {
+	if stmt.showSQL {
+		log.Printf("Raw Exec: query: %v, args: %v\n", query, args)
+	}
+
+	var (
+		result sql.Result
+		err    error
+	)
+	if tx != nil {
+		result, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		result, err = conn.ExecContext(ctx, query, args...)
+	}
+	return result, stmt.translate(err)
+}
+
+// QueryInto runs a raw query and scans the result into dest, a pointer to a
+// struct or to a slice of structs. Columns are matched to fields as in
+// isql.ScanRow. A struct dest with no matching row yields sql.ErrNoRows.
+func (stmt *Statement) QueryInto(ctx context.Context, conn *sql.DB, tx *sql.Tx, dest any, query string, args ...any) error {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return fmt.Errorf("%w: dest must be a non-nil pointer, got %T", dberr.ErrInvalidQuery, dest)
+	}
+
+	rows, err := stmt.Query(ctx, conn, tx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	n, err := scanRows(rows, dest)
+	if err != nil {
+		return stmt.translate(err)
+	}
+	if n == 0 && val.Elem().Kind() != reflect.Slice {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (stmt *Statement) generateMustColMap() map[string]bool {
+	stmt.mustColMap = map[string]bool{}
+	for _, col := range stmt.mustCols {
+		stmt.mustColMap[col] = true
+	}
+	return stmt.mustColMap
+}
+
+func (stmt *Statement) generateMustFilterColMap() map[string]bool {
+	stmt.mustFilterColMap = map[string]bool{}
+	for _, col := range stmt.mustFilterCols {
+		stmt.mustFilterColMap[col] = true
+	}
+	return stmt.mustFilterColMap
+}
+
+func (stmt *Statement) GenerateUpdateQuery(doc any) string {
+	stmt.mustColMap = stmt.generateMustColMap()
+	var setCols []string
+	var setArgs []any
+	rvalue := reflect.ValueOf(doc)
+	if reflect.TypeOf(doc).Kind() == reflect.Pointer {
+		rvalue = rvalue.Elem()
+	}
+	// Collect SET fields with fresh placeholder numbering starting at 1
+	freshCounter := 0
+	for idx := 0; idx < rvalue.NumField(); idx++ {
+		field := rvalue.Type().Field(idx)
+		col := isql.GetFieldName(field)
+
+		if !(stmt.allCols || stmt.mustColMap[col] || isql.HasReqTag(field) || !rvalue.Field(idx).IsZero()) {
+			continue
+		}
+
+		freshCounter++
+		setCols = append(setCols, col+" = "+stmt.dialect.Placeholder(freshCounter))
+		setArgs = append(setArgs, isql.SQLArgValue(field, rvalue.Field(idx)))
+	}
+
+	if stmt.table == "" {
+		stmt.table = isql.GetTableName(doc)
+	}
+
+	// Shift existing WHERE placeholders past the SET ones ($1 → $(freshCounter+1))
+	stmt.where = stmt.dialect.ShiftPlaceholders(stmt.where, freshCounter)
+
+	// SET args before WHERE args so SQL argument order matches
+	stmt.args = append(setArgs, stmt.args...)
+	stmt.argCounter = freshCounter + stmt.argCounter
+
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		stmt.dialect.QuoteIdent(stmt.table), strings.Join(setCols, ", "), stmt.where)
+}
+
+func (stmt *Statement) GenerateDeleteQuery() string {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", stmt.dialect.QuoteIdent(stmt.table), stmt.where)
+	return query
+}
@@ -0,0 +1,206 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/sql/cond"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// namedDialect uses @pN placeholders and bracket quoting so tests can tell
+// every dialect-rendered fragment apart from the builder's own SQL.
+type namedDialect struct{}
+
+func (namedDialect) Name() string             { return "named" }
+func (namedDialect) Placeholder(n int) string { return fmt.Sprintf("@p%d", n) }
+func (namedDialect) MaxParams() int           { return 4 }
+func (namedDialect) QuoteIdent(name string) string {
+	return "[" + name + "]"
+}
+func (namedDialect) SQLType(reflect.Type, bool) string { return "" }
+func (namedDialect) ShiftPlaceholders(query string, offset int) string {
+	return namedPlaceholder.ReplaceAllStringFunc(query, func(m string) string {
+		n, _ := strconv.Atoi(m[2:])
+		return fmt.Sprintf("@p%d", n+offset)
+	})
+}
+
+var namedPlaceholder = regexp.MustCompile(`@p\d+`)
+
+func (namedDialect) SupportsReturning() bool       { return true }
+func (namedDialect) SupportsWindowFunctions() bool { return true }
+func (namedDialect) UpsertClause(_ string, conflictCols, updateCols []string) string {
+	return fmt.Sprintf("MERGE ON %v SET %v", conflictCols, updateCols)
+}
+func (namedDialect) LimitOffset(limit, offset int64) string {
+	if limit == 0 && offset == 0 {
+		return ""
+	}
+	return fmt.Sprintf("TOP %d SKIP %d", limit, offset)
+}
+func (namedDialect) TranslateError(err error) error { return err }
+
+type doc struct {
+	ID    int64  `db:"id,pk autoincr"`
+	Name  string `db:"name"`
+	Email string `db:"email"`
+}
+
+func newStatement() *Statement {
+	stmt := NewStatement(namedDialect{})
+	return &stmt
+}
+
+func TestStatement_readQueryUsesDialect(t *testing.T) {
+	stmt := newStatement().Table("doc").Where("name = ? AND email = ?", "a", "b").Like("email", "%@e.c").Limit(10)
+
+	query := stmt.GenerateReadQuery(&[]doc{})
+
+	assert.Equal(t, "SELECT * FROM [doc] WHERE name = @p1 AND email = @p2 AND email LIKE @p3 TOP 10 SKIP 0", query)
+	assert.Equal(t, []any{"a", "b", "%@e.c"}, stmt.Args())
+}
+
+func TestStatement_whereCond(t *testing.T) {
+	stmt := newStatement().Table("doc").Where("id > ?", 1).WhereCond(cond.Or(cond.Eq("name", "a"), cond.In("email", "b", "c")))
+
+	query := stmt.GenerateReadQuery(&[]doc{})
+
+	assert.Equal(t, "SELECT * FROM [doc] WHERE id > @p1 AND (name = @p2 OR email IN (@p3, @p4))", query)
+	assert.Equal(t, []any{1, "a", "b", "c"}, stmt.Args())
+}
+
+func TestStatement_subqueriesAreRenumbered(t *testing.T) {
+	sub := newStatement().Table("post").Columns("user_id").Where("title = ?", "t").GenerateSubquery(nil)
+	assert.Equal(t, "SELECT user_id FROM [post] WHERE title = @p1", sub.Query)
+
+	stmt := newStatement().Table("doc").
+		Where("name = ?", "a").
+		In("id", sub).
+		Exists("SELECT 1 FROM team WHERE team.id = doc.team_id AND team.name = ?", "x").
+		Having("COUNT(*) > ?", 1).
+		With("recent", "SELECT * FROM post WHERE id > ?", 10).
+		WithRecursive("tree(id)", newStatement().Table("node").Columns("id").Where("root = ?", true).GenerateSubquery(nil)).
+		From("SELECT * FROM doc WHERE email = ?", "d", "e")
+
+	query := stmt.GenerateReadQuery(&[]doc{})
+
+	assert.Equal(t, "WITH RECURSIVE recent AS (SELECT * FROM post WHERE id > @p1), tree(id) AS (SELECT id FROM [node] WHERE root = @p2) "+
+		"SELECT * FROM (SELECT * FROM doc WHERE email = @p3) d "+
+		"WHERE name = @p4 AND id IN (SELECT user_id FROM [post] WHERE title = @p5) "+
+		"AND EXISTS (SELECT 1 FROM team WHERE team.id = doc.team_id AND team.name = @p6) HAVING COUNT(*) > @p7", query)
+	assert.Equal(t, []any{10, true, "e", "a", "t", "x", 1}, stmt.Args())
+
+	stmt.From("SELECT * FROM doc", "d")
+	assert.Equal(t, []any{10, true, "a", "t", "x", 1}, stmt.Args(), "a replaced FROM drops its args")
+}
+
+func TestStatement_subqueriesRenumberJoins(t *testing.T) {
+	base := newStatement().Table("doc").Join("team", "team.id = doc.team_id AND team.name = ?", "x").Where("name = ?", "a")
+	stmt := *base
+	stmt.With("recent", "SELECT * FROM post WHERE id > ?", 10)
+
+	query := stmt.GenerateReadQuery(&[]doc{})
+
+	assert.Equal(t, "WITH recent AS (SELECT * FROM post WHERE id > @p1) "+
+		"SELECT * FROM [doc] JOIN [team] ON team.id = doc.team_id AND team.name = @p2 WHERE name = @p3", query)
+	assert.Equal(t, []any{10, "x", "a"}, stmt.Args())
+
+	stmt.From("SELECT * FROM doc WHERE email = ?", "d", "e")
+	stmt.From("SELECT * FROM doc", "d")
+	assert.Equal(t, "JOIN [team] ON team.id = doc.team_id AND team.name = @p2", stmt.joins[0], "a replaced FROM shifts the joins back")
+	assert.Equal(t, "JOIN [team] ON team.id = doc.team_id AND team.name = @p1", base.joins[0], "copies keep their own numbering")
+}
+
+func TestStatement_updateShiftsWherePlaceholders(t *testing.T) {
+	stmt := newStatement().Table("doc").Where("id = ?", 7)
+
+	query := stmt.GenerateUpdateQuery(doc{Name: "n", Email: "e"})
+
+	assert.Equal(t, "UPDATE [doc] SET name = @p1, email = @p2 WHERE id = @p3", query)
+	assert.Equal(t, []any{"n", "e", 7}, stmt.Args())
+}
+
+func TestStatement_upsertAndBatchesUseDialect(t *testing.T) {
+	stmt := newStatement().Table("doc").PKColumn("id")
+
+	query, err := stmt.GenerateUpsertQuery(doc{Name: "n", Email: "e"}, []string{"email"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO [doc] (name, email) VALUES (@p1, @p2) MERGE ON [email] SET [name]", query)
+
+	docs := []any{doc{Name: "a", Email: "a"}, doc{Name: "b", Email: "b"}, doc{Name: "c", Email: "c"}}
+	assert.Len(t, newStatement().InsertBatches(docs), 2, "MaxParams of 4 fits two rows of two columns")
+}
+
+func TestStatement_upsertRejectsMissingConflictColumns(t *testing.T) {
+	for name, conflictCols := range map[string][]string{
+		"empty": nil,
+		"blank": {""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stmt := newStatement().Table("doc").PKColumn("id")
+			_, err := stmt.GenerateUpsertQuery(doc{Email: "e"}, conflictCols, nil)
+			assert.ErrorIs(t, err, dberr.ErrInvalidQuery)
+		})
+	}
+}
+
+func TestStatement_pageQueriesShareState(t *testing.T) {
+	stmt := newStatement().Table("doc").Join("team", "team.id = doc.team_id").Where("name = ?", "a").OrderBy("id")
+
+	pageQuery, countQuery, windowed := stmt.GeneratePageQueries(&[]doc{}, 3, 10)
+
+	assert.True(t, windowed)
+	assert.Equal(t, "SELECT *, COUNT(*) OVER() AS styx_total FROM [doc] JOIN [team] ON team.id = doc.team_id WHERE name = @p1 ORDER BY id ASC TOP 10 SKIP 20", pageQuery)
+	assert.Equal(t, "SELECT COUNT(*) FROM (SELECT 1 FROM [doc] JOIN [team] ON team.id = doc.team_id WHERE name = @p1) styx_rows", countQuery)
+	assert.Equal(t, []any{"a"}, stmt.Args())
+
+	_, _, windowed = newStatement().Table("doc").Distinct().GeneratePageQueries(&[]doc{}, 1, 10)
+	assert.False(t, windowed, "the window would count rows before DISTINCT")
+
+	unwindowed := NewStatement(unwindowedDialect{})
+	pageQuery, _, windowed = unwindowed.Table("doc").GeneratePageQueries(&[]doc{}, 1, 10)
+	assert.False(t, windowed)
+	assert.NotContains(t, pageQuery, "OVER()")
+}
+
+// unwindowedDialect is namedDialect without window functions.
+type unwindowedDialect struct{ namedDialect }
+
+func (unwindowedDialect) SupportsWindowFunctions() bool { return false }
+
+func TestKeysetPredicate(t *testing.T) {
+	asc := []OrderTerm{{Col: "created_at"}, {Col: "id"}}
+	mixed := []OrderTerm{{Col: "score", Desc: true}, {Col: "id"}}
+	values := []any{10, 7}
+
+	pred, args := KeysetPredicate(asc, values, true)
+	assert.Equal(t, "(created_at, id) > (?, ?)", pred)
+	assert.Equal(t, []any{10, 7}, args)
+
+	pred, _ = KeysetPredicate(asc, values, false)
+	assert.Equal(t, "(created_at, id) < (?, ?)", pred)
+
+	pred, args = KeysetPredicate(mixed, values, true)
+	assert.Equal(t, "(score < ? OR (score = ? AND id > ?))", pred)
+	assert.Equal(t, []any{10, 10, 7}, args)
+
+	pred, _ = KeysetPredicate(mixed, values, false)
+	assert.Equal(t, "(score > ? OR (score = ? AND id < ?))", pred)
+
+	pred, _ = KeysetPredicate([]OrderTerm{{Col: "id", Desc: true}}, []any{3}, true)
+	assert.Equal(t, "id < ?", pred)
+}
+
+func TestStatement_keysetOrderAddsTieBreaker(t *testing.T) {
+	stmt := newStatement().OrderBy("score", "desc")
+	assert.Equal(t, []OrderTerm{{Col: "score", Desc: true}, {Col: "id"}}, stmt.KeysetOrder("id"))
+
+	stmt = newStatement().OrderBy("doc.id", "DESC")
+	assert.Equal(t, []OrderTerm{{Col: "doc.id", Desc: true}}, stmt.KeysetOrder("id"))
+}
@@ -0,0 +1,46 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// ExecuteStreamQuery runs a read query and returns its rows unscanned, so
+// they can be read one at a time. The caller must close them.
+func (stmt *Statement) ExecuteStreamQuery(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string) (isql.RowScanner, error) {
+	if stmt.showSQL {
+		log.Printf("Stream Query: query: %v, args: %v\n", query, stmt.args)
+	}
+
+	rows, err := stmt.query(ctx, conn, tx, query)
+	if err != nil {
+		return nil, stmt.translate(err)
+	}
+	return &rowStream{rows: rows, translate: stmt.translate}, nil
+}
+
+// rowStream is an isql.RowScanner over *sql.Rows that translates driver
+// errors like the rest of the statement's queries.
+type rowStream struct {
+	rows      *sql.Rows
+	translate func(error) error
+}
+
+func (rs *rowStream) Next() bool {
+	return rs.rows.Next()
+}
+
+func (rs *rowStream) Scan(doc any) error {
+	return rs.translate(isql.ScanRow(rs.rows, doc))
+}
+
+func (rs *rowStream) Err() error {
+	return rs.translate(rs.rows.Err())
+}
+
+func (rs *rowStream) Close() error {
+	return rs.rows.Close()
+}
@@ -0,0 +1,162 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/cond"
+)
+
+// GenerateSubquery renders the SELECT built so far, with placeholders
+// numbered from 1, for embedding in another statement. doc, which may be
+// nil, names the table when Table was not called.
+func (stmt *Statement) GenerateSubquery(doc any) isql.Subquery {
+	if stmt.table == "" && doc != nil {
+		stmt.table = isql.GetTableName(doc)
+	}
+	if stmt.softDeleteCol != "" && !stmt.withDeleted {
+		stmt.where = stmt.AddWhereClause(stmt.softDeleteCol + " IS NULL")
+	}
+	args := make([]any, len(stmt.args))
+	copy(args, stmt.args)
+	return isql.Subquery{Query: stmt.renderSelect(stmt.selectColumns(), true), Args: args}
+}
+
+// subquery returns the SQL of sub, with placeholders numbered from 1, and
+// its args. sub is an isql.Engine, an isql.Subquery, a cond.Cond or
+// a string with ? placeholders for args.
+func (stmt *Statement) subquery(sub any, args []any) (string, []any) {
+	switch s := sub.(type) {
+	case isql.Engine:
+		q := s.Subquery()
+		return q.Query, q.Args
+	case isql.Subquery:
+		return s.Query, s.Args
+	case cond.Cond:
+		return stmt.numberFrom1(s.SQL(), len(s.Args())), s.Args()
+	case string:
+		return stmt.numberFrom1(s, len(args)), args
+	}
+	panic(fmt.Sprintf("styx: a subquery must be an Engine, Subquery, cond.Cond or string, got %T", sub))
+}
+
+// numberFrom1 rewrites the first n ? markers of query to the dialect's
+// placeholders, numbered from 1.
+func (stmt *Statement) numberFrom1(query string, n int) string {
+	for i := 1; i <= n; i++ {
+		query = strings.Replace(query, "?", stmt.dialect.Placeholder(i), 1)
+	}
+	return query
+}
+
+// embed renumbers a subquery's placeholders to follow the statement's own
+// and appends its args, for subqueries inside the WHERE clause.
+func (stmt *Statement) embed(sub any, args []any) string {
+	query, subArgs := stmt.subquery(sub, args)
+	query = stmt.dialect.ShiftPlaceholders(query, stmt.argCounter)
+	stmt.argCounter += len(subArgs)
+	stmt.args = append(stmt.args, subArgs...)
+	return query
+}
+
+// embedBefore places a subquery's args at position pos, ahead of the JOIN,
+// WHERE and HAVING args, for parts rendered before them (WITH, FROM). The
+// subquery and every later part are renumbered to match.
+func (stmt *Statement) embedBefore(pos int, sub any, args []any) string {
+	query, subArgs := stmt.subquery(sub, args)
+	n := len(subArgs)
+	query = stmt.dialect.ShiftPlaceholders(query, pos)
+
+	if n > 0 {
+		stmt.from = stmt.dialect.ShiftPlaceholders(stmt.from, n)
+		stmt.shiftJoins(n)
+		stmt.where = stmt.dialect.ShiftPlaceholders(stmt.where, n)
+		stmt.having = stmt.dialect.ShiftPlaceholders(stmt.having, n)
+		merged := make([]any, 0, len(stmt.args)+n)
+		merged = append(merged, stmt.args[:pos]...)
+		merged = append(merged, subArgs...)
+		stmt.args = append(merged, stmt.args[pos:]...)
+		stmt.argCounter += n
+	}
+	return query
+}
+
+// shiftJoins renumbers the placeholders of the JOIN conditions by n. It
+// builds a new slice, since copies of the statement share the old one.
+func (stmt *Statement) shiftJoins(n int) {
+	joins := make([]string, len(stmt.joins))
+	for i, join := range stmt.joins {
+		joins[i] = stmt.dialect.ShiftPlaceholders(join, n)
+	}
+	stmt.joins = joins
+}
+
+// InSubquery adds col IN (subquery) to the WHERE clause.
+func (stmt *Statement) InSubquery(col string, sub any, args ...any) *Statement {
+	stmt.where = stmt.AddWhereClause(fmt.Sprintf("%s IN (%s)", col, stmt.embed(sub, args)))
+	return stmt
+}
+
+// From selects from the subquery under alias instead of from the table.
+// Calling it again replaces the previous subquery and its args.
+func (stmt *Statement) From(sub any, alias string, args ...any) *Statement {
+	if n := stmt.fromArgs; n > 0 {
+		pos := stmt.cteArgs
+		stmt.args = append(stmt.args[:pos:pos], stmt.args[pos+n:]...)
+		stmt.shiftJoins(-n)
+		stmt.where = stmt.dialect.ShiftPlaceholders(stmt.where, -n)
+		stmt.having = stmt.dialect.ShiftPlaceholders(stmt.having, -n)
+		stmt.argCounter -= n
+	}
+	stmt.from = ""
+
+	before := len(stmt.args)
+	query := stmt.embedBefore(stmt.cteArgs, sub, args)
+	stmt.fromArgs = len(stmt.args) - before
+	stmt.from = fmt.Sprintf("(%s) %s", query, alias)
+	if stmt.table == "" {
+		stmt.table = alias
+	}
+	return stmt
+}
+
+// With adds the common table expression name AS (sub) to the SELECT. name
+// may carry a column list, as in "tree(id, parent_id)".
+func (stmt *Statement) With(name string, sub any, args ...any) *Statement {
+	before := len(stmt.args)
+	query := stmt.embedBefore(stmt.cteArgs, sub, args)
+	stmt.cteArgs += len(stmt.args) - before
+	stmt.ctes = append(stmt.ctes, fmt.Sprintf("%s AS (%s)", name, query))
+	return stmt
+}
+
+// WithRecursive is With for a CTE that refers to itself; the query then
+// starts with WITH RECURSIVE.
+func (stmt *Statement) WithRecursive(name string, sub any, args ...any) *Statement {
+	stmt.recursive = true
+	return stmt.With(name, sub, args...)
+}
+
+// CheckSelectOnlyClauses fails with dberr.ErrNotSupported when With or From
+// was called, since UPDATE and DELETE render neither and their args would
+// bind to the wrong placeholders.
+func (stmt *Statement) CheckSelectOnlyClauses() error {
+	if len(stmt.ctes) > 0 || stmt.from != "" {
+		return fmt.Errorf("%w: With and From apply to SELECT statements only", dberr.ErrNotSupported)
+	}
+	return nil
+}
+
+// renderWith returns the WITH clause, followed by a space, or "".
+func (stmt *Statement) renderWith() string {
+	if len(stmt.ctes) == 0 {
+		return ""
+	}
+	keyword := "WITH "
+	if stmt.recursive {
+		keyword = "WITH RECURSIVE "
+	}
+	return keyword + strings.Join(stmt.ctes, ", ") + " "
+}
@@ -0,0 +1,172 @@
+// Package cond builds WHERE conditions that nest with AND, OR and NOT:
+//
+//	db.WhereCond(cond.And(
+//		cond.Eq("active", true),
+//		cond.Or(cond.Gt("age", 18), cond.IsNull("age")),
+//	)).FindMany(ctx, &users)
+//
+// Conditions render with ? placeholders, which the engine numbers for its
+// dialect ($1 on PostgreSQL) when they are passed to WhereCond. The typed
+// columns of sql.Query build the same conditions.
+package cond
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Cond is a WHERE condition and its bind arguments, passed to
+// Engine.WhereCond.
+type Cond struct {
+	expr string
+	args []any
+	cols []string
+	// composite is set for AND/OR lists, which need parentheses when nested.
+	composite bool
+}
+
+// SQL returns the condition with ? placeholders, parenthesised when it
+// combines several conditions so it can be ANDed with others as is.
+func (c Cond) SQL() string { return c.wrapped() }
+
+// Args returns the bind arguments in placeholder order.
+func (c Cond) Args() []any { return c.args }
+
+// Columns returns the columns the condition names, leaving out those in Expr
+// fragments, so that callers can check them against a schema.
+func (c Cond) Columns() []string { return c.cols }
+
+// Expr wraps a raw SQL fragment with ? placeholders.
+func Expr(sql string, args ...any) Cond {
+	return Cond{expr: sql, args: args, composite: true}
+}
+
+// Eq matches rows where col equals v, or IS NULL when v is nil.
+func Eq(col string, v any) Cond {
+	if isNil(v) {
+		return IsNull(col)
+	}
+	return compare(col, "=", v)
+}
+
+// Neq matches rows where col differs from v, or IS NOT NULL when v is nil.
+func Neq(col string, v any) Cond {
+	if isNil(v) {
+		return IsNotNull(col)
+	}
+	return compare(col, "<>", v)
+}
+
+// Gt matches rows where col is greater than v.
+func Gt(col string, v any) Cond { return compare(col, ">", v) }
+
+// Gte matches rows where col is greater than or equal to v.
+func Gte(col string, v any) Cond { return compare(col, ">=", v) }
+
+// Lt matches rows where col is less than v.
+func Lt(col string, v any) Cond { return compare(col, "<", v) }
+
+// Lte matches rows where col is less than or equal to v.
+func Lte(col string, v any) Cond { return compare(col, "<=", v) }
+
+// Like matches rows where col matches the LIKE pattern.
+func Like(col, pattern string) Cond { return compare(col, "LIKE", pattern) }
+
+// Between matches rows where col lies between lo and hi, inclusive.
+func Between(col string, lo, hi any) Cond {
+	return Cond{expr: col + " BETWEEN ? AND ?", args: []any{lo, hi}, cols: []string{col}}
+}
+
+// IsNull matches rows where col is NULL.
+func IsNull(col string) Cond { return Cond{expr: col + " IS NULL", cols: []string{col}} }
+
+// IsNotNull matches rows where col is not NULL.
+func IsNotNull(col string) Cond { return Cond{expr: col + " IS NOT NULL", cols: []string{col}} }
+
+// In matches rows where col equals any of values. A single slice argument
+// is expanded. An empty list matches nothing.
+func In(col string, values ...any) Cond { return in(col, "IN", "1 = 0", values) }
+
+// NotIn matches rows where col equals none of values. A single slice
+// argument is expanded. An empty list matches everything.
+func NotIn(col string, values ...any) Cond { return in(col, "NOT IN", "1 = 1", values) }
+
+// And matches rows satisfying every condition. With none it matches everything.
+func And(conds ...Cond) Cond { return join(" AND ", "1 = 1", conds) }
+
+// Or matches rows satisfying at least one condition. With none it matches nothing.
+func Or(conds ...Cond) Cond { return join(" OR ", "1 = 0", conds) }
+
+// Not negates c.
+func Not(c Cond) Cond {
+	return Cond{expr: "NOT (" + c.expr + ")", args: c.args, cols: c.cols}
+}
+
+func compare(col, op string, v any) Cond {
+	return Cond{expr: col + " " + op + " ?", args: []any{v}, cols: []string{col}}
+}
+
+func in(col, op, empty string, values []any) Cond {
+	if len(values) == 1 {
+		values = expand(values[0], values)
+	}
+	if len(values) == 0 {
+		return Cond{expr: empty, cols: []string{col}}
+	}
+	marks := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	return Cond{expr: col + " " + op + " (" + marks + ")", args: values, cols: []string{col}}
+}
+
+// join combines conds under sep, parenthesising composite operands so
+// that precedence follows the nesting of the calls.
+func join(sep, empty string, conds []Cond) Cond {
+	switch len(conds) {
+	case 0:
+		return Cond{expr: empty}
+	case 1:
+		return conds[0]
+	}
+	out := Cond{composite: true}
+	exprs := make([]string, len(conds))
+	for i, c := range conds {
+		exprs[i] = c.wrapped()
+		out.args = append(out.args, c.args...)
+		out.cols = append(out.cols, c.cols...)
+	}
+	out.expr = strings.Join(exprs, sep)
+	return out
+}
+
+// wrapped returns the condition parenthesised when it is composite.
+func (c Cond) wrapped() string {
+	if c.composite {
+		return "(" + c.expr + ")"
+	}
+	return c.expr
+}
+
+// expand returns the elements of v when it is a slice other than []byte,
+// and fallback otherwise.
+func expand(v any, fallback []any) []any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return fallback
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
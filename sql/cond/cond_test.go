@@ -0,0 +1,45 @@
+package cond
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCond(t *testing.T) {
+	tests := []struct {
+		name string
+		cond Cond
+		sql  string
+		args []any
+	}{
+		{"eq", Eq("name", "a"), "name = ?", []any{"a"}},
+		{"eq nil", Eq("deleted_at", nil), "deleted_at IS NULL", nil},
+		{"neq nil", Neq("deleted_at", (*int)(nil)), "deleted_at IS NOT NULL", nil},
+		{"between", Between("age", 18, 30), "age BETWEEN ? AND ?", []any{18, 30}},
+		{"in", In("id", 1, 2), "id IN (?, ?)", []any{1, 2}},
+		{"in slice", In("id", []int64{1, 2}), "id IN (?, ?)", []any{int64(1), int64(2)}},
+		{"in bytes", In("hash", []byte("ab")), "hash IN (?)", []any{[]byte("ab")}},
+		{"in empty", In("id"), "1 = 0", nil},
+		{"not in empty", NotIn("id", []string{}), "1 = 1", nil},
+		{"and of one", And(Gt("age", 1)), "age > ?", []any{1}},
+		{
+			"nested",
+			And(Eq("a", 1), Or(Lt("b", 2), IsNull("b")), Not(Or(Eq("c", 3), Eq("d", 4)))),
+			"(a = ? AND (b < ? OR b IS NULL) AND NOT (c = ? OR d = ?))",
+			[]any{1, 2, 3, 4},
+		},
+		{"or of ands", Or(And(Eq("a", 1), Eq("b", 2)), Expr("c > d")), "((a = ? AND b = ?) OR (c > d))", []any{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.sql, tt.cond.SQL())
+			assert.Equal(t, tt.args, tt.cond.Args())
+		})
+	}
+}
+
+func TestCond_Columns(t *testing.T) {
+	c := And(Eq("a", 1), Not(Or(In("b"), IsNull("c"))), Expr("d > e"))
+	assert.Equal(t, []string{"a", "b", "c"}, c.Columns())
+}
@@ -3,6 +3,9 @@ package sql
 import (
 	"context"
 	"database/sql"
+
+	"github.com/masudur-rahman/styx/pagination"
+	"github.com/masudur-rahman/styx/sql/cond"
 )
 
 // Engine is the unified SQL database interface. All methods return Engine to
@@ -10,6 +13,10 @@ import (
 type Engine interface {
 	// BeginTx starts a new transaction and returns a transaction-scoped Engine.
 	BeginTx(ctx context.Context) (Engine, error)
+	// BeginTxWithOptions is BeginTx with an isolation level and read-only flag.
+	BeginTxWithOptions(ctx context.Context, opts *sql.TxOptions) (Engine, error)
+	// RunInTx runs fn in a transaction, committing on success, rolling back on error or panic and retrying transient failures.
+	RunInTx(ctx context.Context, opts TxOptions, fn func(Engine) error) error
 	// Commit commits the current transaction.
 	Commit() error
 	// Rollback aborts the current transaction.
@@ -20,10 +27,12 @@ type Engine interface {
 
 	// ID filters by primary key value.
 	ID(id any) Engine
-	// In adds an IN clause for the given column.
+	// In adds an IN clause for the given column. A single Engine or Subquery value becomes col IN (subquery).
 	In(col string, values ...any) Engine
-	// Where adds a parameterised WHERE condition.
+	// Where adds a parameterised WHERE condition, ANDed with the others.
 	Where(cond string, args ...any) Engine
+	// WhereCond adds a condition built with package cond, ANDed with the others.
+	WhereCond(c cond.Cond) Engine
 	// Columns restricts SELECT to the named columns.
 	Columns(cols ...string) Engine
 	// AllCols forces all columns to be included in UPDATE.
@@ -53,10 +62,19 @@ type Engine interface {
 	Like(col string, pattern string) Engine
 	// NotLike adds a NOT LIKE pattern condition.
 	NotLike(col string, pattern string) Engine
-	// Exists adds an EXISTS subquery condition.
-	Exists(subquery string, args ...any) Engine
+	// Exists adds an EXISTS subquery condition: raw SQL with ? placeholders and args, or an Engine or Subquery.
+	Exists(subquery any, args ...any) Engine
 	// NotExists adds a NOT EXISTS subquery condition.
-	NotExists(subquery string, args ...any) Engine
+	NotExists(subquery any, args ...any) Engine
+	// From selects from subquery (an Engine, Subquery or raw SQL with args) under alias instead of the table.
+	From(subquery any, alias string, args ...any) Engine
+	// With adds the common table expression name AS (subquery) in front of the SELECT.
+	With(name string, subquery any, args ...any) Engine
+	// WithRecursive is With for a self-referencing CTE, rendered as WITH RECURSIVE.
+	WithRecursive(name string, subquery any, args ...any) Engine
+	// Subquery renders the SELECT built so far for In, Exists, From or With of another query on the
+	// same engine. proto, when given, supplies the table and soft-delete column as in FindMany.
+	Subquery(proto ...any) Subquery
 	// Count adds a COUNT aggregate expression.
 	Count(col string, alias ...string) Engine
 	// Sum adds a SUM aggregate expression.
@@ -69,6 +87,10 @@ type Engine interface {
 	Max(col string, alias ...string) Engine
 	// Paginate sets LIMIT and OFFSET based on 1-indexed page and per-page count.
 	Paginate(page, perPage int64) Engine
+	// After makes FindCursor return the rows following cursor, a Next cursor from a previous page.
+	After(cursor string) Engine
+	// Before makes FindCursor return the rows preceding cursor, a Prev cursor from a previous page.
+	Before(cursor string) Engine
 
 	// Join adds a JOIN clause.
 	Join(table, condition string) Engine
@@ -81,6 +103,8 @@ type Engine interface {
 
 	// WithDeleted includes soft-deleted rows in query results.
 	WithDeleted() Engine
+	// SoftDeleteCol names the soft-delete column for operations without a struct filter or document to detect it from.
+	SoftDeleteCol(col string) Engine
 	// ForceDelete permanently deletes matching rows, bypassing soft delete.
 	ForceDelete(ctx context.Context, filter ...any) error
 	// Restore clears the soft-delete marker on matching rows.
@@ -92,28 +116,88 @@ type Engine interface {
 	FindOne(ctx context.Context, document any, filter ...any) (bool, error)
 	// FindMany retrieves all matching rows into documents (must be a pointer to a slice).
 	FindMany(ctx context.Context, documents any, filter ...any) error
+	// FindPage retrieves one page of matching rows into documents (a pointer to a slice) and
+	// returns the pagination metadata, counting the total from the same WHERE/JOIN state.
+	FindPage(ctx context.Context, documents any, page, perPage int64, filter ...any) (pagination.Paginator, error)
+	// FindCursor retrieves the Limit rows after (or before) the After/Before cursor in ORDER BY order,
+	// with the primary key as a tie-breaker, and returns the cursors of the neighbouring pages.
+	FindCursor(ctx context.Context, documents any, filter ...any) (pagination.CursorPage, error)
+	// Rows runs the read query for proto's struct type and returns the matching rows unscanned,
+	// to be read one at a time instead of loaded into a slice. The caller must Close them.
+	Rows(ctx context.Context, proto any, filter ...any) (RowScanner, error)
+	// Iterate calls fn with every matching row, scanned into a new value of proto's struct type
+	// and passed as a pointer, stopping at the first error fn returns.
+	Iterate(ctx context.Context, proto any, fn func(row any) error, filter ...any) error
+	// Scalar scans the single value the statement selects, such as one Count or Sum, into dest.
+	// A struct filter also names the table and soft-delete column; it returns sql.ErrNoRows when nothing matches.
+	Scalar(ctx context.Context, dest any, filter ...any) error
+	// FindMaps returns the selected rows as column-name maps, for ad-hoc column sets and aggregates.
+	FindMaps(ctx context.Context, filter ...any) ([]map[string]any, error)
+	// CountRows returns the number of rows the statement selects (groups, when grouped).
+	CountRows(ctx context.Context, filter ...any) (int64, error)
 
 	// InsertOne inserts document and returns the generated primary key.
 	InsertOne(ctx context.Context, document any) (id any, err error)
 	// InsertMany inserts multiple documents and returns their generated primary keys.
 	InsertMany(ctx context.Context, documents []any) ([]any, error)
+	// Upsert inserts document or updates the row it conflicts with on conflictCols
+	// (default: the pk when set, else the uqs/uq columns). updateCols defaults to
+	// every inserted non-key column. Returns the primary key of the written row.
+	Upsert(ctx context.Context, document any, conflictCols []string, updateCols ...string) (id any, err error)
 
 	// UpdateOne updates the row identified by ID() with non-zero fields from document.
 	UpdateOne(ctx context.Context, document any) error
+	// UpdateMany updates every row matching the WHERE clause and returns the affected row count.
+	UpdateMany(ctx context.Context, document any) (int64, error)
 
 	// DeleteOne deletes a single matching row (soft or hard delete depending on schema).
 	DeleteOne(ctx context.Context, filter ...any) error
+	// DeleteMany deletes every matching row (soft or hard) and returns the affected row count.
+	DeleteMany(ctx context.Context, filter ...any) (int64, error)
+
+	// UpdateOneReturning is UpdateOne that scans the updated rows (RETURNING *) into out,
+	// a pointer to a struct or slice, and returns the affected row count.
+	UpdateOneReturning(ctx context.Context, document any, out any) (int64, error)
+	// DeleteOneReturning is DeleteOne that scans the deleted rows into out. The soft-delete column is
+	// detected from the filter struct as in DeleteOne, or from out when no filter is given.
+	DeleteOneReturning(ctx context.Context, out any, filter ...any) (int64, error)
+	// ForceDeleteReturning is ForceDelete that scans the deleted rows into out.
+	ForceDeleteReturning(ctx context.Context, out any, filter ...any) (int64, error)
+	// RestoreReturning is Restore that scans the restored rows into out, detecting the soft-delete
+	// column like DeleteOneReturning.
+	RestoreReturning(ctx context.Context, out any, filter ...any) (int64, error)
 
-	// Query executes a raw SQL query and returns the result rows.
+	// Query executes a raw SQL query, inside the active transaction if any, and returns the result rows.
 	Query(ctx context.Context, query string, args ...any) (*sql.Rows, error)
-	// Exec executes a raw SQL statement (INSERT/UPDATE/DELETE) and returns the result.
+	// QueryRow executes a raw SQL query expected to return at most one row, inside the active transaction if any.
+	QueryRow(ctx context.Context, query string, args ...any) *sql.Row
+	// QueryInto executes a raw SQL query and scans the rows into dest, a pointer to a struct or a slice of structs.
+	QueryInto(ctx context.Context, dest any, query string, args ...any) error
+	// Exec executes a raw SQL statement (INSERT/UPDATE/DELETE), inside the active transaction if any, and returns the result.
 	Exec(ctx context.Context, query string, args ...any) (sql.Result, error)
 
-	// Sync creates or alters tables to match the provided struct schemas.
+	// Sync creates or alters tables to match the provided struct schemas. Existing columns are only
+	// tightened or widened, never loosened; Plan reports the rest.
 	Sync(ctx context.Context, tables ...any) error
+	// Plan reports how the live schema differs from the provided struct schemas, without changing it.
+	// Render the result with PlanSQL to review the DDL.
+	Plan(ctx context.Context, tables ...any) ([]SchemaChange, error)
 	// DropTable drops the named table from the database.
 	DropTable(ctx context.Context, name string) error
 
+	// Dialect names the SQL dialect the engine speaks: "postgres", "sqlite" or "mysql",
+	// or "supabase" for the PostgREST engine, which speaks none.
+	Dialect() string
+
 	// Close releases the underlying database connection.
 	Close() error
 }
+
+// Subquery is a SELECT rendered by Engine.Subquery for use inside another
+// query of the same engine through In, Exists, From or With. Its
+// placeholders are numbered from 1 in the engine's dialect; the outer
+// statement renumbers them and merges Args where the subquery lands.
+type Subquery struct {
+	Query string
+	Args  []any
+}
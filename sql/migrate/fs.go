@@ -0,0 +1,102 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// FromFS loads SQL migrations from the files in dir of fsys, typically an
+// embed.FS. Files are named <version>_<name>.up.sql and, optionally,
+// <version>_<name>.down.sql:
+//
+//	//go:embed migrations/*.sql
+//	var files embed.FS
+//
+//	migrations, err := migrate.FromFS(files, "migrations")
+//
+// Each file is executed as a single statement batch; MySQL needs
+// multiStatements=true in the DSN for files with several statements.
+func FromFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	var order []int64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		version, title, up, err := parseFileName(name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: title}
+			byVersion[version] = mig
+			order = append(order, version)
+		} else if mig.Name != title {
+			return nil, fmt.Errorf("migration %d has files named %q and %q", version, mig.Name, title)
+		}
+
+		fn := execSQL(string(data))
+		if up {
+			mig.Up = fn
+		} else {
+			mig.Down = fn
+		}
+	}
+
+	out := make([]Migration, 0, len(order))
+	for _, v := range order {
+		if byVersion[v].Up == nil {
+			return nil, fmt.Errorf("migration %d %s has no .up.sql file", v, byVersion[v].Name)
+		}
+		out = append(out, *byVersion[v])
+	}
+	return out, nil
+}
+
+// parseFileName splits "0003_add_email.up.sql" into 3, "add_email" and up.
+func parseFileName(name string) (version int64, title string, up bool, err error) {
+	base := strings.TrimSuffix(name, ".sql")
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		base, up = strings.TrimSuffix(base, ".up"), true
+	case strings.HasSuffix(base, ".down"):
+		base = strings.TrimSuffix(base, ".down")
+	default:
+		return 0, "", false, fmt.Errorf("migration file %s: name must end in .up.sql or .down.sql", name)
+	}
+
+	num, title, _ := strings.Cut(base, "_")
+	version, err = strconv.ParseInt(num, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", false, fmt.Errorf("migration file %s: name must start with a positive version number", name)
+	}
+	return version, title, up, nil
+}
+
+func execSQL(query string) Func {
+	return func(ctx context.Context, db isql.Engine) error {
+		if strings.TrimSpace(query) == "" {
+			return nil
+		}
+		_, err := db.Exec(ctx, query)
+		return err
+	}
+}
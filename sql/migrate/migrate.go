@@ -0,0 +1,345 @@
+// Package migrate applies ordered, versioned schema migrations and records
+// them in the styx_migrations table.
+//
+// Migrations are Go functions or SQL files (see FromFS). Every step runs in
+// its own transaction that first takes a migration lock, so concurrent
+// deployments apply each version exactly once:
+//
+//	m, err := migrate.New(db, migrations...)
+//	err = m.Up(ctx)
+//
+// On PostgreSQL and SQLite a step is atomic: when it fails, its schema
+// changes are rolled back along with its record row. MySQL commits every
+// DDL statement implicitly, so a step that fails after one leaves the
+// schema partly changed and no record of the version; fix the schema by
+// hand before running it again, or keep MySQL migrations to one DDL
+// statement each.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// TableName is the bookkeeping table holding the applied versions.
+const TableName = "styx_migrations"
+
+// lockKey identifies the migration lock on PostgreSQL and MySQL.
+const lockKey = 7_273_796_179
+
+// lockRetries bounds how often a step is retried on SQLite while another
+// migrator holds the write lock. With isql.DefaultBackoff this waits for
+// about 30 seconds in total.
+const lockRetries = 36
+
+// ErrNoDown is returned when rolling back a migration without a Down step.
+var ErrNoDown = errors.New("migration has no down step")
+
+// ErrUnknownVersion is returned when a version is neither defined nor 0.
+var ErrUnknownVersion = errors.New("unknown migration version")
+
+// Func is one direction of a migration. db is the transaction the step
+// runs in.
+type Func func(ctx context.Context, db isql.Engine) error
+
+// Migration is one schema change. Versions must be positive and unique;
+// they are applied in ascending order.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      Func
+	Down    Func
+}
+
+// Status describes a migration and whether it has been applied.
+// Missing marks a version recorded as applied that no Migration defines.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool
+}
+
+// record is a row of the bookkeeping table.
+type record struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Migrator runs migrations against one database.
+type Migrator struct {
+	db         isql.Engine
+	migrations []Migration
+}
+
+// New returns a Migrator for migrations, which may be given in any order.
+func New(db isql.Engine, migrations ...Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q: version must be positive, got %d", m.Name, m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migration version %d is defined twice", m.Version)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d: no up step", m.Version)
+		}
+	}
+
+	switch db.Dialect() {
+	case "postgres", "sqlite", "mysql":
+	default:
+		return nil, fmt.Errorf("migrations are not supported on %s", db.Dialect())
+	}
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// Up applies every pending migration in version order.
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down rolls back the most recently applied migration, if any.
+func (m *Migrator) Down(ctx context.Context) error {
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	_, err := m.step(ctx, func(applied map[int64]record) (Migration, bool, error) {
+		var last int64
+		for v := range applied {
+			if v > last {
+				last = v
+			}
+		}
+		if last == 0 {
+			return Migration{}, false, nil
+		}
+		mig, ok := m.find(last)
+		if !ok {
+			return Migration{}, false, fmt.Errorf("%w: %d is applied but not defined", ErrUnknownVersion, last)
+		}
+		return mig, false, nil
+	})
+	return err
+}
+
+// To migrates up or down until exactly the migrations up to version are
+// applied. Version 0 rolls every migration back.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	if _, ok := m.find(version); !ok && version != 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	for {
+		done, err := m.step(ctx, func(applied map[int64]record) (Migration, bool, error) {
+			// Apply the lowest pending version at or below the target...
+			for _, mig := range m.migrations {
+				if mig.Version > version {
+					break
+				}
+				if _, ok := applied[mig.Version]; !ok {
+					return mig, true, nil
+				}
+			}
+			// ...then roll back the highest applied version above it.
+			for i := len(m.migrations) - 1; i >= 0; i-- {
+				mig := m.migrations[i]
+				if mig.Version <= version {
+					break
+				}
+				if _, ok := applied[mig.Version]; ok {
+					return mig, false, nil
+				}
+			}
+			return Migration{}, false, nil
+		})
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// Status lists every defined migration and every applied version, in
+// version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	var applied map[int64]record
+	err := m.db.RunInTx(ctx, m.txOptions(), func(tx isql.Engine) (err error) {
+		applied, err = m.applied(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := Status{Version: mig.Version, Name: mig.Name}
+		if rec, ok := applied[mig.Version]; ok {
+			st.Applied, st.AppliedAt = true, rec.AppliedAt
+			delete(applied, mig.Version)
+		}
+		out = append(out, st)
+	}
+	for _, rec := range applied {
+		out = append(out, Status{Version: rec.Version, Name: rec.Name, Applied: true, AppliedAt: rec.AppliedAt, Missing: true})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// step runs one migration in a locked transaction. next picks it from the
+// versions applied at that point, returning up for the direction; a zero
+// Migration means there is nothing left to do, reported as done.
+func (m *Migrator) step(ctx context.Context, next func(applied map[int64]record) (mig Migration, up bool, err error)) (done bool, err error) {
+	err = m.db.RunInTx(ctx, m.txOptions(), func(tx isql.Engine) error {
+		unlock, err := m.lock(ctx, tx)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		applied, err := m.applied(ctx, tx)
+		if err != nil {
+			return err
+		}
+		mig, up, err := next(applied)
+		if err != nil {
+			return err
+		}
+		if mig.Version == 0 {
+			done = true
+			return nil
+		}
+
+		if up {
+			if err = mig.Up(ctx, tx); err != nil {
+				return fmt.Errorf("migration %d %s up: %w", mig.Version, mig.Name, err)
+			}
+			_, err = tx.Exec(ctx, m.bind("INSERT INTO "+TableName+" (version, name, applied_at) VALUES (?, ?, ?)"),
+				mig.Version, mig.Name, time.Now().UTC())
+			return err
+		}
+
+		if mig.Down == nil {
+			return fmt.Errorf("migration %d %s: %w", mig.Version, mig.Name, ErrNoDown)
+		}
+		if err = mig.Down(ctx, tx); err != nil {
+			return fmt.Errorf("migration %d %s down: %w", mig.Version, mig.Name, err)
+		}
+		_, err = tx.Exec(ctx, m.bind("DELETE FROM "+TableName+" WHERE version = ?"), mig.Version)
+		return err
+	})
+	return done, err
+}
+
+// txOptions returns the options of a step's transaction. On SQLite a
+// migrator that finds the write lock taken fails with SQLITE_BUSY, so the
+// step is retried until the lock is free. Elsewhere the lock blocks and
+// steps are not retried: MySQL commits DDL implicitly, so running a failed
+// step again could repeat the part of it that was committed.
+func (m *Migrator) txOptions() isql.TxOptions {
+	if m.db.Dialect() == "sqlite" {
+		return isql.TxOptions{MaxRetries: lockRetries}
+	}
+	return isql.TxOptions{}
+}
+
+// lock takes the migration lock inside tx and returns its release, which
+// must run before the transaction ends.
+//   - PostgreSQL: pg_advisory_xact_lock, released by the commit itself.
+//   - MySQL: GET_LOCK, a session lock on the transaction's connection.
+//   - SQLite: a write to the bookkeeping table as the transaction's first
+//     statement, which takes the database write lock up front like BEGIN
+//     IMMEDIATE and holds it until the transaction ends. A migrator that
+//     finds the lock taken gets SQLITE_BUSY and retries (see txOptions).
+func (m *Migrator) lock(ctx context.Context, tx isql.Engine) (func(), error) {
+	noop := func() {}
+	switch m.db.Dialect() {
+	case "postgres":
+		_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", lockKey)
+		return noop, err
+	case "mysql":
+		var got int64
+		if err := tx.QueryRow(ctx, "SELECT GET_LOCK(?, -1)", fmt.Sprint(lockKey)).Scan(&got); err != nil {
+			return noop, err
+		}
+		if got != 1 {
+			return noop, fmt.Errorf("could not take the migration lock")
+		}
+		return func() {
+			var released any
+			_ = tx.QueryRow(ctx, "SELECT RELEASE_LOCK(?)", fmt.Sprint(lockKey)).Scan(&released)
+		}, nil
+	default:
+		// Deletes nothing, as versions are positive, but still writes.
+		_, err := tx.Exec(ctx, "DELETE FROM "+TableName+" WHERE version < 0")
+		return noop, err
+	}
+}
+
+// ensureTable creates the bookkeeping table when it does not exist.
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.RunInTx(ctx, m.txOptions(), func(tx isql.Engine) error {
+		_, err := tx.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+TableName+
+			" (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)")
+		return err
+	})
+}
+
+// applied returns the recorded migrations by version.
+func (m *Migrator) applied(ctx context.Context, db isql.Engine) (map[int64]record, error) {
+	var records []record
+	if err := db.QueryInto(ctx, &records, "SELECT version, name, applied_at FROM "+TableName); err != nil {
+		return nil, err
+	}
+	out := make(map[int64]record, len(records))
+	for _, rec := range records {
+		out[rec.Version] = rec
+	}
+	return out, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+	if i < len(m.migrations) && m.migrations[i].Version == version {
+		return m.migrations[i], true
+	}
+	return Migration{}, false
+}
+
+// bind rewrites the ? placeholders of query for PostgreSQL.
+func (m *Migrator) bind(query string) string {
+	if m.db.Dialect() != "postgres" {
+		return query
+	}
+	var out []byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			n++
+			out = append(out, fmt.Sprintf("$%d", n)...)
+			continue
+		}
+		out = append(out, query[i])
+	}
+	return string(out)
+}
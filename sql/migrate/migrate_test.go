@@ -0,0 +1,177 @@
+package migrate_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/migrate"
+	"github.com/masudur-rahman/styx/sql/mock"
+	"github.com/masudur-rahman/styx/sql/sqlite"
+	"github.com/masudur-rahman/styx/sql/sqlite/lib"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDB(t *testing.T) isql.Engine {
+	conn, err := lib.GetSQLiteConnection(filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return sqlite.NewSQLite(conn)
+}
+
+func exec(query string) migrate.Func {
+	return func(ctx context.Context, db isql.Engine) error {
+		_, err := db.Exec(ctx, query)
+		return err
+	}
+}
+
+var migrations = []migrate.Migration{
+	{Version: 2, Name: "add_email", Up: exec("ALTER TABLE account ADD COLUMN email TEXT"), Down: exec("ALTER TABLE account DROP COLUMN email")},
+	{Version: 1, Name: "create_account", Up: exec("CREATE TABLE account (id INTEGER PRIMARY KEY, name TEXT)"), Down: exec("DROP TABLE account")},
+	{Version: 3, Name: "seed", Up: exec("INSERT INTO account (name, email) VALUES ('root', 'root@e.c')")},
+}
+
+func applied(t *testing.T, m *migrate.Migrator) []int64 {
+	status, err := m.Status(context.Background())
+	require.NoError(t, err)
+	var out []int64
+	for _, s := range status {
+		if s.Applied {
+			out = append(out, s.Version)
+		}
+	}
+	return out
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	m, err := migrate.New(db, migrations...)
+	require.NoError(t, err)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Len(t, status, 3)
+	assert.Equal(t, "create_account", status[0].Name)
+	assert.False(t, status[0].Applied)
+
+	require.NoError(t, m.To(ctx, 2))
+	assert.Equal(t, []int64{1, 2}, applied(t, m))
+
+	require.NoError(t, m.Up(ctx))
+	assert.Equal(t, []int64{1, 2, 3}, applied(t, m))
+	require.NoError(t, m.Up(ctx), "nothing left to apply")
+
+	err = m.Down(ctx)
+	assert.ErrorIs(t, err, migrate.ErrNoDown)
+	assert.Equal(t, []int64{1, 2, 3}, applied(t, m), "a failed step is rolled back")
+
+	_, err = db.Exec(ctx, "DELETE FROM account")
+	require.NoError(t, err)
+	m, err = migrate.New(db, migrations[0], migrations[1], migrate.Migration{Version: 3, Name: "seed", Up: exec("SELECT 1"), Down: exec("SELECT 1")})
+	require.NoError(t, err)
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, []int64{1, 2}, applied(t, m))
+
+	require.NoError(t, m.To(ctx, 0))
+	assert.Empty(t, applied(t, m))
+	_, err = db.Exec(ctx, "SELECT * FROM account")
+	assert.Error(t, err, "the table was dropped")
+
+	assert.ErrorIs(t, m.To(ctx, 9), migrate.ErrUnknownVersion)
+}
+
+func TestMigrator_missingVersions(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	m, err := migrate.New(db, migrations[1], migrations[0])
+	require.NoError(t, err)
+	require.NoError(t, m.Up(ctx))
+
+	m, err = migrate.New(db, migrations[1])
+	require.NoError(t, err)
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 2)
+	assert.True(t, status[1].Missing)
+	assert.Equal(t, "add_email", status[1].Name)
+	assert.ErrorIs(t, m.Down(ctx), migrate.ErrUnknownVersion)
+}
+
+func TestNew_invalid(t *testing.T) {
+	db := setupDB(t)
+	_, err := migrate.New(db, migrations[0], migrations[0])
+	assert.Error(t, err)
+	_, err = migrate.New(db, migrate.Migration{Version: 0, Up: exec("SELECT 1")})
+	assert.Error(t, err)
+	_, err = migrate.New(db, migrate.Migration{Version: 1})
+	assert.Error(t, err)
+
+	rest := mock.NewMockEngine(gomock.NewController(t))
+	rest.EXPECT().Dialect().Return("supabase").AnyTimes()
+	_, err = migrate.New(rest, migrations...)
+	assert.Error(t, err)
+}
+
+func TestMigrator_concurrentUp(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := migrate.New(db, migrations...)
+			if err == nil {
+				err = m.Up(ctx)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var n int64
+	require.NoError(t, db.QueryRow(ctx, "SELECT COUNT(*) FROM account").Scan(&n))
+	assert.Equal(t, int64(1), n, "the seed ran once")
+}
+
+func TestFromFS(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"migrations/0001_create_tag.up.sql":   {Data: []byte("CREATE TABLE tag (id INTEGER PRIMARY KEY, name TEXT);")},
+		"migrations/0001_create_tag.down.sql": {Data: []byte("DROP TABLE tag;")},
+		"migrations/0002_seed.up.sql":         {Data: []byte("INSERT INTO tag (name) VALUES ('a'); INSERT INTO tag (name) VALUES ('b');")},
+		"migrations/README.md":                {Data: []byte("ignored")},
+	}
+	migs, err := migrate.FromFS(fsys, "migrations")
+	require.NoError(t, err)
+	require.Len(t, migs, 2)
+	assert.Equal(t, "create_tag", migs[0].Name)
+	assert.Nil(t, migs[1].Down)
+
+	db := setupDB(t)
+	m, err := migrate.New(db, migs...)
+	require.NoError(t, err)
+	require.NoError(t, m.Up(ctx))
+
+	var n int64
+	require.NoError(t, db.QueryRow(ctx, "SELECT COUNT(*) FROM tag").Scan(&n))
+	assert.Equal(t, int64(2), n)
+
+	_, err = migrate.FromFS(fstest.MapFS{"m/1_x.sql": {}}, "m")
+	assert.Error(t, err)
+	_, err = migrate.FromFS(fstest.MapFS{"m/1_x.down.sql": {}}, "m")
+	assert.Error(t, err)
+}
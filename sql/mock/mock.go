@@ -10,7 +10,9 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	pagination "github.com/masudur-rahman/styx/pagination"
 	sql0 "github.com/masudur-rahman/styx/sql"
+	cond "github.com/masudur-rahman/styx/sql/cond"
 )
 
 // MockEngine is a mock of Engine interface.
@@ -36,6 +38,20 @@ func (m *MockEngine) EXPECT() *MockEngineMockRecorder {
 	return m.recorder
 }
 
+// After mocks base method.
+func (m *MockEngine) After(cursor string) sql0.Engine {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "After", cursor)
+	ret0, _ := ret[0].(sql0.Engine)
+	return ret0
+}
+
+// After indicates an expected call of After.
+func (mr *MockEngineMockRecorder) After(cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "After", reflect.TypeOf((*MockEngine)(nil).After), cursor)
+}
+
 // AllCols mocks base method.
 func (m *MockEngine) AllCols() sql0.Engine {
 	m.ctrl.T.Helper()
@@ -69,6 +85,20 @@ func (mr *MockEngineMockRecorder) Avg(col interface{}, alias ...interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Avg", reflect.TypeOf((*MockEngine)(nil).Avg), varargs...)
 }
 
+// Before mocks base method.
+func (m *MockEngine) Before(cursor string) sql0.Engine {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Before", cursor)
+	ret0, _ := ret[0].(sql0.Engine)
+	return ret0
+}
+
+// Before indicates an expected call of Before.
+func (mr *MockEngineMockRecorder) Before(cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Before", reflect.TypeOf((*MockEngine)(nil).Before), cursor)
+}
+
 // BeginTx mocks base method.
 func (m *MockEngine) BeginTx(ctx context.Context) (sql0.Engine, error) {
 	m.ctrl.T.Helper()
@@ -84,6 +114,21 @@ func (mr *MockEngineMockRecorder) BeginTx(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockEngine)(nil).BeginTx), ctx)
 }
 
+// BeginTxWithOptions mocks base method.
+func (m *MockEngine) BeginTxWithOptions(ctx context.Context, opts *sql.TxOptions) (sql0.Engine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTxWithOptions", ctx, opts)
+	ret0, _ := ret[0].(sql0.Engine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTxWithOptions indicates an expected call of BeginTxWithOptions.
+func (mr *MockEngineMockRecorder) BeginTxWithOptions(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTxWithOptions", reflect.TypeOf((*MockEngine)(nil).BeginTxWithOptions), ctx, opts)
+}
+
 // Close mocks base method.
 func (m *MockEngine) Close() error {
 	m.ctrl.T.Helper()
@@ -149,6 +194,46 @@ func (mr *MockEngineMockRecorder) Count(col interface{}, alias ...interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockEngine)(nil).Count), varargs...)
 }
 
+// CountRows mocks base method.
+func (m *MockEngine) CountRows(ctx context.Context, filter ...any) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CountRows", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRows indicates an expected call of CountRows.
+func (mr *MockEngineMockRecorder) CountRows(ctx interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRows", reflect.TypeOf((*MockEngine)(nil).CountRows), varargs...)
+}
+
+// DeleteMany mocks base method.
+func (m *MockEngine) DeleteMany(ctx context.Context, filter ...any) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMany", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMany indicates an expected call of DeleteMany.
+func (mr *MockEngineMockRecorder) DeleteMany(ctx interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMany", reflect.TypeOf((*MockEngine)(nil).DeleteMany), varargs...)
+}
+
 // DeleteOne mocks base method.
 func (m *MockEngine) DeleteOne(ctx context.Context, filter ...any) error {
 	m.ctrl.T.Helper()
@@ -168,6 +253,40 @@ func (mr *MockEngineMockRecorder) DeleteOne(ctx interface{}, filter ...interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOne", reflect.TypeOf((*MockEngine)(nil).DeleteOne), varargs...)
 }
 
+// DeleteOneReturning mocks base method.
+func (m *MockEngine) DeleteOneReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, out}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteOneReturning", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOneReturning indicates an expected call of DeleteOneReturning.
+func (mr *MockEngineMockRecorder) DeleteOneReturning(ctx, out interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, out}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOneReturning", reflect.TypeOf((*MockEngine)(nil).DeleteOneReturning), varargs...)
+}
+
+// Dialect mocks base method.
+func (m *MockEngine) Dialect() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dialect")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Dialect indicates an expected call of Dialect.
+func (mr *MockEngineMockRecorder) Dialect() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dialect", reflect.TypeOf((*MockEngine)(nil).Dialect))
+}
+
 // Distinct mocks base method.
 func (m *MockEngine) Distinct() sql0.Engine {
 	m.ctrl.T.Helper()
@@ -231,7 +350,7 @@ func (mr *MockEngineMockRecorder) Exec(ctx, query interface{}, args ...interface
 }
 
 // Exists mocks base method.
-func (m *MockEngine) Exists(subquery string, args ...any) sql0.Engine {
+func (m *MockEngine) Exists(subquery any, args ...any) sql0.Engine {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{subquery}
 	for _, a := range args {
@@ -249,6 +368,26 @@ func (mr *MockEngineMockRecorder) Exists(subquery interface{}, args ...interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockEngine)(nil).Exists), varargs...)
 }
 
+// FindCursor mocks base method.
+func (m *MockEngine) FindCursor(ctx context.Context, documents any, filter ...any) (pagination.CursorPage, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, documents}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindCursor", varargs...)
+	ret0, _ := ret[0].(pagination.CursorPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCursor indicates an expected call of FindCursor.
+func (mr *MockEngineMockRecorder) FindCursor(ctx, documents interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, documents}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCursor", reflect.TypeOf((*MockEngine)(nil).FindCursor), varargs...)
+}
+
 // FindMany mocks base method.
 func (m *MockEngine) FindMany(ctx context.Context, documents any, filter ...any) error {
 	m.ctrl.T.Helper()
@@ -268,6 +407,26 @@ func (mr *MockEngineMockRecorder) FindMany(ctx, documents interface{}, filter ..
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMany", reflect.TypeOf((*MockEngine)(nil).FindMany), varargs...)
 }
 
+// FindMaps mocks base method.
+func (m *MockEngine) FindMaps(ctx context.Context, filter ...any) ([]map[string]any, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindMaps", varargs...)
+	ret0, _ := ret[0].([]map[string]any)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindMaps indicates an expected call of FindMaps.
+func (mr *MockEngineMockRecorder) FindMaps(ctx interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMaps", reflect.TypeOf((*MockEngine)(nil).FindMaps), varargs...)
+}
+
 // FindOne mocks base method.
 func (m *MockEngine) FindOne(ctx context.Context, document any, filter ...any) (bool, error) {
 	m.ctrl.T.Helper()
@@ -288,6 +447,26 @@ func (mr *MockEngineMockRecorder) FindOne(ctx, document interface{}, filter ...i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOne", reflect.TypeOf((*MockEngine)(nil).FindOne), varargs...)
 }
 
+// FindPage mocks base method.
+func (m *MockEngine) FindPage(ctx context.Context, documents any, page, perPage int64, filter ...any) (pagination.Paginator, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, documents, page, perPage}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindPage", varargs...)
+	ret0, _ := ret[0].(pagination.Paginator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPage indicates an expected call of FindPage.
+func (mr *MockEngineMockRecorder) FindPage(ctx, documents, page, perPage interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, documents, page, perPage}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPage", reflect.TypeOf((*MockEngine)(nil).FindPage), varargs...)
+}
+
 // ForceDelete mocks base method.
 func (m *MockEngine) ForceDelete(ctx context.Context, filter ...any) error {
 	m.ctrl.T.Helper()
@@ -307,6 +486,45 @@ func (mr *MockEngineMockRecorder) ForceDelete(ctx interface{}, filter ...interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceDelete", reflect.TypeOf((*MockEngine)(nil).ForceDelete), varargs...)
 }
 
+// ForceDeleteReturning mocks base method.
+func (m *MockEngine) ForceDeleteReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, out}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ForceDeleteReturning", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceDeleteReturning indicates an expected call of ForceDeleteReturning.
+func (mr *MockEngineMockRecorder) ForceDeleteReturning(ctx, out interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, out}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceDeleteReturning", reflect.TypeOf((*MockEngine)(nil).ForceDeleteReturning), varargs...)
+}
+
+// From mocks base method.
+func (m *MockEngine) From(subquery any, alias string, args ...any) sql0.Engine {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{subquery, alias}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "From", varargs...)
+	ret0, _ := ret[0].(sql0.Engine)
+	return ret0
+}
+
+// From indicates an expected call of From.
+func (mr *MockEngineMockRecorder) From(subquery, alias interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{subquery, alias}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "From", reflect.TypeOf((*MockEngine)(nil).From), varargs...)
+}
+
 // GroupBy mocks base method.
 func (m *MockEngine) GroupBy(cols ...string) sql0.Engine {
 	m.ctrl.T.Helper()
@@ -421,6 +639,25 @@ func (mr *MockEngineMockRecorder) InsertOne(ctx, document interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOne", reflect.TypeOf((*MockEngine)(nil).InsertOne), ctx, document)
 }
 
+// Iterate mocks base method.
+func (m *MockEngine) Iterate(ctx context.Context, proto any, fn func(any) error, filter ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, proto, fn}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Iterate", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Iterate indicates an expected call of Iterate.
+func (mr *MockEngineMockRecorder) Iterate(ctx, proto, fn interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, proto, fn}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterate", reflect.TypeOf((*MockEngine)(nil).Iterate), varargs...)
+}
+
 // Join mocks base method.
 func (m *MockEngine) Join(table, condition string) sql0.Engine {
 	m.ctrl.T.Helper()
@@ -552,7 +789,7 @@ func (mr *MockEngineMockRecorder) MustFilterCols(cols ...interface{}) *gomock.Ca
 }
 
 // NotExists mocks base method.
-func (m *MockEngine) NotExists(subquery string, args ...any) sql0.Engine {
+func (m *MockEngine) NotExists(subquery any, args ...any) sql0.Engine {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{subquery}
 	for _, a := range args {
@@ -650,6 +887,26 @@ func (mr *MockEngineMockRecorder) Paginate(page, perPage interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Paginate", reflect.TypeOf((*MockEngine)(nil).Paginate), page, perPage)
 }
 
+// Plan mocks base method.
+func (m *MockEngine) Plan(ctx context.Context, tables ...any) ([]sql0.SchemaChange, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range tables {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Plan", varargs...)
+	ret0, _ := ret[0].([]sql0.SchemaChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Plan indicates an expected call of Plan.
+func (mr *MockEngineMockRecorder) Plan(ctx interface{}, tables ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, tables...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plan", reflect.TypeOf((*MockEngine)(nil).Plan), varargs...)
+}
+
 // Query mocks base method.
 func (m *MockEngine) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
 	m.ctrl.T.Helper()
@@ -670,6 +927,44 @@ func (mr *MockEngineMockRecorder) Query(ctx, query interface{}, args ...interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockEngine)(nil).Query), varargs...)
 }
 
+// QueryInto mocks base method.
+func (m *MockEngine) QueryInto(ctx context.Context, dest any, query string, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, dest, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryInto", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// QueryInto indicates an expected call of QueryInto.
+func (mr *MockEngineMockRecorder) QueryInto(ctx, dest, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, dest, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryInto", reflect.TypeOf((*MockEngine)(nil).QueryInto), varargs...)
+}
+
+// QueryRow mocks base method.
+func (m *MockEngine) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRow", varargs...)
+	ret0, _ := ret[0].(*sql.Row)
+	return ret0
+}
+
+// QueryRow indicates an expected call of QueryRow.
+func (mr *MockEngineMockRecorder) QueryRow(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockEngine)(nil).QueryRow), varargs...)
+}
+
 // Restore mocks base method.
 func (m *MockEngine) Restore(ctx context.Context, filter ...any) error {
 	m.ctrl.T.Helper()
@@ -689,6 +984,26 @@ func (mr *MockEngineMockRecorder) Restore(ctx interface{}, filter ...interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockEngine)(nil).Restore), varargs...)
 }
 
+// RestoreReturning mocks base method.
+func (m *MockEngine) RestoreReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, out}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RestoreReturning", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreReturning indicates an expected call of RestoreReturning.
+func (mr *MockEngineMockRecorder) RestoreReturning(ctx, out interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, out}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreReturning", reflect.TypeOf((*MockEngine)(nil).RestoreReturning), varargs...)
+}
+
 // RightJoin mocks base method.
 func (m *MockEngine) RightJoin(table, condition string) sql0.Engine {
 	m.ctrl.T.Helper()
@@ -717,6 +1032,59 @@ func (mr *MockEngineMockRecorder) Rollback() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockEngine)(nil).Rollback))
 }
 
+// Rows mocks base method.
+func (m *MockEngine) Rows(ctx context.Context, proto any, filter ...any) (sql0.RowScanner, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, proto}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Rows", varargs...)
+	ret0, _ := ret[0].(sql0.RowScanner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rows indicates an expected call of Rows.
+func (mr *MockEngineMockRecorder) Rows(ctx, proto interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, proto}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rows", reflect.TypeOf((*MockEngine)(nil).Rows), varargs...)
+}
+
+// RunInTx mocks base method.
+func (m *MockEngine) RunInTx(ctx context.Context, opts sql0.TxOptions, fn func(sql0.Engine) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunInTx", ctx, opts, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunInTx indicates an expected call of RunInTx.
+func (mr *MockEngineMockRecorder) RunInTx(ctx, opts, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTx", reflect.TypeOf((*MockEngine)(nil).RunInTx), ctx, opts, fn)
+}
+
+// Scalar mocks base method.
+func (m *MockEngine) Scalar(ctx context.Context, dest any, filter ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, dest}
+	for _, a := range filter {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scalar", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scalar indicates an expected call of Scalar.
+func (mr *MockEngineMockRecorder) Scalar(ctx, dest interface{}, filter ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, dest}, filter...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scalar", reflect.TypeOf((*MockEngine)(nil).Scalar), varargs...)
+}
+
 // ShowSQL mocks base method.
 func (m *MockEngine) ShowSQL(showSQL bool) sql0.Engine {
 	m.ctrl.T.Helper()
@@ -731,6 +1099,38 @@ func (mr *MockEngineMockRecorder) ShowSQL(showSQL interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowSQL", reflect.TypeOf((*MockEngine)(nil).ShowSQL), showSQL)
 }
 
+// SoftDeleteCol mocks base method.
+func (m *MockEngine) SoftDeleteCol(col string) sql0.Engine {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteCol", col)
+	ret0, _ := ret[0].(sql0.Engine)
+	return ret0
+}
+
+// SoftDeleteCol indicates an expected call of SoftDeleteCol.
+func (mr *MockEngineMockRecorder) SoftDeleteCol(col interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteCol", reflect.TypeOf((*MockEngine)(nil).SoftDeleteCol), col)
+}
+
+// Subquery mocks base method.
+func (m *MockEngine) Subquery(proto ...any) sql0.Subquery {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range proto {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Subquery", varargs...)
+	ret0, _ := ret[0].(sql0.Subquery)
+	return ret0
+}
+
+// Subquery indicates an expected call of Subquery.
+func (mr *MockEngineMockRecorder) Subquery(proto ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subquery", reflect.TypeOf((*MockEngine)(nil).Subquery), proto...)
+}
+
 // Sum mocks base method.
 func (m *MockEngine) Sum(col string, alias ...string) sql0.Engine {
 	m.ctrl.T.Helper()
@@ -783,6 +1183,21 @@ func (mr *MockEngineMockRecorder) Table(name interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Table", reflect.TypeOf((*MockEngine)(nil).Table), name)
 }
 
+// UpdateMany mocks base method.
+func (m *MockEngine) UpdateMany(ctx context.Context, document any) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMany", ctx, document)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMany indicates an expected call of UpdateMany.
+func (mr *MockEngineMockRecorder) UpdateMany(ctx, document interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMany", reflect.TypeOf((*MockEngine)(nil).UpdateMany), ctx, document)
+}
+
 // UpdateOne mocks base method.
 func (m *MockEngine) UpdateOne(ctx context.Context, document any) error {
 	m.ctrl.T.Helper()
@@ -797,6 +1212,41 @@ func (mr *MockEngineMockRecorder) UpdateOne(ctx, document interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOne", reflect.TypeOf((*MockEngine)(nil).UpdateOne), ctx, document)
 }
 
+// UpdateOneReturning mocks base method.
+func (m *MockEngine) UpdateOneReturning(ctx context.Context, document, out any) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOneReturning", ctx, document, out)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOneReturning indicates an expected call of UpdateOneReturning.
+func (mr *MockEngineMockRecorder) UpdateOneReturning(ctx, document, out interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOneReturning", reflect.TypeOf((*MockEngine)(nil).UpdateOneReturning), ctx, document, out)
+}
+
+// Upsert mocks base method.
+func (m *MockEngine) Upsert(ctx context.Context, document any, conflictCols []string, updateCols ...string) (any, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, document, conflictCols}
+	for _, a := range updateCols {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Upsert", varargs...)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockEngineMockRecorder) Upsert(ctx, document, conflictCols interface{}, updateCols ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, document, conflictCols}, updateCols...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockEngine)(nil).Upsert), varargs...)
+}
+
 // Where mocks base method.
 func (m *MockEngine) Where(cond string, args ...any) sql0.Engine {
 	m.ctrl.T.Helper()
@@ -816,6 +1266,39 @@ func (mr *MockEngineMockRecorder) Where(cond interface{}, args ...interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Where", reflect.TypeOf((*MockEngine)(nil).Where), varargs...)
 }
 
+// WhereCond mocks base method.
+func (m *MockEngine) WhereCond(c cond.Cond) sql0.Engine {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WhereCond", c)
+	ret0, _ := ret[0].(sql0.Engine)
+	return ret0
+}
+
+// WhereCond indicates an expected call of WhereCond.
+func (mr *MockEngineMockRecorder) WhereCond(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WhereCond", reflect.TypeOf((*MockEngine)(nil).WhereCond), c)
+}
+
+// With mocks base method.
+func (m *MockEngine) With(name string, subquery any, args ...any) sql0.Engine {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{name, subquery}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "With", varargs...)
+	ret0, _ := ret[0].(sql0.Engine)
+	return ret0
+}
+
+// With indicates an expected call of With.
+func (mr *MockEngineMockRecorder) With(name, subquery interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{name, subquery}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "With", reflect.TypeOf((*MockEngine)(nil).With), varargs...)
+}
+
 // WithDeleted mocks base method.
 func (m *MockEngine) WithDeleted() sql0.Engine {
 	m.ctrl.T.Helper()
@@ -829,3 +1312,22 @@ func (mr *MockEngineMockRecorder) WithDeleted() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithDeleted", reflect.TypeOf((*MockEngine)(nil).WithDeleted))
 }
+
+// WithRecursive mocks base method.
+func (m *MockEngine) WithRecursive(name string, subquery any, args ...any) sql0.Engine {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{name, subquery}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithRecursive", varargs...)
+	ret0, _ := ret[0].(sql0.Engine)
+	return ret0
+}
+
+// WithRecursive indicates an expected call of WithRecursive.
+func (mr *MockEngineMockRecorder) WithRecursive(name, subquery interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{name, subquery}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithRecursive", reflect.TypeOf((*MockEngine)(nil).WithRecursive), varargs...)
+}
@@ -0,0 +1,133 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/masudur-rahman/styx/sql/builder"
+)
+
+// MaxParams is the maximum number of placeholders MySQL accepts in a single
+// prepared statement.
+const MaxParams = 65535
+
+// Statement is the query builder used by the MySQL engine.
+type Statement = builder.Statement
+
+// NewStatement returns an empty Statement rendering MySQL.
+func NewStatement() Statement {
+	return builder.NewStatement(Dialect{})
+}
+
+// Dialect renders MySQL: positional ? placeholders, backtick-quoted
+// identifiers and ON DUPLICATE KEY UPDATE upserts. MySQL has no RETURNING,
+// so generated keys come from LAST_INSERT_ID().
+type Dialect struct{}
+
+var _ builder.Dialect = Dialect{}
+
+func (Dialect) Name() string {
+	return "mysql"
+}
+
+func (Dialect) Placeholder(int) string {
+	return "?"
+}
+
+func (Dialect) ShiftPlaceholders(query string, _ int) string {
+	return query
+}
+
+func (Dialect) MaxParams() int {
+	return MaxParams
+}
+
+func (Dialect) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (Dialect) SQLType(fieldType reflect.Type, autoincr bool) string {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if autoincr {
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int32:
+			return "INT AUTO_INCREMENT"
+		case reflect.Int64:
+			return "BIGINT AUTO_INCREMENT"
+		case reflect.Uint64:
+			return "BIGINT UNSIGNED AUTO_INCREMENT"
+		}
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int32:
+		return "INT"
+	case reflect.Int64:
+		return "BIGINT"
+	case reflect.Uint64:
+		return "BIGINT UNSIGNED"
+	case reflect.Float32, reflect.Float64:
+		return "DOUBLE"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.String:
+		return "VARCHAR(255)"
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			return "BLOB"
+		}
+	case reflect.Struct:
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			return "DATETIME(6)"
+		}
+	}
+
+	return ""
+}
+
+func (Dialect) SupportsReturning() bool {
+	return false
+}
+
+// SupportsWindowFunctions is false: MySQL before 8.0 and MariaDB before
+// 10.2 have no window functions, so FindPage counts with a separate query.
+func (Dialect) SupportsWindowFunctions() bool {
+	return false
+}
+
+// UpsertClause renders ON DUPLICATE KEY UPDATE. MySQL matches every unique
+// key, so conflictCols is not part of the clause. A generated pk is passed
+// through LAST_INSERT_ID(expr) so that an update reports the existing row's
+// id; any other key would be cast to an integer, so pkCol is then empty.
+func (Dialect) UpsertClause(pkCol string, _, updateCols []string) string {
+	sets := make([]string, 0, len(updateCols)+1)
+	if pkCol != "" {
+		sets = append(sets, fmt.Sprintf("%s = LAST_INSERT_ID(%s)", pkCol, pkCol))
+	}
+	for _, col := range updateCols {
+		sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", col, col))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+// LimitOffset renders LIMIT/OFFSET. MySQL rejects OFFSET without LIMIT, so
+// an offset alone is paired with the largest possible row count.
+func (Dialect) LimitOffset(limit, offset int64) string {
+	switch {
+	case limit > 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit > 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		return fmt.Sprintf("LIMIT 18446744073709551615 OFFSET %d", offset)
+	}
+	return ""
+}
+
+func (Dialect) TranslateError(err error) error {
+	return TranslateError(err)
+}
@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/sql/builder"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers translated by TranslateError.
+const (
+	errDupEntry              = 1062
+	errBadNull               = 1048
+	errNoDefaultForField     = 1364
+	errNoReferencedRowLegacy = 1216
+	errRowIsReferencedLegacy = 1217
+	errRowIsReferenced       = 1451
+	errNoReferencedRow       = 1452
+	errLockDeadlock          = 1213
+	errCheckConstraint       = 3819
+)
+
+// IsRetryable reports whether err is a deadlock (ER_LOCK_DEADLOCK, 1213),
+// after which MySQL has rolled back the transaction and it may be retried.
+func IsRetryable(err error) bool {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return false
+	}
+	return myErr.Number == errLockDeadlock
+}
+
+var (
+	quotedName   = regexp.MustCompile(`'([^']*)'`)
+	dupKey       = regexp.MustCompile(`for key '([^']*)'`)
+	fkConstraint = regexp.MustCompile("`([^`]*)`, CONSTRAINT `([^`]*)` FOREIGN KEY \\(`([^`]*)`")
+)
+
+// TranslateError wraps go-sql-driver errors into a *dberr.DBError by server
+// error number. MySQL names the key, column or constraint only in the
+// message text, which is parsed for them. Errors that map to no dberr
+// sentinel are returned unchanged.
+func TranslateError(err error) error {
+	var dbErr *dberr.DBError
+	if err == nil || errors.As(err, &dbErr) {
+		return err
+	}
+
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		if errors.Is(err, mysql.ErrInvalidConn) || builder.IsConnError(err) {
+			return &dberr.DBError{Err: dberr.ErrConnectionFailed, Cause: err}
+		}
+		return err
+	}
+
+	translated := &dberr.DBError{Cause: err}
+	switch myErr.Number {
+	case errDupEntry:
+		translated.Err = dberr.ErrDuplicateEntry
+		if m := dupKey.FindStringSubmatch(myErr.Message); m != nil {
+			// MySQL 8 qualifies the key with its table: 'user.email'.
+			if table, key, ok := strings.Cut(m[1], "."); ok {
+				translated.Table, translated.Constraint = table, key
+			} else {
+				translated.Constraint = m[1]
+			}
+		}
+	case errRowIsReferenced, errNoReferencedRow, errRowIsReferencedLegacy, errNoReferencedRowLegacy:
+		translated.Err = dberr.ErrForeignKeyViolation
+		if m := fkConstraint.FindStringSubmatch(myErr.Message); m != nil {
+			translated.Table, translated.Constraint, translated.Column = m[1], m[2], m[3]
+		}
+	case errBadNull, errNoDefaultForField:
+		translated.Err = dberr.ErrNotNullViolation
+		if m := quotedName.FindStringSubmatch(myErr.Message); m != nil {
+			translated.Column = m[1]
+		}
+	case errCheckConstraint:
+		translated.Err = dberr.ErrCheckViolation
+		if m := quotedName.FindStringSubmatch(myErr.Message); m != nil {
+			translated.Constraint = m[1]
+		}
+	case errLockDeadlock:
+		translated.Err = dberr.ErrDeadlock
+	default:
+		return err
+	}
+	return translated
+}
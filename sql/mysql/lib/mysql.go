@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type MySQLConfig struct {
+	Name     string `json:"name" yaml:"name"`
+	Host     string `json:"host" yaml:"host"`
+	Port     string `json:"port" yaml:"port"`
+	User     string `json:"user" yaml:"user"`
+	Password string `json:"password" yaml:"password"`
+}
+
+// String returns the go-sql-driver DSN for the config. Times are parsed into
+// time.Time and UPDATE reports matched rather than changed rows, so that
+// rewriting a row with identical values is not mistaken for a missing row.
+func (cfg MySQLConfig) String() string {
+	c := mysql.NewConfig()
+	c.User = cfg.User
+	c.Passwd = cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(cfg.Host, cfg.Port)
+	c.DBName = cfg.Name
+	c.ParseTime = true
+	c.ClientFoundRows = true
+	return c.FormatDSN()
+}
+
+// GetMySQLConnection opens a MySQL database and returns a *sql.DB connection pool.
+func GetMySQLConnection(cfg MySQLConfig) (*sql.DB, error) {
+	db, err := sql.Open("mysql", cfg.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.PingContext(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func ExecuteWriteQuery(ctx context.Context, query string, conn *sql.DB) (sql.Result, error) {
+	return conn.ExecContext(ctx, query)
+}
+
+// ConsecutiveIDs reports whether a multi-row INSERT is given consecutive
+// AUTO_INCREMENT values, so that every row's key can be derived from
+// LastInsertId, the key of the first row. That takes an
+// auto_increment_increment of 1 and, on InnoDB, an innodb_autoinc_lock_mode
+// other than 2 ("interleaved", the default since MySQL 8.0), under which
+// concurrent INSERTs draw their values from the same range.
+func ConsecutiveIDs(ctx context.Context, conn *sql.DB, tx *sql.Tx) (bool, error) {
+	queryRow := conn.QueryRowContext
+	if tx != nil {
+		queryRow = tx.QueryRowContext
+	}
+
+	var increment int64
+	if err := queryRow(ctx, "SELECT @@auto_increment_increment").Scan(&increment); err != nil {
+		return false, err
+	}
+	if increment != 1 {
+		return false, nil
+	}
+
+	var name string
+	var lockMode int64
+	err := queryRow(ctx, "SHOW VARIABLES LIKE 'innodb_autoinc_lock_mode'").Scan(&name, &lockMode)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return lockMode != 2, nil
+}
@@ -0,0 +1,135 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/masudur-rahman/styx/dberr"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStatement() *Statement {
+	stmt := NewStatement()
+	return &stmt
+}
+
+type testDoc struct {
+	ID        int64           `db:"id,pk autoincr"`
+	Name      string          `db:"name,uq"`
+	Email     string          `db:"email,idx"`
+	Score     float64         `db:"score"`
+	Active    bool            `db:"active"`
+	Avatar    []byte          `db:"avatar"`
+	Payload   json.RawMessage `db:"payload"`
+	CreatedAt time.Time       `db:"created_at"`
+	DeletedAt *time.Time      `db:"deleted_at,soft_delete"`
+}
+
+func TestCreateTableQuery(t *testing.T) {
+	fields, err := getTableInfo(testDoc{})
+	assert.NoError(t, err)
+
+	query := createTableQuery("test_doc", fields)
+
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS `test_doc` ("+
+		"id BIGINT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(255) UNIQUE, email VARCHAR(255), "+
+		"score DOUBLE, active BOOLEAN, avatar BLOB, payload JSON, "+
+		"created_at DATETIME(6), deleted_at DATETIME(6))", query)
+}
+
+func TestExtractIndexes(t *testing.T) {
+	type doc struct {
+		A string `db:"a,idx:ab"`
+		B string `db:"b,idx:ab"`
+		C string `db:"c,unique_idx"`
+	}
+
+	indexes := extractIndexes(doc{})
+
+	assert.Equal(t, []indexInfo{
+		{Name: "ab", Cols: []string{"a", "b"}},
+		{Name: "idx_doc_1", Cols: []string{"c"}, Unique: true},
+	}, indexes)
+}
+
+func TestGenerateInsertQuery_backtickTable(t *testing.T) {
+	stmt := newStatement().Table("test_doc")
+
+	query := stmt.GenerateInsertQuery(testDoc{Name: "alice", Email: "alice@test.com"})
+
+	assert.Equal(t, "INSERT INTO `test_doc` (name, email) VALUES (?, ?)", query)
+	assert.Equal(t, []any{"alice", "alice@test.com"}, stmt.Args())
+}
+
+func TestGenerateUpsertQuery(t *testing.T) {
+	stmt := newStatement().Table("test_doc").PKColumn("id")
+
+	query, err := stmt.GenerateUpsertQuery(testDoc{Name: "alice", Score: 3}, []string{"name"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "INSERT INTO `test_doc` (name, score) VALUES (?, ?) "+
+		"ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), score = VALUES(score)", query)
+}
+
+func TestDialect_LimitOffset(t *testing.T) {
+	assert.Equal(t, "", Dialect{}.LimitOffset(0, 0))
+	assert.Equal(t, "LIMIT 5", Dialect{}.LimitOffset(5, 0))
+	assert.Equal(t, "LIMIT 5 OFFSET 10", Dialect{}.LimitOffset(5, 10))
+	assert.Equal(t, "LIMIT 18446744073709551615 OFFSET 10", Dialect{}.LimitOffset(0, 10), "MySQL needs a LIMIT before OFFSET")
+}
+
+func TestMySQLConfig_String(t *testing.T) {
+	cfg := MySQLConfig{Name: "styx", Host: "localhost", Port: "3306", User: "root", Password: "secret"}
+
+	assert.Equal(t, "root:secret@tcp(localhost:3306)/styx?clientFoundRows=true&parseTime=true", cfg.String())
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&mysql.MySQLError{Number: 1213}))
+	assert.False(t, IsRetryable(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, IsRetryable(errors.New("deadlock")))
+}
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name       string
+		cause      *mysql.MySQLError
+		want       error
+		table      string
+		column     string
+		constraint string
+	}{
+		{"duplicate", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'alice' for key 'user.name'"},
+			dberr.ErrDuplicateEntry, "user", "", "name"},
+		{"foreign key", &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`styx`.`post`, CONSTRAINT `post_user_fk` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`))"},
+			dberr.ErrForeignKeyViolation, "post", "user_id", "post_user_fk"},
+		{"not null", &mysql.MySQLError{Number: 1048, Message: "Column 'name' cannot be null"},
+			dberr.ErrNotNullViolation, "", "name", ""},
+		{"check", &mysql.MySQLError{Number: 3819, Message: "Check constraint 'age_positive' is violated."},
+			dberr.ErrCheckViolation, "", "", "age_positive"},
+		{"deadlock", &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"},
+			dberr.ErrDeadlock, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TranslateError(tt.cause)
+
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorIs(t, err, tt.cause)
+			var dbErr *dberr.DBError
+			if assert.ErrorAs(t, err, &dbErr) {
+				assert.Equal(t, tt.table, dbErr.Table)
+				assert.Equal(t, tt.column, dbErr.Column)
+				assert.Equal(t, tt.constraint, dbErr.Constraint)
+			}
+		})
+	}
+
+	syntax := &mysql.MySQLError{Number: 1064}
+	assert.Same(t, syntax, TranslateError(syntax))
+	assert.ErrorIs(t, TranslateError(mysql.ErrInvalidConn), dberr.ErrConnectionFailed)
+}
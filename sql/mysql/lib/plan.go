@@ -0,0 +1,377 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// PlanTable compares table, a struct, with the live table and returns the
+// changes that would make them match, without applying any.
+func PlanTable(ctx context.Context, conn *sql.DB, table any) ([]isql.SchemaChange, error) {
+	tableName := GenerateTableName(table)
+	fields, err := getTableInfo(table)
+	if err != nil {
+		return nil, err
+	}
+	indexes := extractIndexes(table)
+
+	exist, err := tableExists(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		changes := []isql.SchemaChange{{
+			Kind:  isql.CreateTable,
+			Table: tableName,
+			SQL:   createTableQuery(tableName, fields),
+		}}
+		for _, idx := range indexes {
+			changes = append(changes, addIndexChange(tableName, idx))
+		}
+		return changes, nil
+	}
+
+	columns, err := getExistingColumns(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	allIndexes, err := getIndexes(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	// MySQL keeps UNIQUE constraints as unique indexes. Those the struct
+	// does not declare as indexes are compared as constraints.
+	var liveIndexes, liveUnique []indexInfo
+	for _, idx := range allIndexes {
+		if idx.Unique && findIndex(indexes, idx.Name) == nil {
+			liveUnique = append(liveUnique, idx)
+		} else {
+			liveIndexes = append(liveIndexes, idx)
+		}
+	}
+	q := Dialect{}.QuoteIdent
+
+	// Drops come first, so that no index or constraint refers to a dropped
+	// column, and additions last, once their columns exist.
+	var changes, addConstraints, addIndexes []isql.SchemaChange
+	for _, live := range liveIndexes {
+		want := findIndex(indexes, live.Name)
+		if want != nil && want.Unique == live.Unique && sameColumns(want.Cols, live.Cols, false) {
+			continue
+		}
+		changes = append(changes, isql.SchemaChange{
+			Kind: isql.DropIndex, Table: tableName, Name: live.Name, From: describeIndex(live),
+			SQL: fmt.Sprintf("DROP INDEX %s ON %s", q(live.Name), q(tableName)),
+		})
+		if want != nil {
+			addIndexes = append(addIndexes, addIndexChange(tableName, *want))
+		}
+	}
+	for _, idx := range indexes {
+		if findIndex(liveIndexes, idx.Name) == nil {
+			addIndexes = append(addIndexes, addIndexChange(tableName, idx))
+		}
+	}
+
+	groups := uniqueGroups(fields, columns)
+	for _, live := range liveUnique {
+		if !hasGroup(groups, live.Cols) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.DropConstraint, Table: tableName, Name: live.Name,
+				From: "UNIQUE (" + strings.Join(live.Cols, ", ") + ")",
+				SQL:  fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", q(tableName), q(live.Name)),
+			})
+		}
+	}
+	for _, group := range groups {
+		if !hasGroup(liveUniqueColumns(liveUnique), group) {
+			addConstraints = append(addConstraints, isql.SchemaChange{
+				Kind: isql.AddConstraint, Table: tableName,
+				To:  "UNIQUE (" + strings.Join(group, ", ") + ")",
+				SQL: fmt.Sprintf("ALTER TABLE %s ADD UNIQUE (%s)", q(tableName), strings.Join(group, ", ")),
+			})
+		}
+	}
+
+	changes = append(changes, columnChanges(tableName, fields, columns)...)
+	changes = append(changes, addConstraints...)
+	return append(changes, addIndexes...), nil
+}
+
+// columnChanges compares fields with the live columns of a table: columns
+// to add, alter or drop.
+func columnChanges(tableName string, fields []fieldInfo, columns []columnInfo) []isql.SchemaChange {
+	var changes []isql.SchemaChange
+	q := Dialect{}.QuoteIdent
+	for _, f := range fields {
+		col := findColumn(columns, f.Name)
+		if col == nil {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AddColumn, Table: tableName, Name: f.Name, To: f.Type,
+				SQL: generateAddColumnQuery(tableName, []string{f.Name + " " + f.Type}),
+			})
+			continue
+		}
+		// MODIFY COLUMN restates the whole definition, so it serves the
+		// type, default and nullability changes alike.
+		modify := fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s %s",
+			q(tableName), f.Name, f.SQLType, nullability(f.NotNull))
+		if f.Default != "" {
+			modify += " DEFAULT " + f.Default
+		}
+		if have, want := normalizeType(col.Type), columnType(f.SQLType); want != "" && have != want {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterColumnType, Table: tableName, Name: f.Name, From: have, To: want,
+				SQL: modify,
+			})
+		}
+		if normalizeDefault(col.Default) != normalizeDefault(f.Default) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterDefault, Table: tableName, Name: f.Name, From: col.Default, To: f.Default,
+				SQL: modify,
+			})
+		}
+		if col.Nullable == f.NotNull {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterNullable, Table: tableName, Name: f.Name,
+				From: nullability(!col.Nullable), To: nullability(f.NotNull),
+				SQL: modify,
+			})
+		}
+	}
+	for _, col := range columns {
+		if !hasField(fields, col.Name) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.DropColumn, Table: tableName, Name: col.Name, From: col.Type,
+				SQL: fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", q(tableName), col.Name),
+			})
+		}
+	}
+	return changes
+}
+
+// syncApplies reports whether Sync may make change c on its own. Sync never
+// loosens the live schema: it adds columns, widens types and sets the
+// defaults and NOT NULL constraints fields declare. Dropping a column, a
+// default or a NOT NULL and narrowing a type are only reported by Plan.
+func syncApplies(c isql.SchemaChange) bool {
+	switch c.Kind {
+	case isql.AddColumn:
+		return true
+	case isql.AlterColumnType:
+		return widensType(c.From, c.To)
+	case isql.AlterDefault:
+		return c.To != ""
+	case isql.AlterNullable:
+		return c.To == nullability(true)
+	}
+	return false
+}
+
+// typeRanks orders the integer, floating-point and text types by the values
+// they hold; types of one family widen to a higher rank.
+var typeRanks = map[string]struct {
+	family string
+	rank   int
+}{
+	"TINYINT": {"int", 1}, "SMALLINT": {"int", 2}, "MEDIUMINT": {"int", 3}, "INT": {"int", 4}, "BIGINT": {"int", 5},
+	"FLOAT": {"float", 1}, "DOUBLE": {"float", 2},
+	"TEXT": {"text", 1}, "MEDIUMTEXT": {"text", 2}, "LONGTEXT": {"text", 3},
+}
+
+// widensType reports whether every value of the normalized type from fits
+// in the normalized type to: a longer VARCHAR or a TEXT type, a larger
+// signed integer or float, or a DECIMAL with at least as many digits on
+// both sides of the point. Unsigned types are never widened.
+func widensType(from, to string) bool {
+	fromName, fromArgs := splitType(from)
+	toName, toArgs := splitType(to)
+	fromRank, fromOK := typeRanks[fromName]
+	toRank, toOK := typeRanks[toName]
+	switch {
+	case fromOK && toOK:
+		return fromRank.family == toRank.family && fromRank.rank < toRank.rank
+	case fromName == "VARCHAR":
+		if toOK && toRank.family == "text" {
+			return true
+		}
+		return toName == fromName && len(fromArgs) == 1 && len(toArgs) == 1 && toArgs[0] >= fromArgs[0]
+	case fromName == "DECIMAL":
+		return toName == fromName && len(fromArgs) == 2 && len(toArgs) == 2 &&
+			toArgs[1] >= fromArgs[1] && toArgs[0]-toArgs[1] >= fromArgs[0]-fromArgs[1]
+	}
+	return false
+}
+
+// splitType splits a normalized type such as DECIMAL(10,2) or INT UNSIGNED
+// into its name, with any attributes, and its numeric arguments.
+func splitType(t string) (string, []int) {
+	name, rest, ok := strings.Cut(t, "(")
+	if !ok {
+		return t, nil
+	}
+	args, attrs, _ := strings.Cut(rest, ")")
+	if attrs = strings.TrimSpace(attrs); attrs != "" {
+		name += " " + attrs
+	}
+	var nums []int
+	for _, arg := range strings.Split(args, ",") {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return name, nil
+		}
+		nums = append(nums, n)
+	}
+	return name, nums
+}
+
+// columnType returns the column type of a field's SQL type, without
+// AUTO_INCREMENT.
+func columnType(sqlType string) string {
+	return normalizeType(strings.TrimSuffix(sqlType, " AUTO_INCREMENT"))
+}
+
+// displayWidth matches the display width older servers report for integer
+// types, as in int(11).
+var displayWidth = regexp.MustCompile(`^(TINYINT|SMALLINT|MEDIUMINT|INT|BIGINT)\(\d+\)`)
+
+// normalizeType returns the information_schema spelling of a type, upper
+// case and without integer display widths, so that a struct's SQL type
+// compares equal to the live one.
+func normalizeType(t string) string {
+	t = strings.Join(strings.Fields(strings.ToUpper(t)), " ")
+	switch t {
+	case "BOOLEAN", "BOOL":
+		return "TINYINT"
+	case "INTEGER":
+		return "INT"
+	}
+	return displayWidth.ReplaceAllString(t, "$1")
+}
+
+// currentTimestamp matches the spellings of the current time MySQL
+// reports as CURRENT_TIMESTAMP.
+var currentTimestamp = regexp.MustCompile(`^(now|current_timestamp)(\(\d*\))?$`)
+
+// normalizeDefault returns a DEFAULT expression in a form that compares
+// equal whether it was declared in a tag or read back from
+// information_schema, which drops the quotes of literals.
+func normalizeDefault(def string) string {
+	def = strings.TrimSpace(def)
+	if strings.HasPrefix(def, "(") && strings.HasSuffix(def, ")") {
+		def = def[1 : len(def)-1]
+	}
+	def = strings.ToLower(strings.Trim(def, "'"))
+	switch {
+	case def == "null":
+		return ""
+	case currentTimestamp.MatchString(def):
+		return "current_timestamp"
+	}
+	return def
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+func addIndexChange(tableName string, idx indexInfo) isql.SchemaChange {
+	return isql.SchemaChange{
+		Kind: isql.AddIndex, Table: tableName, Name: idx.Name, To: describeIndex(idx),
+		SQL: createIndexQuery(tableName, idx),
+	}
+}
+
+func describeIndex(idx indexInfo) string {
+	s := "(" + strings.Join(idx.Cols, ", ") + ")"
+	if idx.Unique {
+		s = "UNIQUE " + s
+	}
+	return s
+}
+
+func findIndex(indexes []indexInfo, name string) *indexInfo {
+	for i := range indexes {
+		if indexes[i].Name == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+func hasField(fields []fieldInfo, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueGroups returns the column sets of the UNIQUE constraints that fields
+// declare: one per uq column and one for the uqs columns together. uq
+// columns missing from the live table are left out, as adding the column
+// adds its constraint too.
+func uniqueGroups(fields []fieldInfo, columns []columnInfo) [][]string {
+	var groups [][]string
+	var composite []string
+	for _, f := range fields {
+		if f.Unique && findColumn(columns, f.Name) != nil {
+			groups = append(groups, []string{f.Name})
+		}
+		if f.IsComposite {
+			composite = append(composite, f.Name)
+		}
+	}
+	if len(composite) > 0 {
+		groups = append(groups, composite)
+	}
+	return groups
+}
+
+func liveUniqueColumns(constraints []indexInfo) [][]string {
+	groups := make([][]string, len(constraints))
+	for i, c := range constraints {
+		groups[i] = c.Cols
+	}
+	return groups
+}
+
+func hasGroup(groups [][]string, cols []string) bool {
+	for _, g := range groups {
+		if sameColumns(g, cols, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameColumns reports whether a and b list the same columns, in the same
+// order unless anyOrder is set.
+func sameColumns(a, b []string, anyOrder bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if anyOrder {
+		a = append([]string(nil), a...)
+		b = append([]string(nil), b...)
+		sort.Strings(a)
+		sort.Strings(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
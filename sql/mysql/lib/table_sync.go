@@ -0,0 +1,443 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	isql "github.com/masudur-rahman/styx/sql"
+
+	"github.com/iancoleman/strcase"
+)
+
+type fieldInfo struct {
+	Name        string
+	Type        string
+	IsComposite bool
+	// SQLType is Type without the column constraints.
+	SQLType string
+	NotNull bool
+	Unique  bool
+	Default string
+}
+
+// columnInfo describes a column of a live table.
+type columnInfo struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  string
+}
+
+func GenerateTableName(table interface{}) string {
+	return isql.GetTableName(table)
+}
+
+func getTableInfo(table interface{}) ([]fieldInfo, error) {
+	tableType := reflect.TypeOf(table)
+	tableValue := reflect.ValueOf(table)
+
+	if tableType.Kind() == reflect.Ptr {
+		tableType = tableType.Elem()
+		tableValue = tableValue.Elem()
+	}
+
+	if tableType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("getTableInfo: table is expected to be struct, got %v", tableType.Kind())
+	}
+
+	var fields []fieldInfo
+	for i := 0; i < tableType.NumField(); i++ {
+		fieldType := tableType.Field(i)
+		fieldValue := tableValue.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		fields = append(fields, getFieldInfo(fieldType, fieldValue))
+	}
+
+	return fields, nil
+}
+
+func createTable(ctx context.Context, conn *sql.DB, tableName string, fields []fieldInfo) error {
+	query := createTableQuery(tableName, fields)
+	_, err := ExecuteWriteQuery(ctx, query, conn)
+	return err
+}
+
+// alterColumns adds the missing columns of an existing table and modifies
+// those whose type, default or nullability differ from fields, as long as
+// none of the changes would loosen the live schema, see syncApplies. Since
+// MODIFY COLUMN restates the whole definition, a column with any such
+// change is left as it is and reported by Plan only, as are the columns
+// the struct no longer declares.
+func alterColumns(ctx context.Context, conn *sql.DB, tableName string, fields []fieldInfo) error {
+	columns, err := getExistingColumns(ctx, conn, tableName)
+	if err != nil {
+		return err
+	}
+
+	changes := columnChanges(tableName, fields, columns)
+	kept := map[string]bool{}
+	for _, c := range changes {
+		if !syncApplies(c) {
+			kept[c.Name] = true
+		}
+	}
+
+	var last string
+	for _, c := range changes {
+		// One MODIFY COLUMN makes all the changes of a column.
+		if kept[c.Name] || c.SQL == last {
+			continue
+		}
+		if _, err = ExecuteWriteQuery(ctx, c.SQL, conn); err != nil {
+			return err
+		}
+		last = c.SQL
+	}
+	return nil
+}
+
+func getFieldInfo(fieldType reflect.StructField, fieldValue reflect.Value) fieldInfo {
+	fieldName := getFieldName(fieldType)
+	columnConstraint, autoincr, isComposite := getFieldConstraint(fieldType)
+	opts := isql.GetColumnOptions(fieldType)
+	sqlType := Dialect{}.SQLType(fieldValue.Type(), autoincr)
+	if isql.IsJSONField(fieldType) {
+		sqlType = "JSON"
+	}
+	sqlType = declaredType(sqlType, fieldValue.Type(), opts)
+
+	definition := sqlType
+	if opts.NotNull {
+		definition += " NOT NULL"
+	}
+	if opts.Default != "" {
+		definition += " DEFAULT " + opts.Default
+	}
+	if columnConstraint != "" {
+		definition += " " + columnConstraint
+	}
+	return fieldInfo{
+		Name:        fieldName,
+		Type:        definition,
+		IsComposite: isComposite,
+		SQLType:     sqlType,
+		NotNull:     opts.NotNull || strings.Contains(columnConstraint, "PRIMARY KEY"),
+		Unique:      strings.Contains(columnConstraint, "UNIQUE"),
+		Default:     opts.Default,
+	}
+}
+
+// declaredType applies the type, size and precision options of a field to
+// sqlType, the type its Go type maps to.
+func declaredType(sqlType string, t reflect.Type, opts isql.ColumnOptions) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case opts.Type != "":
+		sqlType = opts.Type
+	case opts.Precision > 0:
+		sqlType = "DECIMAL"
+	case opts.Size > 0 && t.Kind() == reflect.String:
+		sqlType = "VARCHAR"
+	default:
+		return sqlType
+	}
+
+	if strings.Contains(sqlType, "(") {
+		return sqlType
+	}
+	switch {
+	case opts.Precision > 0 && opts.Scale > 0:
+		return fmt.Sprintf("%s(%d,%d)", sqlType, opts.Precision, opts.Scale)
+	case opts.Precision > 0:
+		return fmt.Sprintf("%s(%d)", sqlType, opts.Precision)
+	case opts.Size > 0:
+		return fmt.Sprintf("%s(%d)", sqlType, opts.Size)
+	}
+	return sqlType
+}
+
+func getFieldName(fieldType reflect.StructField) string {
+	return isql.GetFieldName(fieldType)
+}
+
+func getFieldConstraint(fieldType reflect.StructField) (fc string, autoincr bool, isComposite bool) {
+	constraints := []string{}
+	if dbTag := fieldType.Tag.Get("db"); dbTag != "" {
+		tagParts := strings.SplitN(dbTag, ",", 2)
+		if len(tagParts) > 1 {
+			for _, part := range strings.Fields(tagParts[1]) {
+				switch strings.ToUpper(part) {
+				case "PK":
+					constraints = append(constraints, "PRIMARY KEY")
+				case "UQ":
+					constraints = append(constraints, "UNIQUE")
+				case "UQS":
+					isComposite = true
+				case "AUTOINCR":
+					autoincr = true
+				}
+			}
+		}
+	}
+
+	return strings.Join(constraints, " "), autoincr, isComposite
+}
+
+func tableExists(ctx context.Context, conn *sql.DB, tableName string) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	var count int
+	if err := conn.QueryRowContext(ctx, query, tableName).Scan(&count); err != nil {
+		return false, fmt.Errorf("error checking if table exists: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+func createTableQuery(tableName string, fields []fieldInfo) string {
+	var columnDefs []string
+	var compositeKeyGroup []string
+	for _, field := range fields {
+		columnDefs = append(columnDefs, fmt.Sprintf("%s %s", field.Name, field.Type))
+		if field.IsComposite {
+			compositeKeyGroup = append(compositeKeyGroup, field.Name)
+		}
+	}
+
+	columnSQL := strings.Join(columnDefs, ", ")
+	if len(compositeKeyGroup) > 0 {
+		columnSQL += fmt.Sprintf(", UNIQUE(%s)", strings.Join(compositeKeyGroup, ", "))
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", Dialect{}.QuoteIdent(tableName), columnSQL)
+}
+
+func generateAddColumnQuery(tableName string, missingColumns []string) string {
+	var addColumns []string
+	for _, col := range missingColumns {
+		addColumns = append(addColumns, fmt.Sprintf("ADD COLUMN %s", col))
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s %s", Dialect{}.QuoteIdent(tableName), strings.Join(addColumns, ", "))
+}
+
+func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]columnInfo, error) {
+	query := "SELECT column_name, column_type, is_nullable, column_default FROM information_schema.columns " +
+		"WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+	rows, err := conn.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting columns for table %s: %v", tableName, err)
+	}
+	defer rows.Close()
+
+	var columns []columnInfo
+	for rows.Next() {
+		var col columnInfo
+		var nullable string
+		var def sql.NullString
+		if err = rows.Scan(&col.Name, &col.Type, &nullable, &def); err != nil {
+			return nil, fmt.Errorf("error scanning column for table %s: %v", tableName, err)
+		}
+		col.Nullable = nullable == "YES"
+		col.Default = def.String
+		columns = append(columns, col)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting columns for table %s: %v", tableName, err)
+	}
+
+	return columns, nil
+}
+
+func findColumn(columns []columnInfo, name string) *columnInfo {
+	for i := range columns {
+		if strings.EqualFold(columns[i].Name, name) {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+// getIndexes returns the indexes of a table other than its primary key.
+// MySQL keeps UNIQUE constraints as unique indexes, so they are included.
+func getIndexes(ctx context.Context, conn *sql.DB, tableName string) ([]indexInfo, error) {
+	query := "SELECT index_name, column_name, non_unique FROM information_schema.statistics " +
+		"WHERE table_schema = DATABASE() AND table_name = ? AND index_name <> 'PRIMARY' " +
+		"ORDER BY index_name, seq_in_index"
+	rows, err := conn.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting indexes for table %s: %v", tableName, err)
+	}
+	defer rows.Close()
+
+	var indexes []indexInfo
+	for rows.Next() {
+		var name, column string
+		var nonUnique int
+		if err = rows.Scan(&name, &column, &nonUnique); err != nil {
+			return nil, fmt.Errorf("error scanning index for table %s: %v", tableName, err)
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Cols = append(indexes[n-1].Cols, column)
+			continue
+		}
+		indexes = append(indexes, indexInfo{Name: name, Cols: []string{column}, Unique: nonUnique == 0})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting indexes for table %s: %v", tableName, err)
+	}
+
+	return indexes, nil
+}
+
+// indexInfo holds parsed index metadata from struct tags.
+type indexInfo struct {
+	Name   string
+	Cols   []string
+	Unique bool
+}
+
+// extractIndexes parses idx and unique_idx tags from a struct type.
+// Supports: db:"col,idx" (auto-named), db:"col,idx:my_index" (named/composite).
+func extractIndexes(table any) []indexInfo {
+	tableType := reflect.TypeOf(table)
+	if tableType.Kind() == reflect.Ptr {
+		tableType = tableType.Elem()
+	}
+	if tableType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	named := map[string]*indexInfo{}
+	var names []string
+	var unnamed []indexInfo
+
+	for i := 0; i < tableType.NumField(); i++ {
+		field := tableType.Field(i)
+		dbTag := field.Tag.Get("db")
+		if dbTag == "" {
+			continue
+		}
+		parts := strings.SplitN(dbTag, ",", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		colName := parts[0]
+		if colName == "" {
+			colName = strcase.ToSnake(field.Name)
+		}
+
+		for _, part := range strings.Fields(parts[1]) {
+			lp := strings.ToLower(part)
+			switch {
+			case lp == "idx":
+				unnamed = append(unnamed, indexInfo{Cols: []string{colName}})
+			case lp == "unique_idx":
+				unnamed = append(unnamed, indexInfo{Cols: []string{colName}, Unique: true})
+			case strings.HasPrefix(lp, "idx:"), strings.HasPrefix(lp, "unique_idx:"):
+				unique, idxName, _ := strings.Cut(lp, ":")
+				if existing, ok := named[idxName]; ok {
+					existing.Cols = append(existing.Cols, colName)
+				} else {
+					named[idxName] = &indexInfo{Name: idxName, Cols: []string{colName}, Unique: unique == "unique_idx"}
+					names = append(names, idxName)
+				}
+			}
+		}
+	}
+
+	var result []indexInfo
+	for _, name := range names {
+		result = append(result, *named[name])
+	}
+	result = append(result, unnamed...)
+	for i := range result {
+		if result[i].Name == "" {
+			result[i].Name = fmt.Sprintf("idx_%s_%d", GenerateTableName(table), i)
+		}
+	}
+	return result
+}
+
+func indexExists(ctx context.Context, conn *sql.DB, tableName, indexName string) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?"
+	var count int
+	if err := conn.QueryRowContext(ctx, query, tableName, indexName).Scan(&count); err != nil {
+		return false, fmt.Errorf("error checking if index %s exists: %v", indexName, err)
+	}
+	return count > 0, nil
+}
+
+// createIndexes creates the missing indexes. MySQL has no CREATE INDEX IF NOT
+// EXISTS, so existing ones are looked up in information_schema first.
+func createIndexes(ctx context.Context, conn *sql.DB, tableName string, indexes []indexInfo) error {
+	for _, idx := range indexes {
+		exists, err := indexExists(ctx, conn, tableName, idx.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := ExecuteWriteQuery(ctx, createIndexQuery(tableName, idx), conn); err != nil {
+			return fmt.Errorf("error creating index %s: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+func createIndexQuery(tableName string, idx indexInfo) string {
+	q := Dialect{}.QuoteIdent
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)",
+		unique, q(idx.Name), q(tableName), strings.Join(idx.Cols, ", "))
+}
+
+// DropTable drops a table by name.
+func DropTable(ctx context.Context, conn *sql.DB, tableName string) error {
+	query := fmt.Sprintf("DROP TABLE IF EXISTS %s", Dialect{}.QuoteIdent(tableName))
+	_, err := ExecuteWriteQuery(ctx, query, conn)
+	return err
+}
+
+func SyncTable(ctx context.Context, conn *sql.DB, table any) error {
+	tableName := GenerateTableName(table)
+	fields, err := getTableInfo(table)
+	if err != nil {
+		return err
+	}
+
+	if exist, err := tableExists(ctx, conn, tableName); err != nil {
+		return err
+	} else if !exist {
+		if err = createTable(ctx, conn, tableName, fields); err != nil {
+			return err
+		}
+	} else {
+		if err = alterColumns(ctx, conn, tableName, fields); err != nil {
+			return err
+		}
+	}
+
+	indexes := extractIndexes(table)
+	if len(indexes) > 0 {
+		if err = createIndexes(ctx, conn, tableName, indexes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/masudur-rahman/styx/sql/builder"
+	"github.com/masudur-rahman/styx/sql/mysql/lib"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// MySQL is the MySQL engine: the shared builder.Engine with the MySQL
+// dialect and the go-sql-driver/mysql driver hooks.
+type MySQL = builder.Engine
+
+func NewMySQL(conn *sql.DB) MySQL {
+	return builder.NewEngine(conn, lib.NewStatement(), builder.Driver{
+		IsRetryable:    lib.IsRetryable,
+		ConsecutiveIDs: lib.ConsecutiveIDs,
+		SyncTable:      lib.SyncTable,
+		PlanTable:      lib.PlanTable,
+		DropTable:      lib.DropTable,
+	})
+}
@@ -0,0 +1,427 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/masudur-rahman/styx/dberr"
+	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/mysql"
+	"github.com/masudur-rahman/styx/sql/mysql/lib"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type User struct {
+	ID        int64      `db:"id,pk autoincr"`
+	Name      string     `db:"name,uq"`
+	Email     string     `db:"email,idx"`
+	Age       int        `db:"age"`
+	Active    bool       `db:"active"`
+	DeletedAt *time.Time `db:"deleted_at,soft_delete"`
+}
+
+// connect connects to the server in STYX_MYSQL_DSN or, when unset, to an
+// in-memory go-mysql-server started for the test.
+func connect(t *testing.T) *sql.DB {
+	dsn := os.Getenv("STYX_MYSQL_DSN")
+	if dsn == "" {
+		engine := sqle.NewDefault(memory.NewDBProvider(memory.NewDatabase("styx")))
+		srv, err := server.NewDefaultServer(server.Config{Protocol: "tcp", Address: "127.0.0.1:0"}, engine)
+		require.NoError(t, err)
+		go srv.Start()
+		t.Cleanup(func() { _ = srv.Close() })
+		dsn = fmt.Sprintf("root@tcp(%s)/styx?parseTime=true&clientFoundRows=true", srv.Listener.Addr())
+	}
+
+	conn, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func setupDB(t *testing.T) isql.Engine {
+	db := mysql.NewMySQL(connect(t))
+	ctx := context.Background()
+	require.NoError(t, db.DropTable(ctx, "user"))
+	require.NoError(t, db.Sync(ctx, User{}))
+	return db
+}
+
+func TestMySQL_InsertAndFind(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	user := &User{Name: "alice", Email: "alice@example.com", Age: 30, Active: true}
+	id, err := db.InsertOne(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+	assert.Equal(t, int64(1), user.ID)
+
+	explicit := &User{ID: 10, Name: "bob", Email: "bob@example.com", Age: 25}
+	id, err = db.InsertOne(ctx, explicit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), id, "an explicit key is reported back")
+
+	var found User
+	has, err := db.Table("user").FindOne(ctx, &found, User{Name: "alice"})
+	require.NoError(t, err)
+	assert.True(t, has)
+	assert.Equal(t, *user, found)
+
+	var users []User
+	err = db.Table("user").OrderBy("id").Limit(10).Offset(1).FindMany(ctx, &users)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "bob", users[0].Name)
+
+	// Without arguments the driver uses the text protocol, returning numbers as []byte.
+	users = nil
+	require.NoError(t, db.QueryInto(ctx, &users, "SELECT * FROM `user` ORDER BY id"))
+	require.Len(t, users, 2)
+	assert.Equal(t, *user, users[0])
+	assert.Equal(t, int64(10), users[1].ID)
+}
+
+func TestMySQL_InsertMany(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	docs := []any{
+		&User{Name: "u1", Email: "u1@example.com"},
+		&User{Name: "u2", Email: "u2@example.com"},
+		&User{Name: "u3", Email: "u3@example.com"},
+	}
+	ids, err := db.Table("user").InsertMany(ctx, docs)
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(1), int64(2), int64(3)}, ids)
+	assert.Equal(t, int64(3), docs[2].(*User).ID)
+}
+
+func TestConsecutiveIDs(t *testing.T) {
+	ctx := context.Background()
+	conn := connect(t)
+
+	consecutive, err := lib.ConsecutiveIDs(ctx, conn, nil)
+	require.NoError(t, err)
+	assert.True(t, consecutive)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.ExecContext(ctx, "SET SESSION auto_increment_increment = 2")
+	require.NoError(t, err)
+	consecutive, err = lib.ConsecutiveIDs(ctx, conn, tx)
+	require.NoError(t, err)
+	assert.False(t, consecutive)
+}
+
+func TestMySQL_InsertMany_autoIncrementIncrement(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	tx, err := db.BeginTx(ctx)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.Exec(ctx, "SET SESSION auto_increment_increment = 2")
+	require.NoError(t, err)
+
+	docs := []any{
+		&User{Name: "u1", Email: "u1@example.com"},
+		&User{Name: "u2", Email: "u2@example.com"},
+		&User{Name: "u3", Email: "u3@example.com"},
+	}
+	ids, err := tx.Table("user").InsertMany(ctx, docs)
+	require.NoError(t, err)
+
+	var users []User
+	require.NoError(t, tx.Table("user").OrderBy("id").FindMany(ctx, &users))
+	require.Len(t, users, 3)
+	for i, user := range users {
+		assert.Equal(t, user.ID, ids[i], "ids are read per row, not derived from the first")
+		assert.Equal(t, user.ID, docs[i].(*User).ID)
+	}
+}
+
+func TestMySQL_FindPage(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for _, name := range []string{"u1", "u2", "u3"} {
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@example.com"})
+		require.NoError(t, err)
+	}
+
+	var users []User
+	p, err := db.Table("user").OrderBy("id").FindPage(ctx, &users, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), p.TotalItems)
+	assert.Equal(t, int64(2), p.TotalPages)
+	require.Len(t, users, 1)
+	assert.Equal(t, "u3", users[0].Name)
+}
+
+func TestMySQL_FindCursor(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for _, name := range []string{"u1", "u2", "u3"} {
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@example.com"})
+		require.NoError(t, err)
+	}
+
+	var users []User
+	page, err := db.Table("user").Limit(2).FindCursor(ctx, &users)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "u2", users[1].Name)
+	assert.True(t, page.HasNext)
+
+	page, err = db.Table("user").Limit(2).After(page.Next).FindCursor(ctx, &users)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "u3", users[0].Name)
+	assert.False(t, page.HasNext)
+	assert.True(t, page.HasPrev)
+
+	page, err = db.Table("user").Limit(2).Before(page.Prev).FindCursor(ctx, &users)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "u1", users[0].Name)
+	assert.True(t, page.HasNext)
+	assert.False(t, page.HasPrev)
+}
+
+func TestMySQL_Aggregates(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i, age := range []int{20, 30, 30} {
+		name := fmt.Sprintf("u%d", i+1)
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@example.com", Age: age})
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Table("user").ID(1).DeleteOne(ctx, User{}))
+
+	var total int64
+	require.NoError(t, db.Sum("age").Scalar(ctx, &total, User{}))
+	assert.Equal(t, int64(60), total)
+
+	n, err := db.CountRows(ctx, User{Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	// The text protocol returns numbers as text; FindMaps converts them.
+	rows, err := db.Table("user").Columns("age").Count("*", "n").GroupBy("age").FindMaps(ctx, User{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(30), rows[0]["age"])
+	assert.Equal(t, int64(2), rows[0]["n"])
+}
+
+func TestMySQL_Upsert(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	first := &User{Name: "alice", Email: "alice@example.com", Age: 30}
+	id, err := db.Table("user").Upsert(ctx, first, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	again := &User{Name: "alice", Email: "alice@new.com", Age: 31}
+	id, err = db.Table("user").Upsert(ctx, again, []string{"name"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id, "an update reports the existing row's id")
+	assert.Equal(t, int64(1), again.ID)
+
+	var found User
+	_, err = db.Table("user").ID(1).FindOne(ctx, &found)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@new.com", found.Email)
+	assert.Equal(t, 31, found.Age)
+}
+
+type account struct {
+	Code string `db:"code,pk"`
+	Name string `db:"name"`
+}
+
+func TestMySQL_Upsert_stringKey(t *testing.T) {
+	ctx := context.Background()
+	db := mysql.NewMySQL(connect(t))
+	require.NoError(t, db.DropTable(ctx, "account"))
+	require.NoError(t, db.Sync(ctx, account{}))
+
+	_, err := db.Upsert(ctx, &account{Code: "ab-12", Name: "first"}, []string{"code"})
+	require.NoError(t, err)
+	_, err = db.Upsert(ctx, &account{Code: "ab-12", Name: "second"}, []string{"code"})
+	require.NoError(t, err)
+
+	var accounts []account
+	require.NoError(t, db.Table("account").FindMany(ctx, &accounts))
+	assert.Equal(t, []account{{Code: "ab-12", Name: "second"}}, accounts, "the key is not rewritten")
+}
+
+func TestMySQL_UpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i := 1; i <= 3; i++ {
+		_, err := db.InsertOne(ctx, &User{Name: fmt.Sprintf("u%d", i), Email: "x@example.com", Age: 20})
+		require.NoError(t, err)
+	}
+
+	err := db.Table("user").ID(1).UpdateOne(ctx, User{Age: 20})
+	assert.NoError(t, err, "an unchanged row still counts as matched")
+
+	n, err := db.Table("user").Where("age = ?", 20).UpdateMany(ctx, User{Age: 21})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	err = db.Table("user").ID(2).DeleteOne(ctx, User{})
+	require.NoError(t, err)
+
+	var users []User
+	require.NoError(t, db.Table("user").FindMany(ctx, &users))
+	assert.Len(t, users, 2)
+
+	n, err = db.Table("user").Where("age = ?", 21).DeleteMany(ctx, User{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n, "already deleted rows are skipped")
+
+	_, err = db.Table("user").ID(1).ForceDeleteReturning(ctx, &users)
+	assert.ErrorIs(t, err, dberr.ErrNotSupported)
+}
+
+func TestMySQL_Transaction(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	tx, err := db.BeginTx(ctx)
+	require.NoError(t, err)
+	_, err = tx.InsertOne(ctx, &User{Name: "alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	var users []User
+	require.NoError(t, db.Table("user").FindMany(ctx, &users))
+	assert.Len(t, users, 1)
+}
+
+// userV2 is User as a later release declares it.
+type userV2 struct {
+	ID     int64  `db:"id,pk autoincr"`
+	Name   string `db:"name"`
+	Email  string `db:"email,idx"`
+	Age    int64  `db:"age,idx"`
+	Active bool   `db:"active"`
+	Bio    string `db:"bio"`
+}
+
+func (userV2) TableName() string { return "user" }
+
+func TestMySQL_Plan(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	changes, err := db.Plan(ctx, User{})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = db.Plan(ctx, userV2{})
+	require.NoError(t, err)
+	var kinds []isql.ChangeKind
+	for _, c := range changes {
+		kinds = append(kinds, c.Kind)
+	}
+	assert.Equal(t, []isql.ChangeKind{isql.DropConstraint, isql.AlterColumnType, isql.AddColumn, isql.DropColumn, isql.AddIndex}, kinds)
+	assert.Equal(t, "alter column type user.age: INT -> BIGINT", changes[1].String())
+
+	// The rendered statements bring the table in line with userV2.
+	for _, c := range changes {
+		_, err = db.Exec(ctx, c.SQL)
+		require.NoError(t, err, c.SQL)
+	}
+	changes, err = db.Plan(ctx, userV2{})
+	require.NoError(t, err)
+	assert.Empty(t, changes, isql.PlanSQL(changes))
+}
+
+type invoice struct {
+	ID     int64   `db:"id,pk autoincr"`
+	Number string  `db:"number,size:32"`
+	Amount float64 `db:"amount,precision:10,2 default:0 notnull"`
+	Status string  `db:"status,default:'draft'"`
+}
+
+type invoiceV2 struct {
+	ID     int64   `db:"id,pk autoincr"`
+	Number string  `db:"number,size:64 notnull"`
+	Amount float64 `db:"amount,precision:12,2 default:0 notnull"`
+	Status string  `db:"status,default:'open' notnull"`
+}
+
+func (invoiceV2) TableName() string { return "invoice" }
+
+func TestMySQL_SyncAltersColumns(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	require.NoError(t, db.DropTable(ctx, "invoice"))
+	require.NoError(t, db.Sync(ctx, invoice{}))
+
+	changes, err := db.Plan(ctx, invoice{})
+	require.NoError(t, err)
+	assert.Empty(t, changes, isql.PlanSQL(changes))
+
+	_, err = db.Exec(ctx, "INSERT INTO invoice (number) VALUES ('INV-1')")
+	require.NoError(t, err)
+	var inv invoice
+	_, err = db.Table("invoice").Where("number = ?", "INV-1").FindOne(ctx, &inv)
+	require.NoError(t, err)
+	assert.Equal(t, "draft", inv.Status)
+
+	changes, err = db.Plan(ctx, invoiceV2{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, changes)
+	assert.Contains(t, isql.PlanSQL(changes), "ALTER TABLE `invoice` MODIFY COLUMN status VARCHAR(255) NOT NULL DEFAULT 'open';\n")
+
+	require.NoError(t, db.Sync(ctx, invoiceV2{}))
+	changes, err = db.Plan(ctx, invoiceV2{})
+	require.NoError(t, err)
+	assert.Empty(t, changes, isql.PlanSQL(changes))
+}
+
+// invoiceLoose narrows number and no longer declares the default and NOT
+// NULL of amount.
+type invoiceLoose struct {
+	ID     int64   `db:"id,pk autoincr"`
+	Number string  `db:"number,size:16"`
+	Amount float64 `db:"amount,precision:10,2"`
+	Status string  `db:"status,default:'draft'"`
+}
+
+func (invoiceLoose) TableName() string { return "invoice" }
+
+func TestMySQL_SyncDoesNotLoosenColumns(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	require.NoError(t, db.DropTable(ctx, "invoice"))
+	require.NoError(t, db.Sync(ctx, invoice{}))
+
+	changes, err := db.Plan(ctx, invoiceLoose{})
+	require.NoError(t, err)
+	assert.Len(t, changes, 3, isql.PlanSQL(changes))
+
+	require.NoError(t, db.Sync(ctx, invoiceLoose{}))
+	changes, err = db.Plan(ctx, invoice{})
+	require.NoError(t, err)
+	assert.Empty(t, changes, "the live columns are unchanged: %s", isql.PlanSQL(changes))
+}
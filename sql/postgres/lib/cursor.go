@@ -0,0 +1,116 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// FetchSize is how many rows a server-side cursor fetches per round trip.
+const FetchSize = 1000
+
+var cursorSeq atomic.Int64
+
+// CursorRows streams the rows of a query through a server-side cursor
+// (DECLARE ... CURSOR), fetching FetchSize rows at a time so that neither
+// the client nor the driver buffers the whole result. A cursor only lives
+// inside a transaction: the caller's when there is one, otherwise a
+// read-only transaction that Close ends.
+type CursorRows struct {
+	ctx     context.Context
+	tx      *sql.Tx
+	ownTx   bool
+	name    string
+	rows    *sql.Rows
+	fetched int
+	done    bool
+	closed  bool
+	err     error
+}
+
+// DeclareCursor declares a cursor for query on tx, or on a new transaction
+// begun on conn when tx is nil, and returns its rows.
+func DeclareCursor(ctx context.Context, conn *sql.DB, tx *sql.Tx, query string, args ...any) (*CursorRows, error) {
+	cr := &CursorRows{ctx: ctx, tx: tx, name: fmt.Sprintf("styx_cursor_%d", cursorSeq.Add(1))}
+	if tx == nil {
+		var err error
+		if cr.tx, err = conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+			return nil, TranslateError(err)
+		}
+		cr.ownTx = true
+	}
+
+	if _, err := cr.tx.ExecContext(ctx, "DECLARE "+cr.name+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		if cr.ownTx {
+			_ = cr.tx.Rollback()
+		}
+		return nil, TranslateError(err)
+	}
+	return cr, nil
+}
+
+// Next prepares the next row, fetching another batch when the current one
+// is exhausted.
+func (cr *CursorRows) Next() bool {
+	if cr.closed || cr.err != nil {
+		return false
+	}
+	for {
+		if cr.rows != nil {
+			if cr.rows.Next() {
+				cr.fetched++
+				return true
+			}
+			cr.err = cr.rows.Err()
+			cr.rows.Close()
+			cr.rows = nil
+			if cr.err != nil || cr.fetched < FetchSize {
+				cr.done = true
+			}
+		}
+		if cr.done {
+			return false
+		}
+
+		cr.fetched = 0
+		cr.rows, cr.err = cr.tx.QueryContext(cr.ctx, fmt.Sprintf("FETCH FORWARD %d FROM %s", FetchSize, cr.name))
+		if cr.err != nil {
+			cr.done = true
+			return false
+		}
+	}
+}
+
+// Scan copies the current row into doc as isql.ScanRow does.
+func (cr *CursorRows) Scan(doc any) error {
+	if cr.rows == nil {
+		return sql.ErrNoRows
+	}
+	return TranslateError(isql.ScanRow(cr.rows, doc))
+}
+
+// Err returns the error that ended the iteration, if any.
+func (cr *CursorRows) Err() error {
+	return TranslateError(cr.err)
+}
+
+// Close closes the cursor and ends the transaction DeclareCursor began.
+// It is safe to call more than once.
+func (cr *CursorRows) Close() error {
+	if cr.closed {
+		return nil
+	}
+	cr.closed = true
+	if cr.rows != nil {
+		cr.rows.Close()
+	}
+	if cr.ownTx {
+		// Nothing was written; ending the transaction drops the cursor too.
+		return cr.tx.Rollback()
+	}
+	_, err := cr.tx.ExecContext(cr.ctx, "CLOSE "+cr.name)
+	return TranslateError(err)
+}
@@ -0,0 +1,134 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masudur-rahman/styx/sql/builder"
+)
+
+// MaxParams is the maximum number of bind parameters lib/pq accepts in a
+// single query.
+const MaxParams = 65535
+
+// Statement is the query builder used by the Postgres engine.
+type Statement = builder.Statement
+
+// NewStatement returns an empty Statement rendering PostgreSQL.
+func NewStatement() Statement {
+	return builder.NewStatement(Dialect{})
+}
+
+// Dialect renders PostgreSQL: numbered $N placeholders, double-quoted
+// identifiers and ON CONFLICT upserts.
+type Dialect struct{}
+
+var _ builder.Dialect = Dialect{}
+
+func (Dialect) Name() string {
+	return "postgres"
+}
+
+func (Dialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+var placeholderRegex = regexp.MustCompile(`\$(\d+)\b`)
+
+func (Dialect) ShiftPlaceholders(query string, offset int) string {
+	if offset == 0 {
+		return query
+	}
+	return placeholderRegex.ReplaceAllStringFunc(query, func(m string) string {
+		n, _ := strconv.Atoi(m[1:])
+		return fmt.Sprintf("$%d", n+offset)
+	})
+}
+
+func (Dialect) MaxParams() int {
+	return MaxParams
+}
+
+func (Dialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (Dialect) SQLType(fieldType reflect.Type, autoincr bool) string {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if autoincr {
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int32:
+			return "SERIAL"
+		case reflect.Int64, reflect.Uint64:
+			return "BIGSERIAL"
+		}
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int32:
+		return "INTEGER"
+	case reflect.Int64, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32, reflect.Float64:
+		return "FLOAT"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.String:
+		return "VARCHAR(255)"
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			return "BYTEA"
+		}
+		// Other slices, such as pq.StringArray, are arrays of their elements.
+		if fieldType.Elem().Kind() == reflect.String {
+			return "TEXT[]"
+		}
+		if elem := (Dialect{}).SQLType(fieldType.Elem(), false); elem != "" && !strings.HasSuffix(elem, "[]") {
+			return elem + "[]"
+		}
+	case reflect.Struct:
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			return "TIMESTAMP WITH TIME ZONE"
+		}
+	}
+
+	return ""
+}
+
+func (Dialect) SupportsReturning() bool {
+	return true
+}
+
+func (Dialect) SupportsWindowFunctions() bool {
+	return true
+}
+
+func (Dialect) UpsertClause(_ string, conflictCols, updateCols []string) string {
+	sets := make([]string, len(updateCols))
+	for i, col := range updateCols {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", col, col)
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(conflictCols, ", "), strings.Join(sets, ", "))
+}
+
+func (Dialect) LimitOffset(limit, offset int64) string {
+	var parts []string
+	if limit > 0 {
+		parts = append(parts, fmt.Sprintf("LIMIT %d", limit))
+	}
+	if offset > 0 {
+		parts = append(parts, fmt.Sprintf("OFFSET %d", offset))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (Dialect) TranslateError(err error) error {
+	return TranslateError(err)
+}
@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"errors"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/sql/builder"
+
+	"github.com/lib/pq"
+)
+
+// IsRetryable reports whether err is a serialization failure (SQLSTATE 40001)
+// or a deadlock (40P01), after which the whole transaction may be retried.
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// TranslateError wraps lib/pq errors into a *dberr.DBError by SQLSTATE,
+// carrying the constraint, table and column the server reports. Errors that
+// map to no dberr sentinel are returned unchanged.
+func TranslateError(err error) error {
+	var dbErr *dberr.DBError
+	if err == nil || errors.As(err, &dbErr) {
+		return err
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		if builder.IsConnError(err) {
+			return &dberr.DBError{Err: dberr.ErrConnectionFailed, Cause: err}
+		}
+		return err
+	}
+
+	var sentinel error
+	switch pqErr.Code {
+	case "23505":
+		sentinel = dberr.ErrDuplicateEntry
+	case "23503":
+		sentinel = dberr.ErrForeignKeyViolation
+	case "23502":
+		sentinel = dberr.ErrNotNullViolation
+	case "23514":
+		sentinel = dberr.ErrCheckViolation
+	case "40P01":
+		sentinel = dberr.ErrDeadlock
+	case "40001":
+		sentinel = dberr.ErrSerializationFailure
+	default:
+		if pqErr.Code.Class() != "08" {
+			return err
+		}
+		sentinel = dberr.ErrConnectionFailed
+	}
+
+	return &dberr.DBError{
+		Err:        sentinel,
+		Constraint: pqErr.Constraint,
+		Table:      pqErr.Table,
+		Column:     pqErr.Column,
+		Cause:      err,
+	}
+}
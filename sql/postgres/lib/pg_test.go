@@ -1,9 +1,15 @@
 package lib
 
 import (
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/masudur-rahman/styx/dberr"
+
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,6 +25,11 @@ func Test_generateReadQuery(t *testing.T) {
 	})
 }
 
+func newStatement() *Statement {
+	stmt := NewStatement()
+	return &stmt
+}
+
 type insertTestDoc struct {
 	ID    int64  `db:"id,pk autoincr"`
 	Name  string `db:"name"`
@@ -27,7 +38,7 @@ type insertTestDoc struct {
 }
 
 func TestGenerateInsertQuery_skipsZeroValues(t *testing.T) {
-	stmt := new(Statement).Table("test_doc")
+	stmt := newStatement().Table("test_doc")
 	doc := insertTestDoc{Name: "alice", Email: "alice@test.com"}
 
 	query := stmt.GenerateInsertQuery(doc)
@@ -37,11 +48,11 @@ func TestGenerateInsertQuery_skipsZeroValues(t *testing.T) {
 	assert.NotContains(t, query, "score")
 	assert.NotContains(t, query, "id")
 	assert.Contains(t, query, "$1")
-	assert.Equal(t, []any{"alice", "alice@test.com"}, stmt.args)
+	assert.Equal(t, []any{"alice", "alice@test.com"}, stmt.Args())
 }
 
 func TestGenerateInsertQuery_mustColsIncludesZeroValues(t *testing.T) {
-	stmt := new(Statement).Table("test_doc").MustCols("score")
+	stmt := newStatement().Table("test_doc").MustCols("score")
 	doc := insertTestDoc{Name: "alice"}
 
 	query := stmt.GenerateInsertQuery(doc)
@@ -52,7 +63,7 @@ func TestGenerateInsertQuery_mustColsIncludesZeroValues(t *testing.T) {
 }
 
 func TestGenerateInsertQuery_allColsIncludesAllFields(t *testing.T) {
-	stmt := new(Statement).Table("test_doc").AllCols()
+	stmt := newStatement().Table("test_doc").AllCols()
 	doc := insertTestDoc{Name: "alice"}
 
 	query := stmt.GenerateInsertQuery(doc)
@@ -89,7 +100,7 @@ func TestCreateTableQuery_jsonColumns(t *testing.T) {
 }
 
 func TestGenerateInsertQuery_jsonArgsAsText(t *testing.T) {
-	stmt := new(Statement).Table("json_test_doc")
+	stmt := newStatement().Table("json_test_doc")
 	doc := jsonTestDoc{
 		Name:    "alice",
 		Payload: json.RawMessage(`{"a":1}`),
@@ -100,15 +111,263 @@ func TestGenerateInsertQuery_jsonArgsAsText(t *testing.T) {
 
 	assert.Contains(t, query, "payload")
 	assert.Contains(t, query, "address")
-	assert.Equal(t, []any{"alice", `{"a":1}`, `{"street":"Road 1","city":"Dhaka"}`}, stmt.args)
+	assert.Equal(t, []any{"alice", `{"a":1}`, `{"street":"Road 1","city":"Dhaka"}`}, stmt.Args())
 }
 
 func TestGenerateUpdateQuery_jsonArgsAsText(t *testing.T) {
-	stmt := new(Statement).Table("json_test_doc").Where("id = ?", 7)
+	stmt := newStatement().Table("json_test_doc").Where("id = ?", 7)
 	doc := jsonTestDoc{Payload: json.RawMessage(`{"b":2}`)}
 
 	query := stmt.GenerateUpdateQuery(doc)
 
 	assert.Contains(t, query, "payload = $1")
-	assert.Equal(t, []any{`{"b":2}`, 7}, stmt.args)
+	assert.Equal(t, []any{`{"b":2}`, 7}, stmt.Args())
+}
+
+func TestGenerateInsertManyQuery_numbersPlaceholdersPerRow(t *testing.T) {
+	stmt := newStatement().Table("test_doc")
+	docs := []any{
+		insertTestDoc{Name: "alice", Email: "alice@test.com"},
+		&insertTestDoc{Name: "bob", Email: "bob@test.com"},
+	}
+
+	query := stmt.GenerateInsertManyQuery(docs)
+
+	assert.Equal(t, `INSERT INTO "test_doc" (name, email) VALUES ($1, $2), ($3, $4)`, query)
+	assert.Equal(t, []any{"alice", "alice@test.com", "bob", "bob@test.com"}, stmt.Args())
+}
+
+func TestInsertBatches_groupsByColumnsAndParamLimit(t *testing.T) {
+	stmt := newStatement().Table("test_doc")
+	docs := []any{
+		insertTestDoc{Name: "a", Email: "a@test.com"},
+		insertTestDoc{Name: "b", Email: "b@test.com"},
+		insertTestDoc{Name: "c", Email: "c@test.com", Score: 1},
+		insertTestDoc{Name: "d", Email: "d@test.com"},
+	}
+
+	batches := stmt.InsertBatches(docs)
+
+	assert.Equal(t, [][]any{docs[:2], docs[2:3], docs[3:]}, batches)
+
+	docs = make([]any, MaxParams/2+1)
+	for i := range docs {
+		docs[i] = insertTestDoc{Name: "n", Email: "e"}
+	}
+	batches = stmt.InsertBatches(docs)
+	assert.Len(t, batches, 2)
+	assert.Len(t, batches[0], MaxParams/2)
+	assert.Len(t, batches[1], 1)
+}
+
+func TestGenerateUpsertQuery(t *testing.T) {
+	doc := insertTestDoc{Name: "alice", Email: "alice@test.com", Score: 3}
+
+	t.Run("default update columns", func(t *testing.T) {
+		stmt := newStatement().Table("test_doc").PKColumn("id")
+		query, err := stmt.GenerateUpsertQuery(doc, []string{"email"}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "test_doc" (name, email, score) VALUES ($1, $2, $3) ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name, score = EXCLUDED.score`, query)
+		assert.Equal(t, []any{"alice", "alice@test.com", 3}, stmt.Args())
+	})
+
+	t.Run("explicit update columns", func(t *testing.T) {
+		stmt := newStatement().Table("test_doc").PKColumn("id")
+		query, err := stmt.GenerateUpsertQuery(doc, []string{"email"}, []string{"score"})
+		assert.NoError(t, err)
+		assert.Contains(t, query, "ON CONFLICT (email) DO UPDATE SET score = EXCLUDED.score")
+	})
+
+	t.Run("only key columns", func(t *testing.T) {
+		stmt := newStatement().Table("test_doc").PKColumn("id")
+		query, err := stmt.GenerateUpsertQuery(insertTestDoc{Email: "alice@test.com"}, []string{"email"}, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, query, "ON CONFLICT (email) DO UPDATE SET email = EXCLUDED.email")
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&pq.Error{Code: "40001"}))
+	assert.True(t, IsRetryable(fmt.Errorf("commit: %w", &pq.Error{Code: "40P01"})))
+	assert.False(t, IsRetryable(&pq.Error{Code: "23505"}))
+	assert.False(t, IsRetryable(errors.New("40001")))
+}
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"23505", dberr.ErrDuplicateEntry},
+		{"23503", dberr.ErrForeignKeyViolation},
+		{"23502", dberr.ErrNotNullViolation},
+		{"23514", dberr.ErrCheckViolation},
+		{"40P01", dberr.ErrDeadlock},
+		{"40001", dberr.ErrSerializationFailure},
+		{"08006", dberr.ErrConnectionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			cause := &pq.Error{Code: pq.ErrorCode(tt.code), Constraint: "c", Table: "user", Column: "email"}
+			err := TranslateError(cause)
+
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorIs(t, err, cause)
+			var dbErr *dberr.DBError
+			if assert.ErrorAs(t, err, &dbErr) {
+				assert.Equal(t, "c", dbErr.Constraint)
+				assert.Equal(t, "user", dbErr.Table)
+				assert.Equal(t, "email", dbErr.Column)
+			}
+			assert.Same(t, err, TranslateError(err), "translated errors are not wrapped twice")
+		})
+	}
+
+	syntax := &pq.Error{Code: "42601"}
+	assert.Same(t, syntax, TranslateError(syntax))
+	assert.ErrorIs(t, TranslateError(driver.ErrBadConn), dberr.ErrConnectionFailed)
+	assert.NoError(t, TranslateError(nil))
+}
+
+func TestNormalizeType(t *testing.T) {
+	// Struct SQL types against what information_schema reports for them.
+	for sqlType, live := range map[string]string{
+		"BIGSERIAL":                "bigint",
+		"SERIAL":                   "integer",
+		"VARCHAR(255)":             "character varying(255)",
+		"FLOAT":                    "double precision",
+		"BOOLEAN":                  "boolean",
+		"TIMESTAMP WITH TIME ZONE": "timestamp with time zone",
+		"JSONB":                    "jsonb",
+	} {
+		assert.Equal(t, normalizeType(live), normalizeType(sqlType), sqlType)
+	}
+	assert.NotEqual(t, normalizeType("integer"), normalizeType("BIGINT"))
+}
+
+type columnOptionsDoc struct {
+	ID      string         `db:"id,pk type:UUID default:gen_random_uuid()"`
+	Name    string         `db:"name,size:512 notnull"`
+	Price   float64        `db:"price,precision:10,2 default:0 notnull"`
+	Created string         `db:"created,type:TIMESTAMPTZ default:now()"`
+	Tags    pq.StringArray `db:"tags"`
+	Scores  pq.Int64Array  `db:"scores"`
+}
+
+func TestCreateTableQuery_columnOptions(t *testing.T) {
+	fields, err := getTableInfo(columnOptionsDoc{})
+	assert.NoError(t, err)
+
+	query := createTableQuery("column_options_doc", fields)
+
+	assert.Contains(t, query, "id UUID DEFAULT gen_random_uuid() PRIMARY KEY")
+	assert.Contains(t, query, "name VARCHAR(512) NOT NULL")
+	assert.Contains(t, query, "price NUMERIC(10,2) NOT NULL DEFAULT 0")
+	assert.Contains(t, query, "created TIMESTAMPTZ DEFAULT now()")
+	assert.Contains(t, query, "tags TEXT[]")
+	assert.Contains(t, query, "scores BIGINT[]")
+}
+
+func TestNormalizeDefault(t *testing.T) {
+	// Tag defaults against what information_schema reports for them.
+	for tag, live := range map[string]string{
+		"'draft'": "'draft'::character varying",
+		"now()":   "CURRENT_TIMESTAMP",
+		"0":       "0",
+		"'{}'":    "'{}'::text[]",
+		"":        "NULL::character varying",
+	} {
+		assert.Equal(t, normalizeDefault(live), normalizeDefault(tag), tag)
+	}
+	assert.NotEqual(t, normalizeDefault("'open'"), normalizeDefault("'draft'::character varying"))
+}
+
+type foreignKeyDoc struct {
+	ID       int64 `db:"id,pk autoincr"`
+	UserID   int64 `db:"user_id,fk:users.id ondelete:cascade"`
+	ParentID int64 `db:"parent_id,fk:foreign_key_doc onupdate:set_null"`
+}
+
+func TestCreateTableQuery_foreignKeys(t *testing.T) {
+	fields, err := getTableInfo(foreignKeyDoc{})
+	assert.NoError(t, err)
+
+	query := createTableQuery("foreign_key_doc", fields)
+
+	assert.Contains(t, query, `CONSTRAINT "fk_foreign_key_doc_user_id" FOREIGN KEY (user_id) REFERENCES "users" (id) ON DELETE CASCADE`)
+	assert.Contains(t, query, `CONSTRAINT "fk_foreign_key_doc_parent_id" FOREIGN KEY (parent_id) REFERENCES "foreign_key_doc" (id) ON UPDATE SET NULL);`)
+}
+
+func TestForeignKeyChanges(t *testing.T) {
+	fields, err := getTableInfo(foreignKeyDoc{})
+	assert.NoError(t, err)
+	want := foreignKeys("foreign_key_doc", fields)
+	live := []foreignKeyInfo{
+		{Name: "foreign_key_doc_user_id_fkey", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "NO ACTION", OnUpdate: "NO ACTION"},
+		{Name: "fk_foreign_key_doc_parent_id", Column: "parent_id", RefTable: "foreign_key_doc", RefColumn: "id", OnDelete: "NO ACTION", OnUpdate: "SET NULL"},
+		{Name: "foreign_key_doc_org_id_fkey", Column: "org_id", RefTable: "orgs", RefColumn: "id", OnDelete: "NO ACTION", OnUpdate: "NO ACTION"},
+	}
+
+	drops, adds := foreignKeyChanges("foreign_key_doc", want, live, false)
+	if assert.Len(t, drops, 1) {
+		assert.Equal(t, `ALTER TABLE "foreign_key_doc" DROP CONSTRAINT "foreign_key_doc_user_id_fkey"`, drops[0].SQL)
+	}
+	if assert.Len(t, adds, 1) {
+		assert.Equal(t, `ALTER TABLE "foreign_key_doc" ADD CONSTRAINT "fk_foreign_key_doc_user_id" FOREIGN KEY (user_id) REFERENCES "users" (id) ON DELETE CASCADE`, adds[0].SQL)
+	}
+
+	drops, _ = foreignKeyChanges("foreign_key_doc", want, live, true)
+	assert.Len(t, drops, 2, "Plan also reports the undeclared foreign key")
+}
+
+func TestWidensType(t *testing.T) {
+	for _, tc := range []struct {
+		from, to string
+		widens   bool
+	}{
+		{"CHARACTER VARYING(32)", "CHARACTER VARYING(64)", true},
+		{"CHARACTER VARYING(64)", "CHARACTER VARYING(32)", false},
+		{"CHARACTER VARYING(255)", "TEXT", true},
+		{"TEXT", "CHARACTER VARYING(255)", false},
+		{"INTEGER", "BIGINT", true},
+		{"BIGINT", "INTEGER", false},
+		{"REAL", "DOUBLE PRECISION", true},
+		{"NUMERIC(10,2)", "NUMERIC(12,2)", true},
+		{"NUMERIC(10,2)", "NUMERIC(10,4)", false},
+		{"INTEGER", "TEXT", false},
+	} {
+		assert.Equal(t, tc.widens, widensType(tc.from, tc.to), "%s -> %s", tc.from, tc.to)
+	}
+}
+
+type hardenedDoc struct {
+	Code   string `db:"code,size:16"`
+	Name   string `db:"name,size:64"`
+	Status string `db:"status,default:'open' notnull"`
+}
+
+func TestSyncApplies(t *testing.T) {
+	fields, err := getTableInfo(hardenedDoc{})
+	assert.NoError(t, err)
+	live := []columnInfo{
+		{Name: "code", Type: "character varying(32)", Default: "'x'::character varying"},
+		{Name: "name", Type: "character varying(32)"},
+		{Name: "status", Type: "character varying(255)", Nullable: true},
+	}
+
+	var applied, planned []string
+	for _, c := range columnChanges("hardened_doc", fields, live) {
+		planned = append(planned, c.SQL)
+		if syncApplies(c) {
+			applied = append(applied, c.SQL)
+		}
+	}
+
+	assert.Contains(t, planned, `ALTER TABLE "hardened_doc" ALTER COLUMN code TYPE CHARACTER VARYING(16) USING code::CHARACTER VARYING(16)`)
+	assert.Contains(t, planned, `ALTER TABLE "hardened_doc" ALTER COLUMN code DROP DEFAULT`)
+	assert.Equal(t, []string{
+		`ALTER TABLE "hardened_doc" ALTER COLUMN name TYPE CHARACTER VARYING(64) USING name::CHARACTER VARYING(64)`,
+		`ALTER TABLE "hardened_doc" ALTER COLUMN status SET DEFAULT 'open'`,
+		`ALTER TABLE "hardened_doc" ALTER COLUMN status SET NOT NULL`,
+	}, applied, "Sync neither narrows code nor drops its default")
 }
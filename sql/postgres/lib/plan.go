@@ -0,0 +1,442 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// PlanTable compares table, a struct, with the live table and returns the
+// changes that would make them match, without applying any.
+func PlanTable(ctx context.Context, conn *sql.DB, table any) ([]isql.SchemaChange, error) {
+	tableName := GenerateTableName(table)
+	fields, err := getTableInfo(table)
+	if err != nil {
+		return nil, err
+	}
+	indexes := extractIndexes(table)
+
+	exist, err := tableExists(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		changes := []isql.SchemaChange{{
+			Kind:  isql.CreateTable,
+			Table: tableName,
+			SQL:   strings.TrimSuffix(createTableQuery(tableName, fields), ";"),
+		}}
+		for _, idx := range indexes {
+			changes = append(changes, addIndexChange(tableName, idx))
+		}
+		return changes, nil
+	}
+
+	columns, err := getExistingColumns(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	liveIndexes, err := getIndexes(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	liveUnique, err := getUniqueConstraints(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	liveForeignKeys, err := getForeignKeys(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drops come first, so that no index or constraint refers to a dropped
+	// column, and additions last, once their columns exist.
+	var changes, addConstraints, addIndexes []isql.SchemaChange
+	for _, live := range liveIndexes {
+		want := findIndex(indexes, live.Name)
+		if want != nil && want.Unique == live.Unique && sameColumns(want.Cols, live.Cols, false) {
+			continue
+		}
+		changes = append(changes, isql.SchemaChange{
+			Kind: isql.DropIndex, Table: tableName, Name: live.Name, From: describeIndex(live),
+			SQL: fmt.Sprintf("DROP INDEX \"%s\"", live.Name),
+		})
+		if want != nil {
+			addIndexes = append(addIndexes, addIndexChange(tableName, *want))
+		}
+	}
+	for _, idx := range indexes {
+		if findIndex(liveIndexes, idx.Name) == nil {
+			addIndexes = append(addIndexes, addIndexChange(tableName, idx))
+		}
+	}
+
+	groups := uniqueGroups(fields, columns)
+	for _, live := range liveUnique {
+		if !hasGroup(groups, live.Cols) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.DropConstraint, Table: tableName, Name: live.Name,
+				From: "UNIQUE (" + strings.Join(live.Cols, ", ") + ")",
+				SQL:  fmt.Sprintf("ALTER TABLE \"%s\" DROP CONSTRAINT \"%s\"", tableName, live.Name),
+			})
+		}
+	}
+	for _, group := range groups {
+		if !hasGroup(liveUniqueColumns(liveUnique), group) {
+			addConstraints = append(addConstraints, isql.SchemaChange{
+				Kind: isql.AddConstraint, Table: tableName,
+				To:  "UNIQUE (" + strings.Join(group, ", ") + ")",
+				SQL: fmt.Sprintf("ALTER TABLE \"%s\" ADD UNIQUE (%s)", tableName, strings.Join(group, ", ")),
+			})
+		}
+	}
+
+	dropForeignKeys, addForeignKeys := foreignKeyChanges(tableName, foreignKeys(tableName, fields), liveForeignKeys, true)
+	changes = append(changes, dropForeignKeys...)
+	addConstraints = append(addConstraints, addForeignKeys...)
+
+	changes = append(changes, columnChanges(tableName, fields, columns)...)
+	changes = append(changes, addConstraints...)
+	return append(changes, addIndexes...), nil
+}
+
+// columnChanges compares fields with the live columns of a table: columns
+// to add, alter or drop.
+func columnChanges(tableName string, fields []fieldInfo, columns []columnInfo) []isql.SchemaChange {
+	var changes []isql.SchemaChange
+	alter := fmt.Sprintf("ALTER TABLE \"%s\" ALTER COLUMN ", tableName)
+	for _, f := range fields {
+		col := findColumn(columns, f.Name)
+		if col == nil {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AddColumn, Table: tableName, Name: f.Name, To: f.Type,
+				SQL: generateAddColumnQuery(tableName, []string{f.Name + " " + f.Type}),
+			})
+			continue
+		}
+		if have, want := normalizeType(col.Type), normalizeType(f.SQLType); want != "" && have != want {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterColumnType, Table: tableName, Name: f.Name, From: have, To: want,
+				SQL: fmt.Sprintf("%s%s TYPE %s USING %s::%s", alter, f.Name, want, f.Name, want),
+			})
+		}
+		// Serial columns take their default from a sequence.
+		serial := strings.HasPrefix(col.Default, "nextval(")
+		if !serial && normalizeDefault(col.Default) != normalizeDefault(f.Default) {
+			action := "DROP DEFAULT"
+			if f.Default != "" {
+				action = "SET DEFAULT " + f.Default
+			}
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterDefault, Table: tableName, Name: f.Name, From: col.Default, To: f.Default,
+				SQL: alter + f.Name + " " + action,
+			})
+		}
+		if col.Nullable == f.NotNull {
+			action := "DROP NOT NULL"
+			if f.NotNull {
+				action = "SET NOT NULL"
+			}
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterNullable, Table: tableName, Name: f.Name,
+				From: nullability(!col.Nullable), To: nullability(f.NotNull),
+				SQL: alter + f.Name + " " + action,
+			})
+		}
+	}
+	for _, col := range columns {
+		if !hasField(fields, col.Name) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.DropColumn, Table: tableName, Name: col.Name, From: col.Type,
+				SQL: fmt.Sprintf("ALTER TABLE \"%s\" DROP COLUMN %s", tableName, col.Name),
+			})
+		}
+	}
+	return changes
+}
+
+// syncApplies reports whether Sync makes change c on its own. Sync never
+// loosens the live schema: it adds columns, widens types and sets the
+// defaults and NOT NULL constraints fields declare. Dropping a column, a
+// default or a NOT NULL and narrowing a type are only reported by Plan.
+func syncApplies(c isql.SchemaChange) bool {
+	switch c.Kind {
+	case isql.AddColumn:
+		return true
+	case isql.AlterColumnType:
+		return widensType(c.From, c.To)
+	case isql.AlterDefault:
+		return c.To != ""
+	case isql.AlterNullable:
+		return c.To == nullability(true)
+	}
+	return false
+}
+
+// integerRanks orders the integer types by the values they hold.
+var integerRanks = map[string]int{"SMALLINT": 1, "INTEGER": 2, "BIGINT": 3}
+
+// widensType reports whether every value of the normalized type from fits
+// in the normalized type to: a longer or unbounded CHARACTER VARYING or
+// TEXT, a larger integer, DOUBLE PRECISION for REAL, or a NUMERIC with at
+// least as many digits on both sides of the point.
+func widensType(from, to string) bool {
+	fromName, fromArgs := splitType(from)
+	toName, toArgs := splitType(to)
+	switch {
+	case integerRanks[fromName] > 0 && integerRanks[toName] > 0:
+		return integerRanks[fromName] < integerRanks[toName]
+	case integerRanks[fromName] > 0 && to == "NUMERIC":
+		return true
+	case from == "REAL":
+		return to == "DOUBLE PRECISION"
+	case fromName == "CHARACTER VARYING":
+		if to == "TEXT" || to == "CHARACTER VARYING" {
+			return true
+		}
+		return toName == fromName && len(fromArgs) == 1 && len(toArgs) == 1 && toArgs[0] >= fromArgs[0]
+	case fromName == "NUMERIC":
+		if to == "NUMERIC" {
+			return true
+		}
+		return toName == fromName && len(fromArgs) == 2 && len(toArgs) == 2 &&
+			toArgs[1] >= fromArgs[1] && toArgs[0]-toArgs[1] >= fromArgs[0]-fromArgs[1]
+	}
+	return false
+}
+
+// splitType splits a normalized type such as NUMERIC(10,2) into its name and
+// numeric arguments.
+func splitType(t string) (string, []int) {
+	name, args, ok := strings.Cut(t, "(")
+	if !ok {
+		return t, nil
+	}
+	var nums []int
+	for _, arg := range strings.Split(strings.TrimSuffix(args, ")"), ",") {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return name, nil
+		}
+		nums = append(nums, n)
+	}
+	return name, nums
+}
+
+// foreignKeyChanges compares the foreign keys a struct declares with the
+// live ones of its table, matched by column. Live foreign keys that differ
+// are dropped and added again; those on other columns are dropped only when
+// dropUndeclared is set.
+func foreignKeyChanges(tableName string, want, live []foreignKeyInfo, dropUndeclared bool) (drops, adds []isql.SchemaChange) {
+	for _, fk := range live {
+		declared := findForeignKey(want, fk.Column)
+		if declared == nil && !dropUndeclared {
+			continue
+		}
+		if declared != nil && sameForeignKey(*declared, fk) {
+			continue
+		}
+		drops = append(drops, isql.SchemaChange{
+			Kind: isql.DropConstraint, Table: tableName, Name: fk.Name, From: foreignKeyClause(fk),
+			SQL: fmt.Sprintf("ALTER TABLE \"%s\" DROP CONSTRAINT \"%s\"", tableName, fk.Name),
+		})
+	}
+	for _, fk := range want {
+		if existing := findForeignKey(live, fk.Column); existing != nil && sameForeignKey(*existing, fk) {
+			continue
+		}
+		adds = append(adds, isql.SchemaChange{
+			Kind: isql.AddConstraint, Table: tableName, Name: fk.Name, To: foreignKeyClause(fk),
+			SQL: fmt.Sprintf("ALTER TABLE \"%s\" ADD CONSTRAINT \"%s\" %s", tableName, fk.Name, foreignKeyClause(fk)),
+		})
+	}
+	return drops, adds
+}
+
+func findForeignKey(fks []foreignKeyInfo, column string) *foreignKeyInfo {
+	for i := range fks {
+		if fks[i].Column == column {
+			return &fks[i]
+		}
+	}
+	return nil
+}
+
+// sameForeignKey reports whether a and b reference the same column with the
+// same actions, whatever their names.
+func sameForeignKey(a, b foreignKeyInfo) bool {
+	return a.RefTable == b.RefTable && a.RefColumn == b.RefColumn && a.OnDelete == b.OnDelete && a.OnUpdate == b.OnUpdate
+}
+
+// typeAliases maps alternative spellings of a type, including the serial
+// types, to the name information_schema reports.
+var typeAliases = map[string]string{
+	"INT":         "INTEGER",
+	"INT4":        "INTEGER",
+	"SERIAL":      "INTEGER",
+	"INT8":        "BIGINT",
+	"BIGSERIAL":   "BIGINT",
+	"INT2":        "SMALLINT",
+	"SMALLSERIAL": "SMALLINT",
+	"FLOAT":       "DOUBLE PRECISION",
+	"FLOAT8":      "DOUBLE PRECISION",
+	"FLOAT4":      "REAL",
+	"BOOL":        "BOOLEAN",
+	"TIMESTAMP":   "TIMESTAMP WITHOUT TIME ZONE",
+	"TIMESTAMPTZ": "TIMESTAMP WITH TIME ZONE",
+	"VARCHAR":     "CHARACTER VARYING",
+	"CHAR":        "CHARACTER",
+	"DECIMAL":     "NUMERIC",
+}
+
+// normalizeType returns the information_schema spelling of a type, upper
+// case, so that a struct's SQL type compares equal to the live one.
+func normalizeType(t string) string {
+	t = strings.Join(strings.Fields(strings.ToUpper(t)), " ")
+	if elem, ok := strings.CutSuffix(t, "[]"); ok {
+		// information_schema has no length for the elements of an array.
+		elem, _, _ = strings.Cut(elem, "(")
+		return normalizeType(elem) + "[]"
+	}
+	if alias, ok := typeAliases[t]; ok {
+		return alias
+	}
+
+	name, args, ok := strings.Cut(t, "(")
+	if !ok {
+		return t
+	}
+	if alias, ok := typeAliases[name]; ok {
+		name = alias
+	}
+	if name == "NUMERIC" && !strings.Contains(args, ",") {
+		args = strings.TrimSuffix(args, ")") + ",0)"
+	}
+	return name + "(" + strings.ReplaceAll(args, " ", "")
+}
+
+// cast matches the type cast PostgreSQL appends to a stored default, as in
+// 'draft'::character varying.
+var cast = regexp.MustCompile(`::[a-z ]+(\[\])?$`)
+
+// normalizeDefault returns a DEFAULT expression in a form that compares
+// equal whether it was declared in a tag or read back from the catalog.
+func normalizeDefault(def string) string {
+	def = strings.TrimSpace(def)
+	for cast.MatchString(def) {
+		def = cast.ReplaceAllString(def, "")
+	}
+	def = strings.Trim(def, "'")
+	switch lower := strings.ToLower(def); lower {
+	case "now()", "current_timestamp":
+		return "current_timestamp"
+	case "null":
+		return ""
+	default:
+		return lower
+	}
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+func addIndexChange(tableName string, idx indexInfo) isql.SchemaChange {
+	return isql.SchemaChange{
+		Kind: isql.AddIndex, Table: tableName, Name: idx.Name, To: describeIndex(idx),
+		SQL: createIndexQuery(tableName, idx),
+	}
+}
+
+func describeIndex(idx indexInfo) string {
+	s := "(" + strings.Join(idx.Cols, ", ") + ")"
+	if idx.Unique {
+		s = "UNIQUE " + s
+	}
+	return s
+}
+
+func findIndex(indexes []indexInfo, name string) *indexInfo {
+	for i := range indexes {
+		if indexes[i].Name == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+func hasField(fields []fieldInfo, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueGroups returns the column sets of the UNIQUE constraints that fields
+// declare: one per uq column and one for the uqs columns together. uq
+// columns missing from the live table are left out, as adding the column
+// adds its constraint too.
+func uniqueGroups(fields []fieldInfo, columns []columnInfo) [][]string {
+	var groups [][]string
+	var composite []string
+	for _, f := range fields {
+		if f.Unique && findColumn(columns, f.Name) != nil {
+			groups = append(groups, []string{f.Name})
+		}
+		if f.IsComposite {
+			composite = append(composite, f.Name)
+		}
+	}
+	if len(composite) > 0 {
+		groups = append(groups, composite)
+	}
+	return groups
+}
+
+func liveUniqueColumns(constraints []indexInfo) [][]string {
+	groups := make([][]string, len(constraints))
+	for i, c := range constraints {
+		groups[i] = c.Cols
+	}
+	return groups
+}
+
+func hasGroup(groups [][]string, cols []string) bool {
+	for _, g := range groups {
+		if sameColumns(g, cols, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameColumns reports whether a and b list the same columns, in the same
+// order unless anyOrder is set.
+func sameColumns(a, b []string, anyOrder bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if anyOrder {
+		a = append([]string(nil), a...)
+		b = append([]string(nil), b...)
+		sort.Strings(a)
+		sort.Strings(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
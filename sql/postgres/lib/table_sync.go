@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-	"time"
 
 	isql "github.com/masudur-rahman/styx/sql"
 
@@ -17,6 +16,22 @@ type fieldInfo struct {
 	Name        string
 	Type        string
 	IsComposite bool
+	// SQLType is Type without the column constraints.
+	SQLType string
+	NotNull bool
+	Unique  bool
+	Default string
+	// References, OnDelete and OnUpdate declare a foreign key, see
+	// isql.ColumnOptions.
+	References, OnDelete, OnUpdate string
+}
+
+// columnInfo describes a column of a live table.
+type columnInfo struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  string
 }
 
 func GenerateTableName(table interface{}) string {
@@ -59,18 +74,40 @@ func createTable(ctx context.Context, conn *sql.DB, tableName string, fields []f
 	return err
 }
 
-func addMissingColumns(ctx context.Context, conn *sql.DB, tableName string, fields []fieldInfo) error {
+// alterColumns adds the missing columns of an existing table, widens their
+// types and sets the defaults and NOT NULL constraints fields declare. The
+// changes that would loosen the live schema, see syncApplies, are left to
+// Plan; so are the columns the struct no longer declares.
+func alterColumns(ctx context.Context, conn *sql.DB, tableName string, fields []fieldInfo) error {
 	columns, err := getExistingColumns(ctx, conn, tableName)
 	if err != nil {
 		return err
 	}
 
-	missingColumns := getMissingColumns(fields, columns)
-	if len(missingColumns) > 0 {
-		alterQuery := generateAddColumnQuery(tableName, missingColumns)
-		_, err = ExecuteWriteQuery(ctx, alterQuery, conn)
-		if err != nil {
-			return fmt.Errorf("error adding columns to table %s: %v (query: %s)", tableName, err, alterQuery)
+	for _, c := range columnChanges(tableName, fields, columns) {
+		if !syncApplies(c) {
+			continue
+		}
+		if _, err = ExecuteWriteQuery(ctx, c.SQL, conn); err != nil {
+			return fmt.Errorf("error altering table %s: %v (query: %s)", tableName, err, c.SQL)
+		}
+	}
+	return nil
+}
+
+// alterForeignKeys adds the foreign keys fields declare to an existing table
+// and replaces those whose reference or actions changed. Foreign keys the
+// struct does not declare are left alone.
+func alterForeignKeys(ctx context.Context, conn *sql.DB, tableName string, fields []fieldInfo) error {
+	live, err := getForeignKeys(ctx, conn, tableName)
+	if err != nil {
+		return err
+	}
+
+	drops, adds := foreignKeyChanges(tableName, foreignKeys(tableName, fields), live, false)
+	for _, c := range append(drops, adds...) {
+		if _, err = ExecuteWriteQuery(ctx, c.SQL, conn); err != nil {
+			return fmt.Errorf("error altering foreign keys of table %s: %w (query: %s)", tableName, TranslateError(err), c.SQL)
 		}
 	}
 	return nil
@@ -79,20 +116,68 @@ func addMissingColumns(ctx context.Context, conn *sql.DB, tableName string, fiel
 func getFieldInfo(fieldType reflect.StructField, fieldValue reflect.Value) fieldInfo {
 	fieldName := getFieldName(fieldType)
 	columnConstraint, autoincr, isComposite := getFieldConstraint(fieldType)
-	if columnConstraint != "" {
-		columnConstraint = " " + columnConstraint
-	}
-	sqlType := getSQLType(fieldValue.Type(), autoincr)
+	opts := isql.GetColumnOptions(fieldType)
+	sqlType := Dialect{}.SQLType(fieldValue.Type(), autoincr)
 	if isql.IsJSONField(fieldType) {
 		sqlType = "JSONB"
 	}
+	sqlType = declaredType(sqlType, fieldValue.Type(), opts)
+
+	definition := sqlType
+	if opts.NotNull {
+		definition += " NOT NULL"
+	}
+	if opts.Default != "" {
+		definition += " DEFAULT " + opts.Default
+	}
+	if columnConstraint != "" {
+		definition += " " + columnConstraint
+	}
 	return fieldInfo{
 		Name:        fieldName,
-		Type:        sqlType + columnConstraint,
+		Type:        definition,
 		IsComposite: isComposite,
+		SQLType:     sqlType,
+		NotNull:     opts.NotNull || strings.Contains(columnConstraint, "PRIMARY KEY"),
+		Unique:      strings.Contains(columnConstraint, "UNIQUE"),
+		Default:     opts.Default,
+		References:  opts.References,
+		OnDelete:    opts.OnDelete,
+		OnUpdate:    opts.OnUpdate,
 	}
 }
 
+// declaredType applies the type, size and precision options of a field to
+// sqlType, the type its Go type maps to.
+func declaredType(sqlType string, t reflect.Type, opts isql.ColumnOptions) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case opts.Type != "":
+		sqlType = opts.Type
+	case opts.Precision > 0:
+		sqlType = "NUMERIC"
+	case opts.Size > 0 && t.Kind() == reflect.String:
+		sqlType = "VARCHAR"
+	default:
+		return sqlType
+	}
+
+	if strings.Contains(sqlType, "(") {
+		return sqlType
+	}
+	switch {
+	case opts.Precision > 0 && opts.Scale > 0:
+		return fmt.Sprintf("%s(%d,%d)", sqlType, opts.Precision, opts.Scale)
+	case opts.Precision > 0:
+		return fmt.Sprintf("%s(%d)", sqlType, opts.Precision)
+	case opts.Size > 0:
+		return fmt.Sprintf("%s(%d)", sqlType, opts.Size)
+	}
+	return sqlType
+}
+
 func getFieldName(fieldType reflect.StructField) string {
 	return isql.GetFieldName(fieldType)
 }
@@ -100,7 +185,7 @@ func getFieldName(fieldType reflect.StructField) string {
 func getFieldConstraint(fieldType reflect.StructField) (fc string, autoincr bool, isComposite bool) {
 	constraints := []string{}
 	if dbTag := fieldType.Tag.Get("db"); dbTag != "" {
-		tagParts := strings.Split(dbTag, ",")
+		tagParts := strings.SplitN(dbTag, ",", 2)
 		if len(tagParts) > 1 {
 			for _, part := range strings.Fields(tagParts[1]) {
 				switch strings.ToUpper(part) {
@@ -227,43 +312,6 @@ func getUniqueColumnGroups(t reflect.Type) [][]string {
 	return result
 }
 
-func getSQLType(fieldType reflect.Type, autoincr bool) string {
-	for fieldType.Kind() == reflect.Ptr {
-		fieldType = fieldType.Elem()
-	}
-	if autoincr {
-		switch fieldType.Kind() {
-		case reflect.Int, reflect.Int32:
-			return "SERIAL"
-		case reflect.Int64, reflect.Uint64:
-			return "BIGSERIAL"
-		}
-	}
-
-	switch fieldType.Kind() {
-	case reflect.Int, reflect.Int32:
-		return "INTEGER"
-	case reflect.Int64, reflect.Uint64:
-		return "BIGINT"
-	case reflect.Float32, reflect.Float64:
-		return "FLOAT"
-	case reflect.Bool:
-		return "BOOLEAN"
-	case reflect.String:
-		return "VARCHAR(255)"
-	case reflect.Slice:
-		if fieldType.Elem().Kind() == reflect.Uint8 {
-			return "BYTEA"
-		}
-	case reflect.Struct:
-		if fieldType == reflect.TypeOf(time.Time{}) {
-			return "TIMESTAMP WITH TIME ZONE"
-		}
-	}
-
-	return ""
-}
-
 func tableExists(ctx context.Context, conn *sql.DB, tableName string) (bool, error) {
 	tableQuery := "" +
 		"SELECT EXISTS (" +
@@ -298,10 +346,110 @@ func createTableQuery(tableName string, fields []fieldInfo) string {
 		compositeKeySQL := fmt.Sprintf("UNIQUE(%s)", strings.Join(compositeKeyGroup, ", "))
 		columnSQL += ", " + compositeKeySQL
 	}
+	for _, fk := range foreignKeys(tableName, fields) {
+		columnSQL += fmt.Sprintf(", CONSTRAINT \"%s\" %s", fk.Name, foreignKeyClause(fk))
+	}
 
 	return fmt.Sprintf("CREATE TABLE \"%s\" (%s);", tableName, columnSQL)
 }
 
+// foreignKeyInfo is a FOREIGN KEY constraint on a single column.
+type foreignKeyInfo struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnDelete  string
+	OnUpdate  string
+}
+
+// foreignKeys returns the foreign keys fields declare, named
+// fk_<table>_<column>. A reference without a column points to id.
+func foreignKeys(tableName string, fields []fieldInfo) []foreignKeyInfo {
+	var fks []foreignKeyInfo
+	for _, f := range fields {
+		if f.References == "" {
+			continue
+		}
+		refTable, refColumn, ok := strings.Cut(f.References, ".")
+		if !ok {
+			refColumn = "id"
+		}
+		fks = append(fks, foreignKeyInfo{
+			Name:      fmt.Sprintf("fk_%s_%s", tableName, f.Name),
+			Column:    f.Name,
+			RefTable:  refTable,
+			RefColumn: refColumn,
+			OnDelete:  orNoAction(f.OnDelete),
+			OnUpdate:  orNoAction(f.OnUpdate),
+		})
+	}
+	return fks
+}
+
+func orNoAction(action string) string {
+	if action == "" {
+		return "NO ACTION"
+	}
+	return action
+}
+
+// foreignKeyClause renders fk as a table constraint, without its name.
+func foreignKeyClause(fk foreignKeyInfo) string {
+	clause := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES \"%s\" (%s)", fk.Column, fk.RefTable, fk.RefColumn)
+	if fk.OnDelete != "NO ACTION" {
+		clause += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "NO ACTION" {
+		clause += " ON UPDATE " + fk.OnUpdate
+	}
+	return clause
+}
+
+// referentialActions maps the action codes of pg_constraint to SQL.
+var referentialActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// getForeignKeys returns the single-column foreign keys of a table.
+func getForeignKeys(ctx context.Context, conn *sql.DB, tableName string) ([]foreignKeyInfo, error) {
+	query := `
+	SELECT c.conname, a.attname, rt.relname, ra.attname, c.confdeltype, c.confupdtype
+	FROM pg_constraint c
+	JOIN pg_class t ON t.oid = c.conrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	JOIN pg_class rt ON rt.oid = c.confrelid
+	JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = c.conkey[1]
+	JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = c.confkey[1]
+	WHERE c.contype = 'f' AND n.nspname = 'public' AND t.relname = $1 AND cardinality(c.conkey) = 1
+	ORDER BY c.conname;
+	`
+
+	rows, err := conn.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting foreign keys for table %s: %v", tableName, err)
+	}
+	defer rows.Close()
+
+	var fks []foreignKeyInfo
+	for rows.Next() {
+		var fk foreignKeyInfo
+		if err = rows.Scan(&fk.Name, &fk.Column, &fk.RefTable, &fk.RefColumn, &fk.OnDelete, &fk.OnUpdate); err != nil {
+			return nil, fmt.Errorf("error scanning foreign key for table %s: %v", tableName, err)
+		}
+		fk.OnDelete, fk.OnUpdate = referentialActions[fk.OnDelete], referentialActions[fk.OnUpdate]
+		fks = append(fks, fk)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting foreign keys for table %s: %v", tableName, err)
+	}
+	return fks, nil
+}
+
 func generateAddColumnQuery(tableName string, missingColumns []string) string {
 	alterQuery := fmt.Sprintf("ALTER TABLE \"%s\" ", tableName)
 	var addColumns []string
@@ -313,22 +461,43 @@ func generateAddColumnQuery(tableName string, missingColumns []string) string {
 	return alterQuery
 }
 
-func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]string, error) {
-	var columns []string
+func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]columnInfo, error) {
+	var columns []columnInfo
 
-	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_name='%s'", tableName))
+	query := "" +
+		"SELECT column_name, data_type, udt_name, character_maximum_length, " +
+		"numeric_precision, numeric_scale, is_nullable, column_default " +
+		"FROM information_schema.columns " +
+		"WHERE table_schema = 'public' AND table_name = $1 " +
+		"ORDER BY ordinal_position"
+	rows, err := conn.QueryContext(ctx, query, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("error getting columns for table %s: %v", tableName, err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var column string
-		err = rows.Scan(&column)
+		var col columnInfo
+		var udtName, nullable string
+		var maxLen, precision, scale sql.NullInt64
+		var def sql.NullString
+		err = rows.Scan(&col.Name, &col.Type, &udtName, &maxLen, &precision, &scale, &nullable, &def)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning column for table %s: %v", tableName, err)
 		}
-		columns = append(columns, column)
+		switch {
+		case col.Type == "ARRAY":
+			col.Type = strings.TrimPrefix(udtName, "_") + "[]"
+		case col.Type == "USER-DEFINED":
+			col.Type = udtName
+		case maxLen.Valid:
+			col.Type = fmt.Sprintf("%s(%d)", col.Type, maxLen.Int64)
+		case col.Type == "numeric" && precision.Valid:
+			col.Type = fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+		}
+		col.Nullable = nullable == "YES"
+		col.Default = def.String
+		columns = append(columns, col)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -338,25 +507,24 @@ func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]
 	return columns, nil
 }
 
-func getMissingColumns(fields []fieldInfo, columns []string) []string {
-	var missingColumns []string
-
-	for _, f := range fields {
-		if !contains(columns, f.Name) {
-			missingColumns = append(missingColumns, fmt.Sprintf("%s %s", f.Name, f.Type))
+func findColumn(columns []columnInfo, name string) *columnInfo {
+	for i := range columns {
+		if columns[i].Name == name {
+			return &columns[i]
 		}
 	}
-
-	return missingColumns
+	return nil
 }
 
-func getUniqueConstraints(ctx context.Context, conn *sql.DB, tableName string) ([][]string, error) {
+// getUniqueConstraints returns the UNIQUE constraints of a table.
+func getUniqueConstraints(ctx context.Context, conn *sql.DB, tableName string) ([]indexInfo, error) {
 	query := `
-	SELECT kcu.column_name
+	SELECT tc.constraint_name, kcu.column_name
 	FROM information_schema.table_constraints tc
-	JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name
-	WHERE tc.table_name = $1 AND tc.constraint_type = 'UNIQUE'
-	ORDER BY kcu.ordinal_position;
+	JOIN information_schema.key_column_usage kcu
+		ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+	WHERE tc.table_schema = 'public' AND tc.table_name = $1 AND tc.constraint_type = 'UNIQUE'
+	ORDER BY tc.constraint_name, kcu.ordinal_position;
 	`
 
 	rows, err := conn.QueryContext(ctx, query, tableName)
@@ -365,26 +533,65 @@ func getUniqueConstraints(ctx context.Context, conn *sql.DB, tableName string) (
 	}
 	defer rows.Close()
 
-	var columns []string
-	for rows.Next() {
-		var column string
-		err = rows.Scan(&column)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning unique constraint for table %s: %v", tableName, err)
-		}
-		columns = append(columns, column)
+	constraints, err := scanIndexColumns(rows, true)
+	if err != nil {
+		return nil, fmt.Errorf("error getting unique constraints for table %s: %v", tableName, err)
 	}
+	return constraints, nil
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error getting unique constraints for table %s: %v", tableName, err)
+// getIndexes returns the indexes of a table other than those backing its
+// primary key and UNIQUE constraints.
+func getIndexes(ctx context.Context, conn *sql.DB, tableName string) ([]indexInfo, error) {
+	query := `
+	SELECT i.relname, a.attname, ix.indisunique
+	FROM pg_index ix
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	JOIN pg_class t ON t.oid = ix.indrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	JOIN LATERAL unnest(ix.indkey::smallint[]) WITH ORDINALITY AS k(attnum, ord) ON true
+	JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+	WHERE n.nspname = 'public' AND t.relname = $1
+		AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conindid = ix.indexrelid)
+	ORDER BY i.relname, k.ord;
+	`
+
+	rows, err := conn.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting indexes for table %s: %v", tableName, err)
 	}
+	defer rows.Close()
 
-	var result [][]string
-	if len(columns) > 0 {
-		result = append(result, columns)
+	indexes, err := scanIndexColumns(rows, false)
+	if err != nil {
+		return nil, fmt.Errorf("error getting indexes for table %s: %v", tableName, err)
 	}
+	return indexes, nil
+}
 
-	return result, nil
+// scanIndexColumns groups rows of index name, column name and, unless
+// allUnique is set, uniqueness into indexes.
+func scanIndexColumns(rows *sql.Rows, allUnique bool) ([]indexInfo, error) {
+	var indexes []indexInfo
+	for rows.Next() {
+		var name, column string
+		unique := allUnique
+		var err error
+		if allUnique {
+			err = rows.Scan(&name, &column)
+		} else {
+			err = rows.Scan(&name, &column, &unique)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Cols = append(indexes[n-1].Cols, column)
+			continue
+		}
+		indexes = append(indexes, indexInfo{Name: name, Cols: []string{column}, Unique: unique})
+	}
+	return indexes, rows.Err()
 }
 
 func generateDropConstraintStatement(tableName string, uqConstraints [][]string) string {
@@ -423,15 +630,6 @@ func generateAddConstraintStatement(tableName string,
 	return sql
 }
 
-func contains(slice []string, val string) bool {
-	for _, v := range slice {
-		if v == val {
-			return true
-		}
-	}
-	return false
-}
-
 // indexInfo holds parsed index metadata from struct tags.
 type indexInfo struct {
 	Name   string
@@ -450,6 +648,7 @@ func extractIndexes(table any) []indexInfo {
 	}
 
 	named := map[string]*indexInfo{}
+	var names []string
 	var unnamed []indexInfo
 
 	for i := 0; i < tableType.NumField(); i++ {
@@ -469,54 +668,56 @@ func extractIndexes(table any) []indexInfo {
 
 		for _, part := range strings.Fields(parts[1]) {
 			lp := strings.ToLower(part)
-			if lp == "idx" {
+			switch {
+			case lp == "idx":
 				unnamed = append(unnamed, indexInfo{Cols: []string{colName}})
-			} else if lp == "unique_idx" {
+			case lp == "unique_idx":
 				unnamed = append(unnamed, indexInfo{Cols: []string{colName}, Unique: true})
-			} else if strings.HasPrefix(lp, "idx:") {
-				idxName := strings.TrimPrefix(lp, "idx:")
+			case strings.HasPrefix(lp, "idx:"), strings.HasPrefix(lp, "unique_idx:"):
+				unique, idxName, _ := strings.Cut(lp, ":")
 				if existing, ok := named[idxName]; ok {
 					existing.Cols = append(existing.Cols, colName)
 				} else {
-					named[idxName] = &indexInfo{Name: idxName, Cols: []string{colName}}
-				}
-			} else if strings.HasPrefix(lp, "unique_idx:") {
-				idxName := strings.TrimPrefix(lp, "unique_idx:")
-				if existing, ok := named[idxName]; ok {
-					existing.Cols = append(existing.Cols, colName)
-				} else {
-					named[idxName] = &indexInfo{Name: idxName, Cols: []string{colName}, Unique: true}
+					named[idxName] = &indexInfo{Name: idxName, Cols: []string{colName}, Unique: unique == "unique_idx"}
+					names = append(names, idxName)
 				}
 			}
 		}
 	}
 
+	// Named indexes keep their tag order so that the generated names of the
+	// unnamed ones that follow are stable.
 	var result []indexInfo
-	for _, idx := range named {
-		result = append(result, *idx)
+	for _, name := range names {
+		result = append(result, *named[name])
 	}
-	return append(result, unnamed...)
+	result = append(result, unnamed...)
+	for i := range result {
+		if result[i].Name == "" {
+			result[i].Name = fmt.Sprintf("idx_%s_%d", GenerateTableName(table), i)
+		}
+	}
+	return result
 }
 
 func createIndexes(ctx context.Context, conn *sql.DB, tableName string, indexes []indexInfo) error {
-	for i, idx := range indexes {
-		unique := ""
-		if idx.Unique {
-			unique = "UNIQUE "
-		}
-		name := idx.Name
-		if name == "" {
-			name = fmt.Sprintf("idx_%s_%d", tableName, i)
-		}
-		query := fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS \"%s\" ON \"%s\" (%s)",
-			unique, name, tableName, strings.Join(idx.Cols, ", "))
-		if _, err := ExecuteWriteQuery(ctx, query, conn); err != nil {
-			return fmt.Errorf("error creating index %s: %w", name, err)
+	for _, idx := range indexes {
+		if _, err := ExecuteWriteQuery(ctx, createIndexQuery(tableName, idx), conn); err != nil {
+			return fmt.Errorf("error creating index %s: %w", idx.Name, err)
 		}
 	}
 	return nil
 }
 
+func createIndexQuery(tableName string, idx indexInfo) string {
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS \"%s\" ON \"%s\" (%s)",
+		unique, idx.Name, tableName, strings.Join(idx.Cols, ", "))
+}
+
 // DropTable drops a table by name.
 func DropTable(ctx context.Context, conn *sql.DB, tableName string) error {
 	query := fmt.Sprintf("DROP TABLE IF EXISTS \"%s\"", tableName)
@@ -538,7 +739,10 @@ func SyncTable(ctx context.Context, conn *sql.DB, table any) error {
 			return err
 		}
 	} else {
-		if err = addMissingColumns(ctx, conn, tableName, fields); err != nil {
+		if err = alterColumns(ctx, conn, tableName, fields); err != nil {
+			return err
+		}
+		if err = alterForeignKeys(ctx, conn, tableName, fields); err != nil {
 			return err
 		}
 	}
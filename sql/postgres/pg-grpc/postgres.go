@@ -6,8 +6,10 @@ import (
 	"strings"
 
 	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/pagination"
 	"github.com/masudur-rahman/styx/pkg"
 	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/cond"
 	"github.com/masudur-rahman/styx/sql/postgres/pg-grpc/pb"
 
 	"google.golang.org/protobuf/types/known/anypb"
@@ -29,6 +31,14 @@ func (d Database) BeginTx(ctx context.Context) (isql.Engine, error) {
 	return nil, dberr.ErrTransactionNotStarted
 }
 
+func (d Database) BeginTxWithOptions(ctx context.Context, opts *sql.TxOptions) (isql.Engine, error) {
+	return nil, dberr.ErrTransactionNotStarted
+}
+
+func (d Database) RunInTx(ctx context.Context, opts isql.TxOptions, fn func(isql.Engine) error) error {
+	return isql.RunInTx(ctx, d, opts, nil, fn)
+}
+
 func (d Database) Commit() error {
 	return dberr.ErrTransactionNotStarted
 }
@@ -55,6 +65,10 @@ func (d Database) Where(s string, a ...any) isql.Engine {
 	panic("implement me")
 }
 
+func (d Database) WhereCond(c cond.Cond) isql.Engine {
+	panic("implement me")
+}
+
 func (d Database) Columns(s ...string) isql.Engine {
 	panic("implement me")
 }
@@ -111,11 +125,27 @@ func (d Database) NotLike(col string, pattern string) isql.Engine {
 	panic("implement me")
 }
 
-func (d Database) Exists(subquery string, args ...any) isql.Engine {
+func (d Database) Exists(subquery any, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (d Database) NotExists(subquery any, args ...any) isql.Engine {
 	panic("implement me")
 }
 
-func (d Database) NotExists(subquery string, args ...any) isql.Engine {
+func (d Database) From(subquery any, alias string, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (d Database) With(name string, subquery any, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (d Database) WithRecursive(name string, subquery any, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (d Database) Subquery(proto ...any) isql.Subquery {
 	panic("implement me")
 }
 
@@ -143,6 +173,14 @@ func (d Database) Paginate(page, perPage int64) isql.Engine {
 	panic("implement me")
 }
 
+func (d Database) After(cursor string) isql.Engine {
+	panic("implement me")
+}
+
+func (d Database) Before(cursor string) isql.Engine {
+	panic("implement me")
+}
+
 func (d Database) Join(table, condition string) isql.Engine {
 	panic("implement me")
 }
@@ -167,6 +205,10 @@ func (d Database) WithDeleted() isql.Engine {
 	panic("implement me")
 }
 
+func (d Database) SoftDeleteCol(col string) isql.Engine {
+	panic("implement me")
+}
+
 func (d Database) ForceDelete(ctx context.Context, filter ...any) error {
 	panic("implement me")
 }
@@ -241,6 +283,34 @@ func (d Database) FindMany(ctx context.Context, documents any, filter ...any) er
 	return pkg.ParseInto(rmaps, documents)
 }
 
+func (d Database) FindPage(ctx context.Context, documents any, page, perPage int64, filter ...any) (pagination.Paginator, error) {
+	panic("implement me")
+}
+
+func (d Database) FindCursor(ctx context.Context, documents any, filter ...any) (pagination.CursorPage, error) {
+	panic("implement me")
+}
+
+func (d Database) Rows(ctx context.Context, proto any, filter ...any) (isql.RowScanner, error) {
+	panic("implement me")
+}
+
+func (d Database) Iterate(ctx context.Context, proto any, fn func(row any) error, filter ...any) error {
+	panic("implement me")
+}
+
+func (d Database) Scalar(ctx context.Context, dest any, filter ...any) error {
+	panic("implement me")
+}
+
+func (d Database) FindMaps(ctx context.Context, filter ...any) ([]map[string]any, error) {
+	panic("implement me")
+}
+
+func (d Database) CountRows(ctx context.Context, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
 func (d Database) InsertOne(ctx context.Context, document any) (id any, err error) {
 	df, err := pkg.ToProtoAny(document)
 	if err != nil {
@@ -281,6 +351,10 @@ func (d Database) InsertMany(ctx context.Context, documents []any) ([]any, error
 	return ids, nil
 }
 
+func (d Database) Upsert(ctx context.Context, document any, conflictCols []string, updateCols ...string) (any, error) {
+	panic("implement me")
+}
+
 func (d Database) UpdateOne(ctx context.Context, document any) error {
 	if err := dberr.CheckIDNonEmpty(d.id); err != nil {
 		return err
@@ -328,10 +402,42 @@ func (d Database) DeleteOne(ctx context.Context, filter ...any) error {
 	return err
 }
 
+func (d Database) UpdateMany(ctx context.Context, document any) (int64, error) {
+	panic("implement me")
+}
+
+func (d Database) DeleteMany(ctx context.Context, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
+func (d Database) UpdateOneReturning(ctx context.Context, document any, out any) (int64, error) {
+	panic("implement me")
+}
+
+func (d Database) DeleteOneReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
+func (d Database) ForceDeleteReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
+func (d Database) RestoreReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
 func (d Database) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
 	panic("implement me")
 }
 
+func (d Database) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	panic("implement me")
+}
+
+func (d Database) QueryInto(ctx context.Context, dest any, query string, args ...any) error {
+	panic("implement me")
+}
+
 func (d Database) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	panic("implement me")
 }
@@ -340,10 +446,18 @@ func (d Database) Sync(ctx context.Context, tables ...any) error {
 	return dberr.ErrTransactionNotStarted
 }
 
+func (d Database) Plan(ctx context.Context, tables ...any) ([]isql.SchemaChange, error) {
+	panic("implement me")
+}
+
 func (d Database) DropTable(ctx context.Context, name string) error {
 	panic("implement me")
 }
 
+func (d Database) Dialect() string {
+	return "postgres"
+}
+
 func (d Database) Close() error {
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/masudur-rahman/styx/dberr"
 	"github.com/masudur-rahman/styx/sql"
 	"github.com/masudur-rahman/styx/sql/postgres"
 	"github.com/masudur-rahman/styx/sql/postgres/lib"
@@ -99,6 +100,43 @@ func TestPostgres_FindMany(t *testing.T) {
 	})
 }
 
+func TestPostgres_Iterate(t *testing.T) {
+	ctx := context.Background()
+	db, closer := initializeDB(t)
+	defer closer()
+
+	var users []TestUser
+	require.Nil(t, db.FindMany(ctx, &users))
+
+	t.Run("own transaction", func(t *testing.T) {
+		n := 0
+		err := db.Iterate(ctx, TestUser{}, func(row any) error {
+			n++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, len(users), n)
+	})
+
+	t.Run("inside a transaction", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx)
+		require.Nil(t, err)
+		defer tx.Rollback()
+
+		rows, err := sql.Stream[TestUser](ctx, tx)
+		require.Nil(t, err)
+		n := 0
+		for rows.Next() {
+			var u TestUser
+			assert.Nil(t, rows.Scan(&u))
+			n++
+		}
+		assert.Nil(t, rows.Err())
+		assert.Nil(t, rows.Close())
+		assert.Equal(t, len(users), n)
+	})
+}
+
 func TestPostgres_InsertOne(t *testing.T) {
 	ctx := context.Background()
 	db, closer := initializeDB(t)
@@ -171,3 +209,34 @@ func TestPostgres_DeleteOne(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestPostgres_Plan(t *testing.T) {
+	ctx := context.Background()
+	db, closer := initializeDB(t)
+	defer closer()
+
+	require.Nil(t, db.Sync(ctx, TestUser{}))
+	changes, err := db.Plan(ctx, TestUser{})
+	assert.Nil(t, err)
+	assert.Empty(t, changes, sql.PlanSQL(changes))
+}
+
+type TestPost struct {
+	ID     int64  `db:"id,pk autoincr"`
+	UserID int64  `db:"user_id,fk:test_user.id ondelete:cascade"`
+	Title  string `db:"title"`
+}
+
+func TestPostgres_ForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	db, closer := initializeDB(t)
+	defer closer()
+
+	require.Nil(t, db.Sync(ctx, TestUser{}, TestPost{}))
+	changes, err := db.Plan(ctx, TestPost{})
+	assert.Nil(t, err)
+	assert.Empty(t, changes, sql.PlanSQL(changes))
+
+	_, err = db.InsertOne(ctx, &TestPost{UserID: -1, Title: "orphan"})
+	assert.ErrorIs(t, err, dberr.ErrForeignKeyViolation)
+}
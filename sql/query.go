@@ -0,0 +1,220 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/sql/cond"
+)
+
+// ColumnRef is implemented by every typed column so Columns can take
+// columns of mixed value types.
+type ColumnRef interface {
+	Name() string
+}
+
+// Column is a typed reference to a table column. V is the Go type of the
+// mapped struct field, so comparisons only accept values of that type.
+// Descriptors are normally generated from db tags by cmd/styxgen.
+type Column[V any] struct {
+	name string
+}
+
+// Col returns a typed reference to the named column.
+func Col[V any](name string) Column[V] {
+	return Column[V]{name: name}
+}
+
+// Name returns the column name.
+func (c Column[V]) Name() string {
+	return c.name
+}
+
+// Eq matches rows where the column equals v.
+func (c Column[V]) Eq(v V) cond.Cond { return cond.Eq(c.name, v) }
+
+// Ne matches rows where the column differs from v.
+func (c Column[V]) Ne(v V) cond.Cond { return cond.Neq(c.name, v) }
+
+// Gt matches rows where the column is greater than v.
+func (c Column[V]) Gt(v V) cond.Cond { return cond.Gt(c.name, v) }
+
+// Gte matches rows where the column is greater than or equal to v.
+func (c Column[V]) Gte(v V) cond.Cond { return cond.Gte(c.name, v) }
+
+// Lt matches rows where the column is less than v.
+func (c Column[V]) Lt(v V) cond.Cond { return cond.Lt(c.name, v) }
+
+// Lte matches rows where the column is less than or equal to v.
+func (c Column[V]) Lte(v V) cond.Cond { return cond.Lte(c.name, v) }
+
+// In matches rows where the column equals any of values. An empty list matches nothing.
+func (c Column[V]) In(values ...V) cond.Cond { return cond.In(c.name, anys(values)...) }
+
+// NotIn matches rows where the column equals none of values. An empty list matches everything.
+func (c Column[V]) NotIn(values ...V) cond.Cond { return cond.NotIn(c.name, anys(values)...) }
+
+// Like matches rows where the column matches the LIKE pattern.
+func (c Column[V]) Like(pattern string) cond.Cond { return cond.Like(c.name, pattern) }
+
+// IsNull matches rows where the column is NULL.
+func (c Column[V]) IsNull() cond.Cond { return cond.IsNull(c.name) }
+
+// IsNotNull matches rows where the column is not NULL.
+func (c Column[V]) IsNotNull() cond.Cond { return cond.IsNotNull(c.name) }
+
+// Asc orders by the column in ascending order.
+func (c Column[V]) Asc() Order { return Order{col: c.name, dir: "ASC"} }
+
+// Desc orders by the column in descending order.
+func (c Column[V]) Desc() Order { return Order{col: c.name, dir: "DESC"} }
+
+func anys[V any](values []V) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// Order is an ORDER BY term built from a typed column.
+type Order struct {
+	col string
+	dir string
+}
+
+// TypedQuery is a SELECT over the table of T whose columns are checked
+// against T's db tags before the query runs. Every method returns a copy,
+// so a partially built query can be reused.
+type TypedQuery[T any] struct {
+	engine      Engine
+	conds       []cond.Cond
+	orders      []Order
+	cols        []string
+	limit       int64
+	offset      int64
+	withDeleted bool
+}
+
+// Query starts a typed query on T's table.
+func Query[T any](engine Engine) TypedQuery[T] {
+	return TypedQuery[T]{engine: engine}
+}
+
+// Where adds conditions, joined with AND. They are built from typed columns
+// or with package cond, and may be combined with cond.And, cond.Or and
+// cond.Not.
+func (q TypedQuery[T]) Where(conds ...cond.Cond) TypedQuery[T] {
+	q.conds = append(q.conds[:len(q.conds):len(q.conds)], conds...)
+	return q
+}
+
+// OrderBy adds ORDER BY terms.
+func (q TypedQuery[T]) OrderBy(orders ...Order) TypedQuery[T] {
+	q.orders = append(q.orders[:len(q.orders):len(q.orders)], orders...)
+	return q
+}
+
+// Columns restricts the SELECT to the given columns.
+func (q TypedQuery[T]) Columns(cols ...ColumnRef) TypedQuery[T] {
+	names := q.cols[:len(q.cols):len(q.cols)]
+	for _, c := range cols {
+		names = append(names, c.Name())
+	}
+	q.cols = names
+	return q
+}
+
+// Limit sets the maximum number of rows returned.
+func (q TypedQuery[T]) Limit(n int64) TypedQuery[T] {
+	q.limit = n
+	return q
+}
+
+// Offset skips the first n rows.
+func (q TypedQuery[T]) Offset(n int64) TypedQuery[T] {
+	q.offset = n
+	return q
+}
+
+// WithDeleted includes soft-deleted rows.
+func (q TypedQuery[T]) WithDeleted() TypedQuery[T] {
+	q.withDeleted = true
+	return q
+}
+
+// All returns every matching row.
+func (q TypedQuery[T]) All(ctx context.Context) ([]T, error) {
+	db, err := q.build()
+	if err != nil {
+		return nil, err
+	}
+	var out []T
+	if err = db.FindMany(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// One returns the first matching row. The bool is false when nothing matched.
+func (q TypedQuery[T]) One(ctx context.Context) (T, bool, error) {
+	var out T
+	db, err := q.build()
+	if err != nil {
+		return out, false, err
+	}
+	found, err := db.FindOne(ctx, &out)
+	return out, found, err
+}
+
+// build checks every referenced column against T's field map and applies
+// the query to a fresh statement on T's table.
+func (q TypedQuery[T]) build() (Engine, error) {
+	var doc T
+	fields := GetDBFieldMap(doc)
+	check := func(col string) error {
+		if _, ok := fields[col]; !ok {
+			return fmt.Errorf("%w: %s has no column %q", dberr.ErrInvalidQuery, GetTableName(doc), col)
+		}
+		return nil
+	}
+	for _, c := range q.conds {
+		for _, col := range c.Columns() {
+			if err := check(col); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, o := range q.orders {
+		if err := check(o.col); err != nil {
+			return nil, err
+		}
+	}
+	for _, col := range q.cols {
+		if err := check(col); err != nil {
+			return nil, err
+		}
+	}
+
+	db := q.engine.Table(GetTableName(doc))
+	for _, c := range q.conds {
+		db = db.WhereCond(c)
+	}
+	if len(q.cols) > 0 {
+		db = db.Columns(q.cols...)
+	}
+	for _, o := range q.orders {
+		db = db.OrderBy(o.col, o.dir)
+	}
+	if q.limit > 0 {
+		db = db.Limit(q.limit)
+	}
+	if q.offset > 0 {
+		db = db.Offset(q.offset)
+	}
+	if q.withDeleted {
+		db = db.WithDeleted()
+	}
+	return db, nil
+}
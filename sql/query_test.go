@@ -0,0 +1,112 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/masudur-rahman/styx/dberr"
+	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/cond"
+	"github.com/masudur-rahman/styx/sql/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type account struct {
+	ID        int64     `db:"id,pk autoincr"`
+	Email     string    `db:"email,uq"`
+	Age       int       `db:"age"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+var accountCols = struct {
+	ID        isql.Column[int64]
+	Email     isql.Column[string]
+	Age       isql.Column[int]
+	CreatedAt isql.Column[time.Time]
+}{
+	ID:        isql.Col[int64]("id"),
+	Email:     isql.Col[string]("email"),
+	Age:       isql.Col[int]("age"),
+	CreatedAt: isql.Col[time.Time]("created_at"),
+}
+
+func TestCond(t *testing.T) {
+	c := cond.And(
+		accountCols.Email.Eq("a@e.c"),
+		cond.Or(accountCols.Age.Gte(18), accountCols.ID.In(1, 2)),
+		cond.Not(accountCols.Email.Like("%@spam.c")),
+	)
+	assert.Equal(t, "(email = ? AND (age >= ? OR id IN (?, ?)) AND NOT (email LIKE ?))", c.SQL())
+	assert.Equal(t, []any{"a@e.c", 18, int64(1), int64(2), "%@spam.c"}, c.Args())
+	assert.Equal(t, []string{"email", "age", "id", "email"}, c.Columns())
+
+	assert.Equal(t, "1 = 0", accountCols.ID.In().SQL())
+	assert.Equal(t, "1 = 1", accountCols.ID.NotIn().SQL())
+	assert.Equal(t, "1 = 1", cond.And().SQL())
+	assert.Equal(t, "created_at IS NULL", accountCols.CreatedAt.IsNull().SQL())
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("All applies the query to the model's table", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		gomock.InOrder(
+			db.EXPECT().Table("account").Return(db),
+			db.EXPECT().WhereCond(cond.Eq("email", "a@e.c")).Return(db),
+			db.EXPECT().WhereCond(cond.Or(cond.Gt("age", 60), cond.Lt("age", 18))).Return(db),
+			db.EXPECT().Columns("id", "email").Return(db),
+			db.EXPECT().OrderBy("created_at", "DESC").Return(db),
+			db.EXPECT().Limit(int64(10)).Return(db),
+			db.EXPECT().Offset(int64(20)).Return(db),
+			db.EXPECT().FindMany(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, out any, _ ...any) error {
+				*out.(*[]account) = []account{{ID: 1}, {ID: 2}}
+				return nil
+			}),
+		)
+
+		got, err := isql.Query[account](db).
+			Where(accountCols.Email.Eq("a@e.c"), cond.Or(accountCols.Age.Gt(60), accountCols.Age.Lt(18))).
+			Columns(accountCols.ID, accountCols.Email).
+			OrderBy(accountCols.CreatedAt.Desc()).
+			Limit(10).Offset(20).
+			All(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []account{{ID: 1}, {ID: 2}}, got)
+	})
+
+	t.Run("One reports whether a row matched", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		db.EXPECT().Table("account").Return(db)
+		db.EXPECT().WhereCond(cond.Eq("id", int64(7))).Return(db)
+		db.EXPECT().WithDeleted().Return(db)
+		db.EXPECT().FindOne(ctx, gomock.Any()).Return(false, nil)
+
+		_, found, err := isql.Query[account](db).Where(accountCols.ID.Eq(7)).WithDeleted().One(ctx)
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("unknown columns fail before reaching the engine", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+
+		_, err := isql.Query[account](db).OrderBy(isql.Col[string]("name").Asc()).All(ctx)
+		assert.ErrorIs(t, err, dberr.ErrInvalidQuery)
+		assert.ErrorContains(t, err, `account has no column "name"`)
+	})
+
+	t.Run("queries are immutable", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		base := isql.Query[account](db).Where(accountCols.Age.Gte(18))
+		_ = base.Where(accountCols.Email.Eq("x"))
+
+		db.EXPECT().Table("account").Return(db)
+		db.EXPECT().WhereCond(cond.Gte("age", 18)).Return(db)
+		db.EXPECT().FindMany(ctx, gomock.Any()).Return(nil)
+		_, err := base.All(ctx)
+		assert.NoError(t, err)
+	})
+}
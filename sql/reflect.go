@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -163,6 +164,54 @@ found:
 	return softDeleteCol
 }
 
+// GetConflictColumns returns the columns that identify doc's row for an
+// upsert: the pk column when its value is set, otherwise the composite uqs
+// key, otherwise the first uq column (preferring one with a value), and
+// finally the pk column.
+func GetConflictColumns(doc any) []string {
+	val := reflect.ValueOf(doc)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	t := val.Type()
+
+	pkCol := GetPKColumn(doc)
+	var uqs, uq, uqSet []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		col := GetFieldName(field)
+		if col == pkCol && !val.Field(i).IsZero() {
+			return []string{pkCol}
+		}
+
+		parts := strings.SplitN(field.Tag.Get("db"), ",", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		for _, part := range strings.Fields(parts[1]) {
+			switch strings.ToUpper(part) {
+			case "UQS":
+				uqs = append(uqs, col)
+			case "UQ":
+				uq = append(uq, col)
+				if !val.Field(i).IsZero() {
+					uqSet = append(uqSet, col)
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(uqs) > 0:
+		return uqs
+	case len(uqSet) > 0:
+		return uqSet[:1]
+	case len(uq) > 0:
+		return uq[:1]
+	}
+	return []string{pkCol}
+}
+
 // GetFieldName returns the database column name for a struct field.
 func GetFieldName(field reflect.StructField) string {
 	fieldName := field.Name
@@ -211,6 +260,67 @@ func HasJSONTag(field reflect.StructField) bool {
 	return false
 }
 
+// ColumnOptions holds the column definition options of a db tag, as in
+// db:"price,precision:10,2 default:0 notnull". Option values cannot
+// contain spaces.
+type ColumnOptions struct {
+	// Type replaces the SQL type the Go type maps to (type:UUID).
+	Type string
+	// Size is the length of a string column (size:512).
+	Size int
+	// Precision and Scale make a fixed-point column (precision:10,2).
+	Precision, Scale int
+	// Default is the column's DEFAULT expression, as written (default:now()).
+	Default string
+	// NotNull declares the column NOT NULL (notnull).
+	NotNull bool
+	// References is the table.column a foreign key on the column points
+	// to (fk:users.id); the column defaults to id (fk:users).
+	References string
+	// OnDelete and OnUpdate are the referential actions of the foreign
+	// key, such as CASCADE or SET NULL (ondelete:cascade, onupdate:set_null).
+	OnDelete, OnUpdate string
+}
+
+// GetColumnOptions parses the column definition options of a struct field's db tag.
+func GetColumnOptions(field reflect.StructField) ColumnOptions {
+	var opts ColumnOptions
+	parts := strings.SplitN(field.Tag.Get("db"), ",", 2)
+	if len(parts) < 2 {
+		return opts
+	}
+	for _, part := range strings.Fields(parts[1]) {
+		key, value, _ := strings.Cut(part, ":")
+		switch strings.ToLower(key) {
+		case "type":
+			opts.Type = value
+		case "size":
+			opts.Size, _ = strconv.Atoi(value)
+		case "precision":
+			p, s, _ := strings.Cut(value, ",")
+			opts.Precision, _ = strconv.Atoi(p)
+			opts.Scale, _ = strconv.Atoi(s)
+		case "default":
+			opts.Default = value
+		case "notnull":
+			opts.NotNull = true
+		case "fk":
+			opts.References = value
+		case "ondelete":
+			opts.OnDelete = referentialAction(value)
+		case "onupdate":
+			opts.OnUpdate = referentialAction(value)
+		}
+	}
+	return opts
+}
+
+// referentialAction spells a tag's ondelete or onupdate value in SQL, as
+// in set_null to SET NULL.
+func referentialAction(value string) string {
+	return strings.ToUpper(strings.NewReplacer("_", " ", "-", " ").Replace(value))
+}
+
 // IsJSONField reports whether a struct field is stored as JSON in the
 // database: either tagged with the "json" db option, or typed
 // json.RawMessage (directly or behind a pointer).
@@ -312,12 +422,6 @@ func ScanRow(rows *sql.Rows, doc any) error {
 		return err
 	}
 
-	fieldMap := GetDBFieldMap(doc)
-	val := reflect.ValueOf(doc)
-	if val.Kind() == reflect.Ptr {
-		val = val.Elem()
-	}
-
 	scans := make([]any, len(fields))
 	for i := range scans {
 		scans[i] = &scans[i]
@@ -326,6 +430,18 @@ func ScanRow(rows *sql.Rows, doc any) error {
 		return err
 	}
 
+	return AssignRow(doc, fields, scans)
+}
+
+// AssignRow sets the fields of doc from a row already scanned into scans,
+// one value per column in fields. Columns without a matching field are skipped.
+func AssignRow(doc any, fields []string, scans []any) error {
+	fieldMap := GetDBFieldMap(doc)
+	val := reflect.ValueOf(doc)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
 	for idx, col := range fields {
 		rawVal := scans[idx]
 		if rawVal == nil {
@@ -349,6 +465,17 @@ func ScanRow(rows *sql.Rows, doc any) error {
 			continue
 		}
 
+		if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+			if err := scanner.Scan(rawVal); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if b, ok := rawVal.([]byte); ok {
+			rawVal = parseNumericText(b, field.Type())
+		}
+
 		// Handle type conversion if necessary
 		v := reflect.ValueOf(rawVal)
 		if v.Type().AssignableTo(field.Type()) {
@@ -412,6 +539,30 @@ func asBool(v any) bool {
 	}
 }
 
+// parseNumericText parses a numeric column returned as text, as the MySQL
+// text protocol does, when the destination is a numeric type. Anything else is
+// returned unchanged.
+func parseNumericText(b []byte, t reflect.Type) any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	}
+	return b
+}
+
 func parseTime(s string) (time.Time, error) {
 	layouts := []string{
 		"2006-01-02 15:04:05",
@@ -43,6 +43,16 @@ func TestAsBool_conversions(t *testing.T) {
 	}
 }
 
+func TestParseNumericText(t *testing.T) {
+	var ptr *int64
+	assert.Equal(t, int64(42), parseNumericText([]byte("42"), reflect.TypeOf(int(0))))
+	assert.Equal(t, int64(-7), parseNumericText([]byte("-7"), reflect.TypeOf(ptr)))
+	assert.Equal(t, uint64(7), parseNumericText([]byte("7"), reflect.TypeOf(uint32(0))))
+	assert.Equal(t, 1.5, parseNumericText([]byte("1.5"), reflect.TypeOf(float32(0))))
+	assert.Equal(t, []byte("abc"), parseNumericText([]byte("abc"), reflect.TypeOf(int64(0))))
+	assert.Equal(t, []byte("42"), parseNumericText([]byte("42"), reflect.TypeOf("")))
+}
+
 type jsonAddress struct {
 	Street string `json:"street"`
 	City   string `json:"city"`
@@ -140,3 +150,51 @@ func TestSetJSONField(t *testing.T) {
 		assert.Equal(t, jsonAddress{}, doc.Address)
 	})
 }
+
+func TestGetConflictColumns(t *testing.T) {
+	type account struct {
+		ID    int64  `db:"id,pk autoincr"`
+		Name  string `db:"name,uq"`
+		Email string `db:"email,uq"`
+	}
+	type membership struct {
+		ID     int64 `db:"id,pk autoincr"`
+		UserID int64 `db:"user_id,uqs"`
+		OrgID  int64 `db:"org_id,uqs"`
+	}
+	type plain struct {
+		Key   string `db:"key,pk"`
+		Value string `db:"value"`
+	}
+
+	assert.Equal(t, []string{"id"}, GetConflictColumns(account{ID: 7, Email: "a@e.c"}))
+	assert.Equal(t, []string{"email"}, GetConflictColumns(&account{Email: "a@e.c"}))
+	assert.Equal(t, []string{"name"}, GetConflictColumns(account{}))
+	assert.Equal(t, []string{"user_id", "org_id"}, GetConflictColumns(membership{UserID: 1, OrgID: 2}))
+	assert.Equal(t, []string{"key"}, GetConflictColumns(plain{Value: "v"}))
+}
+
+func TestGetColumnOptions(t *testing.T) {
+	type product struct {
+		ID     string  `db:"id,pk type:UUID default:gen_random_uuid()"`
+		Name   string  `db:"name,size:512 notnull"`
+		Price  float64 `db:"price,precision:10,2 default:0 notnull"`
+		Status string  `db:"status,idx default:'draft'"`
+		Note   string  `db:"note"`
+		UserID int64   `db:"user_id,fk:users.id ondelete:cascade onupdate:set_null notnull"`
+	}
+	opts := func(name string) ColumnOptions {
+		f, ok := reflect.TypeOf(product{}).FieldByName(name)
+		if !ok {
+			t.Fatalf("no field %s", name)
+		}
+		return GetColumnOptions(f)
+	}
+
+	assert.Equal(t, ColumnOptions{Type: "UUID", Default: "gen_random_uuid()"}, opts("ID"))
+	assert.Equal(t, ColumnOptions{Size: 512, NotNull: true}, opts("Name"))
+	assert.Equal(t, ColumnOptions{Precision: 10, Scale: 2, Default: "0", NotNull: true}, opts("Price"))
+	assert.Equal(t, ColumnOptions{Default: "'draft'"}, opts("Status"))
+	assert.Equal(t, ColumnOptions{}, opts("Note"))
+	assert.Equal(t, ColumnOptions{NotNull: true, References: "users.id", OnDelete: "CASCADE", OnUpdate: "SET NULL"}, opts("UserID"))
+}
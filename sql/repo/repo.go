@@ -0,0 +1,180 @@
+// Package repo provides a generic CRUD repository on top of sql.Engine.
+package repo
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/pagination"
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// Repository reads and writes rows of T, a struct with db tags. The table
+// and primary key come from isql.GetTableName and isql.GetPKColumn, soft
+// deletes follow T's soft_delete column and writes are validated against
+// T's validate tags. A Repository is a value; the With* methods return
+// modified copies.
+type Repository[T any] struct {
+	db          isql.Engine
+	table       string
+	pkCol       string
+	softDelete  string
+	validate    bool
+	withDeleted bool
+}
+
+// New returns a Repository for T backed by db. Pass the engine returned by
+// BeginTx (or given to RunInTx) to work inside a transaction.
+func New[T any](db isql.Engine) Repository[T] {
+	var doc T
+	return Repository[T]{
+		db:         db,
+		table:      isql.GetTableName(doc),
+		pkCol:      isql.GetPKColumn(doc),
+		softDelete: isql.ExtractSoftDeleteColumn(doc),
+		validate:   true,
+	}
+}
+
+// WithValidation turns struct validation on writes on or off (default on).
+func (r Repository[T]) WithValidation(enable bool) Repository[T] {
+	r.validate = enable
+	return r
+}
+
+// WithDeleted includes soft-deleted rows in Get and List.
+func (r Repository[T]) WithDeleted() Repository[T] {
+	r.withDeleted = true
+	return r
+}
+
+// Table returns the table name of T.
+func (r Repository[T]) Table() string {
+	return r.table
+}
+
+// Get returns the row with the given primary key, or dberr.ErrNotFound.
+func (r Repository[T]) Get(ctx context.Context, id any) (T, error) {
+	var doc T
+	found, err := r.byID(id).FindOne(ctx, &doc)
+	if err != nil {
+		return doc, err
+	}
+	if !found {
+		return doc, dberr.ErrNotFound
+	}
+	return doc, nil
+}
+
+// Find returns the first row matching filter, a T with the fields to match
+// set, or dberr.ErrNotFound.
+func (r Repository[T]) Find(ctx context.Context, filter T) (T, error) {
+	var doc T
+	found, err := r.session().FindOne(ctx, &doc, filter)
+	if err != nil {
+		return doc, err
+	}
+	if !found {
+		return doc, dberr.ErrNotFound
+	}
+	return doc, nil
+}
+
+// List returns one page of rows matching filter along with the pagination
+// metadata. filter may be nil to list every row; page is 1-indexed.
+func (r Repository[T]) List(ctx context.Context, filter any, page, perPage int64) ([]T, pagination.Paginator, error) {
+	docs := []T{}
+	p, err := r.session().OrderBy(r.pkCol).FindPage(ctx, &docs, page, perPage, filters(filter)...)
+	if err != nil {
+		return nil, p, err
+	}
+	return docs, p, nil
+}
+
+// Count returns the number of rows matching filter, which may be nil.
+func (r Repository[T]) Count(ctx context.Context, filter any) (int64, error) {
+	return r.session().CountRows(ctx, filters(filter)...)
+}
+
+// Create inserts doc and returns it with the generated primary key set.
+func (r Repository[T]) Create(ctx context.Context, doc T) (T, error) {
+	_, err := r.session().InsertOne(ctx, &doc)
+	return doc, err
+}
+
+// CreateMany inserts docs and returns them with their primary keys set.
+func (r Repository[T]) CreateMany(ctx context.Context, docs []T) ([]T, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+	out := make([]T, len(docs))
+	copy(out, docs)
+	args := make([]any, len(out))
+	for i := range out {
+		args[i] = &out[i]
+	}
+	if _, err := r.session().InsertMany(ctx, args); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Update writes the non-zero fields of doc to the row with doc's primary
+// key. It returns dberr.ErrInvalidID when the key is unset and
+// dberr.ErrNotFound when no row has it.
+func (r Repository[T]) Update(ctx context.Context, doc T) error {
+	id, err := r.pkValue(doc)
+	if err != nil {
+		return err
+	}
+	return r.byID(id).UpdateOne(ctx, &doc)
+}
+
+// Delete removes the row with the given primary key, soft-deleting it when
+// T has a soft_delete column. It returns dberr.ErrNotFound when no row has it.
+func (r Repository[T]) Delete(ctx context.Context, id any) error {
+	return r.byID(id).DeleteOne(ctx)
+}
+
+// ForceDelete permanently removes the row with the given primary key.
+func (r Repository[T]) ForceDelete(ctx context.Context, id any) error {
+	return r.byID(id).ForceDelete(ctx)
+}
+
+// session starts a statement on T's table with the repository settings.
+func (r Repository[T]) session() isql.Engine {
+	db := r.db.Table(r.table).EnableValidation(r.validate)
+	if r.softDelete != "" {
+		db = db.SoftDeleteCol(r.softDelete)
+	}
+	if r.withDeleted {
+		db = db.WithDeleted()
+	}
+	return db
+}
+
+// byID starts a session filtered by T's primary key column.
+func (r Repository[T]) byID(id any) isql.Engine {
+	return r.session().Where(r.pkCol+" = ?", id)
+}
+
+// pkValue returns doc's primary key, or dberr.ErrInvalidID when it is unset.
+func (r Repository[T]) pkValue(doc T) (any, error) {
+	idx, ok := isql.GetDBFieldMap(doc)[r.pkCol]
+	if !ok {
+		return nil, dberr.ErrInvalidID
+	}
+	field := reflect.ValueOf(doc).Field(idx)
+	if field.IsZero() {
+		return nil, dberr.ErrInvalidID
+	}
+	return field.Interface(), nil
+}
+
+func filters(filter any) []any {
+	if filter == nil {
+		return nil
+	}
+	return []any{filter}
+}
@@ -0,0 +1,186 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/sql/repo"
+	"github.com/masudur-rahman/styx/sql/sqlite"
+	"github.com/masudur-rahman/styx/sql/sqlite/lib"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Member struct {
+	ID        int64      `db:"id,pk autoincr"`
+	Name      string     `db:"name,uq" validate:"required"`
+	Team      string     `db:"team"`
+	DeletedAt *time.Time `db:"deleted_at,soft_delete"`
+}
+
+type Tag struct {
+	ID   int64  `db:"id,pk autoincr"`
+	Name string `db:"name"`
+}
+
+// Account has a string primary key that is not named id and a req column,
+// which a zero Account filter would still match against the empty string.
+type Account struct {
+	UserID    string     `db:"user_id,pk"`
+	Email     string     `db:"email,req"`
+	DeletedAt *time.Time `db:"deleted_at,soft_delete"`
+}
+
+func setup(t *testing.T) (repo.Repository[Member], repo.Repository[Tag]) {
+	conn, err := lib.GetSQLiteConnection(":memory:")
+	assert.NoError(t, err)
+	db := sqlite.NewSQLite(conn)
+	assert.NoError(t, db.Sync(context.Background(), Member{}, Tag{}))
+	return repo.New[Member](db), repo.New[Tag](db)
+}
+
+func TestRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	members, _ := setup(t)
+	assert.Equal(t, "member", members.Table())
+
+	m, err := members.Create(ctx, Member{Name: "alice", Team: "core"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), m.ID)
+
+	got, err := members.Get(ctx, m.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", got.Name)
+
+	got, err = members.Find(ctx, Member{Team: "core"})
+	assert.NoError(t, err)
+	assert.Equal(t, m.ID, got.ID)
+
+	m.Team = "infra"
+	assert.NoError(t, members.Update(ctx, m))
+	got, _ = members.Get(ctx, m.ID)
+	assert.Equal(t, "infra", got.Team)
+
+	assert.ErrorIs(t, members.Update(ctx, Member{Name: "nobody"}), dberr.ErrInvalidID)
+	assert.ErrorIs(t, members.Update(ctx, Member{ID: 99, Name: "nobody"}), dberr.ErrNotFound)
+
+	_, err = members.Get(ctx, 99)
+	assert.ErrorIs(t, err, dberr.ErrNotFound)
+}
+
+func TestRepository_Validation(t *testing.T) {
+	ctx := context.Background()
+	members, _ := setup(t)
+
+	_, err := members.Create(ctx, Member{Team: "core"})
+	assert.True(t, dberr.IsValidationError(err))
+
+	_, err = members.WithValidation(false).Create(ctx, Member{Team: "core"})
+	assert.NoError(t, err)
+}
+
+func TestRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	members, tags := setup(t)
+
+	m, err := members.Create(ctx, Member{Name: "alice"})
+	assert.NoError(t, err)
+	assert.NoError(t, members.Delete(ctx, m.ID))
+
+	_, err = members.Get(ctx, m.ID)
+	assert.ErrorIs(t, err, dberr.ErrNotFound)
+	got, err := members.WithDeleted().Get(ctx, m.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, got.DeletedAt)
+
+	n, err := members.Count(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	n, err = members.WithDeleted().Count(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.NoError(t, members.ForceDelete(ctx, m.ID))
+	_, err = members.WithDeleted().Get(ctx, m.ID)
+	assert.ErrorIs(t, err, dberr.ErrNotFound)
+
+	tag, err := tags.Create(ctx, Tag{Name: "go"})
+	assert.NoError(t, err)
+	assert.NoError(t, tags.Delete(ctx, tag.ID))
+	_, err = tags.WithDeleted().Get(ctx, tag.ID)
+	assert.ErrorIs(t, err, dberr.ErrNotFound, "tables without soft_delete are hard-deleted")
+	assert.ErrorIs(t, tags.Delete(ctx, tag.ID), dberr.ErrNotFound)
+}
+
+func TestRepository_primaryKeyColumn(t *testing.T) {
+	ctx := context.Background()
+	conn, err := lib.GetSQLiteConnection(":memory:")
+	assert.NoError(t, err)
+	db := sqlite.NewSQLite(conn)
+	assert.NoError(t, db.Sync(ctx, Account{}))
+	accounts := repo.New[Account](db)
+
+	a, err := accounts.Create(ctx, Account{UserID: "u1", Email: "a@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", a.UserID)
+	_, err = accounts.Create(ctx, Account{UserID: "u2", Email: "b@example.com"})
+	assert.NoError(t, err)
+
+	got, err := accounts.Get(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "a@example.com", got.Email)
+
+	a.Email = "c@example.com"
+	assert.NoError(t, accounts.Update(ctx, a))
+	got, _ = accounts.Get(ctx, "u1")
+	assert.Equal(t, "c@example.com", got.Email)
+
+	assert.NoError(t, accounts.Delete(ctx, "u1"))
+	_, err = accounts.Get(ctx, "u1")
+	assert.ErrorIs(t, err, dberr.ErrNotFound)
+	got, err = accounts.WithDeleted().Get(ctx, "u1")
+	assert.NoError(t, err)
+	assert.NotNil(t, got.DeletedAt)
+
+	n, err := accounts.Count(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = accounts.WithDeleted().Count(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.NoError(t, accounts.ForceDelete(ctx, "u1"))
+	_, err = accounts.WithDeleted().Get(ctx, "u1")
+	assert.ErrorIs(t, err, dberr.ErrNotFound)
+	_, err = accounts.Get(ctx, "u2")
+	assert.NoError(t, err, "only the row with the key is deleted")
+}
+
+func TestRepository_List(t *testing.T) {
+	ctx := context.Background()
+	_, tags := setup(t)
+
+	created, err := tags.CreateMany(ctx, []Tag{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), created[4].ID)
+
+	page, p, err := tags.List(ctx, nil, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{ID: 3, Name: "c"}, {ID: 4, Name: "d"}}, page)
+	assert.Equal(t, int64(5), p.TotalItems)
+	assert.Equal(t, int64(3), p.TotalPages)
+	assert.True(t, p.HasNext())
+
+	page, p, err = tags.List(ctx, Tag{Name: "e"}, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, int64(1), p.TotalItems)
+	assert.False(t, p.HasNext())
+
+	page, p, err = tags.List(ctx, Tag{Name: "z"}, 1, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, page)
+	assert.Equal(t, int64(0), p.TotalPages)
+}
@@ -0,0 +1,88 @@
+package sql
+
+import (
+	"context"
+	"reflect"
+)
+
+// RowScanner streams the rows of a query one at a time instead of loading
+// them into a slice. Call Next before every Scan and Close when done; Err
+// reports the error, if any, that ended the iteration.
+type RowScanner interface {
+	// Next prepares the next row for Scan and reports whether there is one.
+	Next() bool
+	// Scan copies the current row into doc, a pointer to a struct, as ScanRow does.
+	Scan(doc any) error
+	// Err returns the error encountered during iteration.
+	Err() error
+	// Close releases the rows and whatever they hold on the server.
+	Close() error
+}
+
+// Rows is a RowScanner typed to T.
+type Rows[T any] struct {
+	rs RowScanner
+}
+
+// Stream runs the read query built on db for T and returns its rows without
+// loading them, for result sets too large to hold in memory:
+//
+//	rows, err := isql.Stream[User](ctx, db.Table("user").Where("age > ?", 18))
+//	defer rows.Close()
+//	for rows.Next() {
+//		var u User
+//		if err := rows.Scan(&u); err != nil { ... }
+//	}
+func Stream[T any](ctx context.Context, db Engine, filter ...any) (*Rows[T], error) {
+	var doc T
+	rs, err := db.Rows(ctx, &doc, filter...)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows[T]{rs: rs}, nil
+}
+
+// Next prepares the next row for Scan and reports whether there is one.
+func (r *Rows[T]) Next() bool {
+	return r.rs.Next()
+}
+
+// Scan copies the current row into dest.
+func (r *Rows[T]) Scan(dest *T) error {
+	return r.rs.Scan(dest)
+}
+
+// Err returns the error encountered during iteration.
+func (r *Rows[T]) Err() error {
+	return r.rs.Err()
+}
+
+// Close releases the rows.
+func (r *Rows[T]) Close() error {
+	return r.rs.Close()
+}
+
+// IterateRows scans every row of rs into a new value of proto's struct type
+// and calls fn with a pointer to it, stopping at the first error from fn.
+// It closes rs. Engines implement Iterate with this helper.
+func IterateRows(rs RowScanner, proto any, fn func(row any) error) error {
+	defer rs.Close()
+
+	typ := reflect.TypeOf(proto)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for rs.Next() {
+		row := reflect.New(typ).Interface()
+		if err := rs.Scan(row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rs.Err(); err != nil {
+		return err
+	}
+	return rs.Close()
+}
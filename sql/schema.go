@@ -0,0 +1,73 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind classifies a SchemaChange.
+type ChangeKind string
+
+const (
+	CreateTable     ChangeKind = "create table"
+	AddColumn       ChangeKind = "add column"
+	DropColumn      ChangeKind = "drop column"
+	AlterColumnType ChangeKind = "alter column type"
+	AlterNullable   ChangeKind = "alter column nullability"
+	AlterDefault    ChangeKind = "alter column default"
+	AddIndex        ChangeKind = "add index"
+	DropIndex       ChangeKind = "drop index"
+	AddConstraint   ChangeKind = "add constraint"
+	DropConstraint  ChangeKind = "drop constraint"
+)
+
+// SchemaChange is one difference between a struct schema and the live
+// table, as reported by Engine.Plan.
+type SchemaChange struct {
+	Kind  ChangeKind
+	Table string
+	// Name is the column, index or constraint concerned; empty for CreateTable.
+	Name string
+	// From and To describe the live and the wanted definition, such as two
+	// column types or NULL and NOT NULL.
+	From, To string
+	// SQL is the statement that makes the change. It is empty when the
+	// dialect cannot make it in place, as for column types on SQLite.
+	SQL string
+}
+
+// String describes the change, as in "alter column type user.age: INTEGER -> BIGINT".
+func (c SchemaChange) String() string {
+	s := string(c.Kind) + " " + c.Table
+	if c.Name != "" {
+		s += "." + c.Name
+	}
+	if c.From != "" || c.To != "" {
+		s += fmt.Sprintf(": %s -> %s", orNone(c.From), orNone(c.To))
+	}
+	return s
+}
+
+// PlanSQL renders changes as a SQL script, one statement per line, for
+// review before it is applied. Changes without SQL become comments, and a
+// statement that makes several changes at once, such as MySQL's MODIFY
+// COLUMN, is written once.
+func PlanSQL(changes []SchemaChange) string {
+	var b strings.Builder
+	for i, c := range changes {
+		switch {
+		case c.SQL == "":
+			b.WriteString("-- " + c.String() + ": not supported in place\n")
+		case i == 0 || c.SQL != changes[i-1].SQL:
+			b.WriteString(c.SQL + ";\n")
+		}
+	}
+	return b.String()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
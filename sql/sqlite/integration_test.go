@@ -2,12 +2,15 @@ package sqlite_test
 
 import (
 	"context"
+	stdsql "database/sql"
 	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/masudur-rahman/styx/dberr"
 	"github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/cond"
 	"github.com/masudur-rahman/styx/sql/sqlite"
 	"github.com/masudur-rahman/styx/sql/sqlite/lib"
 
@@ -165,3 +168,868 @@ func TestIntegration_JSONFields(t *testing.T) {
 	assert.JSONEq(t, `{"note":"second"}`, string(updated.Payload))
 	assert.Equal(t, &Location{Lat: 1, Lon: 2}, updated.Extra)
 }
+
+func TestIntegration_InsertManyBatch(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	users := []any{
+		&User{Name: "A", Email: "a@e.c", Age: 10},
+		&User{Name: "B", Email: "b@e.c", Age: 20},
+		&User{Name: "C", Email: "c@e.c"},
+		&User{Name: "D", Email: "d@e.c", Age: 40},
+	}
+	ids, err := db.Table("user").InsertMany(ctx, users)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{int64(1), int64(2), int64(3), int64(4)}, ids)
+	for i, u := range users {
+		assert.Equal(t, int64(i+1), u.(*User).ID)
+	}
+
+	var got []User
+	err = db.Table("user").OrderBy("id").FindMany(ctx, &got)
+	assert.NoError(t, err)
+	assert.Len(t, got, 4)
+	assert.Equal(t, "C", got[2].Name)
+	assert.Equal(t, 40, got[3].Age)
+
+	_, err = db.Table("user").InsertMany(ctx, []any{&User{Name: "E", Email: "e@e.c"}, &User{Name: "A", Email: "x@e.c"}})
+	assert.Error(t, err, "unique violation fails the whole batch")
+}
+
+func TestIntegration_Upsert(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	user := &User{Name: "A", Email: "a@e.c", Age: 10}
+	id, err := db.Table("user").Upsert(ctx, user, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+	assert.Equal(t, int64(1), user.ID)
+
+	// Conflicts on the uq column and updates the remaining columns.
+	again := &User{Name: "A", Email: "a2@e.c", Age: 11}
+	id, err = db.Table("user").Upsert(ctx, again, []string{"name"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	var got User
+	_, err = db.Table("user").ID(1).FindOne(ctx, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, "a2@e.c", got.Email)
+	assert.Equal(t, 11, got.Age)
+
+	// Only the listed update columns change.
+	_, err = db.Table("user").Upsert(ctx, &User{Name: "A", Email: "a3@e.c", Age: 12}, []string{"name"}, "age")
+	assert.NoError(t, err)
+	_, err = db.Table("user").ID(1).FindOne(ctx, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, "a2@e.c", got.Email)
+	assert.Equal(t, 12, got.Age)
+
+	// Non-conflicting rows are inserted.
+	id, err = db.Table("user").Upsert(ctx, &User{Name: "B", Email: "b@e.c"}, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, int64(1), id)
+}
+
+func TestIntegration_UpdateManyDeleteMany(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	_, err := db.Table("user").InsertMany(ctx, []any{
+		&User{Name: "A", Email: "a@e.c", Age: 10},
+		&User{Name: "B", Email: "b@e.c", Age: 10},
+		&User{Name: "C", Email: "c@e.c", Age: 30},
+	})
+	assert.NoError(t, err)
+
+	_, err = db.Table("user").UpdateMany(ctx, User{Age: 11})
+	assert.ErrorIs(t, err, dberr.ErrMissingWhereClause)
+	_, err = db.Table("user").DeleteMany(ctx)
+	assert.ErrorIs(t, err, dberr.ErrMissingWhereClause)
+
+	affected, err := db.Table("user").Where("age = ?", 10).UpdateMany(ctx, User{Age: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	affected, err = db.Table("user").Where("age = ?", 99).UpdateMany(ctx, User{Age: 1})
+	assert.NoError(t, err, "no matching rows is not an error")
+	assert.Zero(t, affected)
+
+	// Soft delete: rows already deleted are neither counted nor updated again.
+	affected, err = db.Table("user").DeleteMany(ctx, User{Age: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	affected, err = db.Table("user").DeleteMany(ctx, User{Age: 20})
+	assert.NoError(t, err)
+	assert.Zero(t, affected)
+
+	affected, err = db.Table("user").Where("age = ?", 20).UpdateMany(ctx, User{Age: 21})
+	assert.NoError(t, err)
+	assert.Zero(t, affected, "soft-deleted rows are skipped")
+
+	var users []User
+	err = db.Table("user").WithDeleted().FindMany(ctx, &users)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+
+	affected, err = db.Table("user").WithDeleted().Where("age = ?", 20).UpdateMany(ctx, User{Age: 21})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+}
+
+func TestIntegration_Returning(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	_, err := db.Table("user").InsertMany(ctx, []any{
+		&User{Name: "A", Email: "a@e.c", Age: 10},
+		&User{Name: "B", Email: "b@e.c", Age: 10},
+	})
+	assert.NoError(t, err)
+
+	var updated User
+	n, err := db.Table("user").ID(1).UpdateOneReturning(ctx, User{Age: 15}, &updated)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, "A", updated.Name)
+	assert.Equal(t, 15, updated.Age)
+
+	var deleted []User
+	n, err = db.Table("user").Where("age = ?", 10).DeleteOneReturning(ctx, &deleted)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, "B", deleted[0].Name)
+	assert.NotNil(t, deleted[0].DeletedAt, "soft delete marker is returned")
+
+	var restored User
+	n, err = db.Table("user").ID(2).RestoreReturning(ctx, &restored)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Nil(t, restored.DeletedAt)
+
+	var removed User
+	n, err = db.Table("user").ID(2).ForceDeleteReturning(ctx, &removed)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, "b@e.c", removed.Email)
+
+	_, err = db.Table("user").ID(2).WithDeleted().UpdateOneReturning(ctx, User{Age: 1}, &updated)
+	assert.ErrorIs(t, err, dberr.ErrNotFound)
+}
+
+func TestIntegration_DeleteDetectsSoftDeleteAlike(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	_, err := db.Table("user").InsertMany(ctx, []any{
+		&User{Name: "A", Email: "a@e.c", Age: 10},
+		&User{Name: "B", Email: "b@e.c", Age: 10},
+	})
+	assert.NoError(t, err)
+
+	// The same filter struct soft deletes with and without RETURNING.
+	assert.NoError(t, db.Table("user").DeleteOne(ctx, User{Name: "A"}))
+	var deleted []User
+	_, err = db.Table("user").DeleteOneReturning(ctx, &deleted, User{Name: "B"})
+	assert.NoError(t, err)
+
+	var users []User
+	assert.NoError(t, db.Table("user").WithDeleted().OrderBy("id").FindMany(ctx, &users))
+	if assert.Len(t, users, 2) {
+		assert.NotNil(t, users[0].DeletedAt)
+		assert.NotNil(t, users[1].DeletedAt)
+	}
+
+	// And both restore paths clear the marker again.
+	assert.NoError(t, db.Table("user").Restore(ctx, User{Name: "A"}))
+	var restored []User
+	_, err = db.Table("user").RestoreReturning(ctx, &restored, User{Name: "B"})
+	assert.NoError(t, err)
+
+	users = nil
+	assert.NoError(t, db.Table("user").FindMany(ctx, &users))
+	assert.Len(t, users, 2)
+}
+
+func TestIntegration_RawQueries(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	tx, err := db.BeginTx(ctx)
+	assert.NoError(t, err)
+	_, err = tx.Exec(ctx, "INSERT INTO user (name, email, age) VALUES (?, ?, ?)", "A", "a@e.c", 10)
+	assert.NoError(t, err)
+
+	var count int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM user").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count, "raw queries see the transaction's writes")
+	assert.NoError(t, tx.Rollback())
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM user").Scan(&count)
+	assert.NoError(t, err)
+	assert.Zero(t, count, "raw writes are rolled back with the transaction")
+
+	_, err = db.Exec(ctx, "INSERT INTO user (name, email, age) VALUES (?, ?, ?), (?, ?, ?)", "A", "a@e.c", 10, "B", "b@e.c", 20)
+	assert.NoError(t, err)
+
+	var users []User
+	err = db.QueryInto(ctx, &users, "SELECT * FROM user WHERE age >= ? ORDER BY id", 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "b@e.c", users[1].Email)
+
+	var user User
+	err = db.QueryInto(ctx, &user, "SELECT id, name FROM user WHERE age = ?", 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "B", user.Name)
+	assert.Empty(t, user.Email, "only selected columns are scanned")
+
+	err = db.QueryInto(ctx, &user, "SELECT * FROM user WHERE age = ?", 99)
+	assert.ErrorIs(t, err, stdsql.ErrNoRows)
+
+	err = db.QueryInto(ctx, user, "SELECT * FROM user")
+	assert.ErrorIs(t, err, dberr.ErrInvalidQuery)
+}
+
+func TestIntegration_NestedTransactions(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	tx, err := db.BeginTx(ctx)
+	assert.NoError(t, err)
+	_, err = tx.InsertOne(ctx, &User{Name: "A", Email: "a@e.c", Age: 10})
+	assert.NoError(t, err)
+
+	inner, err := tx.BeginTx(ctx)
+	assert.NoError(t, err)
+	_, err = inner.InsertOne(ctx, &User{Name: "B", Email: "b@e.c", Age: 20})
+	assert.NoError(t, err)
+	assert.NoError(t, inner.Rollback(), "rolls back to the savepoint only")
+
+	inner, err = tx.BeginTx(ctx)
+	assert.NoError(t, err)
+	deepest, err := inner.BeginTx(ctx)
+	assert.NoError(t, err)
+	_, err = deepest.InsertOne(ctx, &User{Name: "C", Email: "c@e.c", Age: 30})
+	assert.NoError(t, err)
+	assert.NoError(t, deepest.Commit())
+	assert.NoError(t, inner.Commit())
+
+	var users []User
+	assert.NoError(t, tx.Table("user").OrderBy("id").FindMany(ctx, &users))
+	assert.Len(t, users, 2)
+	assert.NoError(t, tx.Commit())
+
+	users = nil
+	assert.NoError(t, db.Table("user").OrderBy("id").FindMany(ctx, &users))
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, "A", users[0].Name)
+		assert.Equal(t, "C", users[1].Name)
+	}
+	assert.ErrorIs(t, db.Commit(), dberr.ErrTransactionNotStarted)
+}
+
+func TestIntegration_RunInTx(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	err := db.RunInTx(ctx, sql.TxOptions{}, func(tx sql.Engine) error {
+		_, err := tx.InsertOne(ctx, &User{Name: "A", Email: "a@e.c", Age: 10})
+		return err
+	})
+	assert.NoError(t, err)
+
+	boom := fmt.Errorf("boom")
+	err = db.RunInTx(ctx, sql.TxOptions{MaxRetries: 3}, func(tx sql.Engine) error {
+		if _, err := tx.InsertOne(ctx, &User{Name: "B", Email: "b@e.c", Age: 20}); err != nil {
+			return err
+		}
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+
+	assert.Panics(t, func() {
+		_ = db.RunInTx(ctx, sql.TxOptions{}, func(tx sql.Engine) error {
+			_, _ = tx.InsertOne(ctx, &User{Name: "C", Email: "c@e.c", Age: 30})
+			panic("boom")
+		})
+	})
+
+	var users []User
+	assert.NoError(t, db.Table("user").FindMany(ctx, &users))
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "A", users[0].Name)
+	}
+
+	tx, err := db.BeginTxWithOptions(ctx, &stdsql.TxOptions{Isolation: stdsql.LevelSerializable})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Rollback())
+}
+
+func TestIntegration_TranslatedErrors(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	_, err := db.Table("user").InsertOne(ctx, &User{Name: "A", Email: "a@e.c"})
+	assert.NoError(t, err)
+
+	_, err = db.Table("user").InsertOne(ctx, &User{Name: "A", Email: "other@e.c"})
+	assert.ErrorIs(t, err, dberr.ErrDuplicateEntry)
+	var dbErr *dberr.DBError
+	if assert.ErrorAs(t, err, &dbErr) {
+		assert.Equal(t, "user", dbErr.Table)
+		assert.Equal(t, "name", dbErr.Column)
+	}
+
+	_, err = db.Exec(ctx, "INSERT INTO user (name, email) VALUES (?, ?)", "B", "a@e.c")
+	assert.ErrorIs(t, err, dberr.ErrDuplicateEntry)
+}
+
+var userCols = struct {
+	ID   sql.Column[int64]
+	Name sql.Column[string]
+	Age  sql.Column[int]
+}{
+	ID:   sql.Col[int64]("id"),
+	Name: sql.Col[string]("name"),
+	Age:  sql.Col[int]("age"),
+}
+
+func TestIntegration_TypedQuery(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i, name := range []string{"A", "B", "C", "D"} {
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@e.c", Age: 10 * (i + 1)})
+		assert.NoError(t, err)
+	}
+
+	users, err := sql.Query[User](db).
+		Where(cond.Or(userCols.Age.Gte(30), userCols.Name.Eq("A"))).
+		OrderBy(userCols.Age.Desc()).
+		All(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.Equal(t, "D", users[0].Name)
+	assert.Equal(t, "A", users[2].Name)
+
+	user, found, err := sql.Query[User](db).Where(userCols.Name.In("B", "Z"), userCols.Age.Lt(25)).One(ctx)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 20, user.Age)
+
+	_, found, err = sql.Query[User](db).Where(userCols.ID.Eq(99)).One(ctx)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestIntegration_FindPage(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("U%d", i)
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@e.c", Age: 20 + i%2})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, db.Table("user").ID(5).DeleteOne(ctx, User{}))
+
+	var users []User
+	p, err := db.Table("user").OrderBy("id").FindPage(ctx, &users, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), p.TotalItems, "soft-deleted rows are not counted")
+	assert.Equal(t, int64(2), p.TotalPages)
+	assert.Equal(t, []string{"U3", "U4"}, []string{users[0].Name, users[1].Name})
+
+	users = nil
+	p, err = db.Table("user").FindPage(ctx, &users, 1, 10, User{Age: 21})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, int64(2), p.TotalItems)
+
+	// Past the last page the window is empty, so the total is counted separately.
+	users = nil
+	p, err = db.Table("user").FindPage(ctx, &users, 9, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Equal(t, int64(4), p.TotalItems)
+	assert.False(t, p.HasNext())
+
+	var ages []User
+	p, err = db.Table("user").Distinct().Columns("age").OrderBy("age").FindPage(ctx, &ages, 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, ages, 1)
+	assert.Equal(t, int64(2), p.TotalItems)
+}
+
+func TestIntegration_FindCursor(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	ages := []int{30, 20, 30, 10, 20, 30}
+	for i, age := range ages {
+		name := fmt.Sprintf("U%d", i+1)
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@e.c", Age: age})
+		assert.NoError(t, err)
+	}
+	names := func(users []User) []string {
+		out := make([]string, len(users))
+		for i, u := range users {
+			out[i] = u.Name
+		}
+		return out
+	}
+
+	// age DESC, id ASC: U1 U3 U6 | U2 U5 | U4
+	var users []User
+	page, err := db.Table("user").OrderBy("age", "DESC").Limit(2).FindCursor(ctx, &users)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"U1", "U3"}, names(users))
+	assert.True(t, page.HasNext)
+	assert.False(t, page.HasPrev)
+
+	page, err = db.Table("user").OrderBy("age", "DESC").Limit(2).After(page.Next).FindCursor(ctx, &users)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"U6", "U2"}, names(users))
+	assert.True(t, page.HasNext)
+	assert.True(t, page.HasPrev)
+	prev := page.Prev
+
+	page, err = db.Table("user").OrderBy("age", "DESC").Limit(2).After(page.Next).FindCursor(ctx, &users)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"U5", "U4"}, names(users))
+	assert.False(t, page.HasNext)
+
+	page, err = db.Table("user").OrderBy("age", "DESC").Limit(2).Before(prev).FindCursor(ctx, &users)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"U1", "U3"}, names(users))
+	assert.False(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+
+	// Filters and soft delete apply as in FindMany.
+	assert.NoError(t, db.Table("user").ID(3).DeleteOne(ctx, User{}))
+	page, err = db.Table("user").Limit(5).FindCursor(ctx, &users, User{Age: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"U1", "U6"}, names(users))
+	assert.False(t, page.HasNext)
+
+	// A cursor only fits the ORDER BY it was issued for.
+	_, err = db.Table("user").OrderBy("name").After(prev).FindCursor(ctx, &users)
+	assert.ErrorIs(t, err, dberr.ErrInvalidQuery)
+}
+
+func TestIntegration_Iterate(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("U%d", i)
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@e.c", Age: 10 * i})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, db.Table("user").ID(2).DeleteOne(ctx, User{}))
+
+	var names []string
+	err := db.Table("user").Where("age > ?", 10).OrderBy("id").Iterate(ctx, User{}, func(row any) error {
+		names = append(names, row.(*User).Name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"U3", "U4", "U5"}, names)
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = db.Table("user").Iterate(ctx, &User{}, func(row any) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+
+	rows, err := sql.Stream[User](ctx, db.Table("user").OrderBy("age", "DESC"), User{Age: 40})
+	assert.NoError(t, err)
+	defer rows.Close()
+	var got []User
+	for rows.Next() {
+		var u User
+		assert.NoError(t, rows.Scan(&u))
+		got = append(got, u)
+	}
+	assert.NoError(t, rows.Err())
+	assert.Len(t, got, 1)
+	assert.Equal(t, "U4", got[0].Name)
+	assert.NoError(t, rows.Close())
+}
+
+func TestIntegration_Aggregates(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i, age := range []int{20, 30, 30, 40} {
+		name := fmt.Sprintf("U%d", i+1)
+		id, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@e.c", Age: age})
+		assert.NoError(t, err)
+		_, err = db.Table("post").InsertOne(ctx, &Post{UserID: id.(int64), Title: "t" + name})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, db.Table("user").ID(4).DeleteOne(ctx, User{}))
+
+	var total int64
+	assert.NoError(t, db.Sum("age").Scalar(ctx, &total, User{}))
+	assert.Equal(t, int64(80), total, "the soft-deleted row is excluded")
+
+	var maxAge int
+	assert.NoError(t, db.Table("user").WithDeleted().Max("age").Scalar(ctx, &maxAge))
+	assert.Equal(t, 40, maxAge)
+
+	var none *int64
+	assert.NoError(t, db.Sum("age").Where("age > ?", 100).Scalar(ctx, &none, User{}))
+	assert.Nil(t, none, "SUM of no rows is NULL")
+
+	var name string
+	err := db.Table("user").Columns("name").Where("age > ?", 100).Scalar(ctx, &name)
+	assert.ErrorIs(t, err, stdsql.ErrNoRows)
+
+	err = db.Count("*").Scalar(ctx, &total)
+	assert.ErrorIs(t, err, dberr.ErrInvalidQuery, "no table to select from")
+
+	n, err := db.CountRows(ctx, User{Age: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = db.Table("user").GroupBy("age").CountRows(ctx, User{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n, "grouped statements count groups")
+
+	n, err = db.Table("post").Join("user", "user.id = post.user_id").Where("user.age >= ?", 30).CountRows(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	rows, err := db.Columns("age").Count("*", "n").GroupBy("age").OrderBy("age").FindMaps(ctx, User{})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"n": int64(1), "age": int64(20)},
+		{"n": int64(2), "age": int64(30)},
+	}, rows)
+}
+
+func TestIntegration_WhereCond(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i, age := range []int{15, 25, 35, 45} {
+		name := fmt.Sprintf("U%d", i+1)
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@e.c", Age: age})
+		assert.NoError(t, err)
+	}
+
+	var users []User
+	err := db.Table("user").
+		WhereCond(cond.Or(cond.Lt("age", 20), cond.Between("age", 40, 50))).
+		Where("name <> ?", "U4").
+		OrderBy("id").FindMany(ctx, &users)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "U1", users[0].Name)
+
+	n, err := db.Table("user").WhereCond(cond.Not(cond.In("name", []string{"U1", "U2"}))).CountRows(ctx, User{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+type Category struct {
+	ID       int64  `db:"id,pk autoincr"`
+	ParentID int64  `db:"parent_id"`
+	Name     string `db:"name"`
+}
+
+func TestIntegration_Subqueries(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i, name := range []string{"U1", "U2", "U3"} {
+		_, err := db.Table("user").InsertOne(ctx, &User{Name: name, Email: name + "@e.c", Age: 20 + 10*i})
+		assert.NoError(t, err)
+	}
+	for _, p := range []Post{{UserID: 1, Title: "a"}, {UserID: 1, Title: "b"}, {UserID: 3, Title: "c"}} {
+		_, err := db.Table("post").InsertOne(ctx, &p)
+		assert.NoError(t, err)
+	}
+
+	var users []User
+	posters := db.Table("post").Columns("user_id").Where("title <> ?", "c")
+	assert.NoError(t, db.Table("user").Where("age > ?", 0).In("id", posters).FindMany(ctx, &users))
+	assert.Len(t, users, 1)
+	assert.Equal(t, "U1", users[0].Name)
+
+	users = nil
+	assert.NoError(t, db.Table("user").
+		NotExists(db.Table("post").Columns("1").Where("post.user_id = user.id")).
+		FindMany(ctx, &users))
+	assert.Len(t, users, 1)
+	assert.Equal(t, "U2", users[0].Name)
+
+	n, err := db.From(db.Table("user").Where("age >= ?", 30), "older").Where("age < ?", 40).CountRows(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// A recursive CTE walks a hierarchy.
+	assert.NoError(t, db.Sync(ctx, Category{}))
+	for _, c := range []Category{{Name: "root"}, {ParentID: 1, Name: "a"}, {ParentID: 2, Name: "a1"}, {Name: "other"}} {
+		_, err = db.Table("category").InsertOne(ctx, &c)
+		assert.NoError(t, err)
+	}
+	var tree []Category
+	err = db.WithRecursive("tree",
+		"SELECT * FROM category WHERE name = ? UNION ALL SELECT c.* FROM category c JOIN tree ON c.parent_id = tree.id", "root").
+		Table("tree").Where("name <> ?", "root").OrderBy("id").FindMany(ctx, &tree)
+	assert.NoError(t, err)
+	assert.Len(t, tree, 2)
+	assert.Equal(t, "a1", tree[1].Name)
+
+	// UPDATE and DELETE render neither a CTE nor a FROM subquery.
+	_, err = db.With("root", "SELECT id FROM category WHERE name = ?", "root").
+		Table("category").Where("name = ?", "other").DeleteMany(ctx)
+	assert.ErrorIs(t, err, dberr.ErrNotSupported)
+	_, err = db.From(db.Table("user").Where("age >= ?", 30), "older").Where("age < ?", 40).UpdateMany(ctx, &User{Age: 1})
+	assert.ErrorIs(t, err, dberr.ErrNotSupported)
+}
+
+// userV2 is User as a later release declares it: email loses its UNIQUE
+// constraint for an index, age becomes fractional, bio is new and the
+// soft-delete column is gone.
+type userV2 struct {
+	ID    int64   `db:"id,pk autoincr"`
+	Name  string  `db:"name,uq"`
+	Email string  `db:"email,idx"`
+	Age   float64 `db:"age"`
+	Bio   string  `db:"bio"`
+}
+
+func (userV2) TableName() string { return "user" }
+
+func TestIntegration_Plan(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	changes, err := db.Plan(ctx, User{}, Post{})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = db.Plan(ctx, Category{})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, sql.CreateTable, changes[0].Kind)
+		assert.Contains(t, changes[0].SQL, `CREATE TABLE IF NOT EXISTS "category"`)
+	}
+
+	changes, err = db.Plan(ctx, userV2{})
+	assert.NoError(t, err)
+	var kinds []sql.ChangeKind
+	for _, c := range changes {
+		kinds = append(kinds, c.Kind)
+	}
+	assert.Equal(t, []sql.ChangeKind{sql.DropConstraint, sql.AlterColumnType, sql.AddColumn, sql.DropColumn, sql.AddIndex}, kinds)
+	assert.Equal(t, "alter column type user.age: INTEGER -> REAL", changes[1].String())
+
+	script := sql.PlanSQL(changes)
+	assert.Contains(t, script, "-- drop constraint user.sqlite_autoindex_user_2: UNIQUE (email) -> (none): not supported in place\n")
+	assert.Contains(t, script, "ALTER TABLE \"user\" ADD COLUMN bio TEXT;\n")
+	assert.Contains(t, script, "ALTER TABLE \"user\" DROP COLUMN deleted_at;\n")
+	assert.Contains(t, script, `CREATE INDEX IF NOT EXISTS "idx_user_0" ON "user" (email);`)
+
+	// Plan leaves the schema alone.
+	changes, err = db.Plan(ctx, User{})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+type Invoice struct {
+	ID     int64   `db:"id,pk autoincr"`
+	Number string  `db:"number,size:32 notnull"`
+	Amount float64 `db:"amount,precision:10,2 default:0 notnull"`
+	Status string  `db:"status,default:'draft'"`
+	Note   string  `db:"note,type:TEXT"`
+}
+
+type invoiceV2 struct {
+	ID     int64   `db:"id,pk autoincr"`
+	Number string  `db:"number,size:32 notnull"`
+	Amount float64 `db:"amount,precision:10,2 default:0 notnull"`
+	Status string  `db:"status,default:'open' notnull"`
+	Note   string  `db:"note,type:TEXT"`
+}
+
+func (invoiceV2) TableName() string { return "invoice" }
+
+func TestIntegration_ColumnOptions(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	assert.NoError(t, db.Sync(ctx, Invoice{}))
+
+	changes, err := db.Plan(ctx, Invoice{})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = db.Exec(ctx, `INSERT INTO "invoice" (number) VALUES ('INV-1')`)
+	assert.NoError(t, err)
+	var inv Invoice
+	_, err = db.Table("invoice").Where("number = ?", "INV-1").FindOne(ctx, &inv)
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", inv.Status)
+	assert.Equal(t, 0.0, inv.Amount)
+
+	_, err = db.Exec(ctx, `INSERT INTO "invoice" (status) VALUES ('paid')`)
+	assert.Error(t, err, "number is NOT NULL")
+
+	changes, err = db.Plan(ctx, invoiceV2{})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, "alter column default invoice.status: 'draft' -> 'open'", changes[0].String())
+		assert.Equal(t, "alter column nullability invoice.status: NULL -> NOT NULL", changes[1].String())
+		assert.Empty(t, changes[0].SQL)
+	}
+
+	// Sync makes them by rebuilding the table.
+	assert.NoError(t, db.Sync(ctx, invoiceV2{}))
+	changes, err = db.Plan(ctx, invoiceV2{})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = db.Exec(ctx, `INSERT INTO "invoice" (number) VALUES ('INV-2')`)
+	assert.NoError(t, err)
+	var invoices []invoiceV2
+	assert.NoError(t, db.Table("invoice").FindMany(ctx, &invoices))
+	if assert.Len(t, invoices, 2) {
+		assert.Equal(t, "draft", invoices[0].Status)
+		assert.Equal(t, "open", invoices[1].Status)
+	}
+}
+
+type postV2 struct {
+	ID     int64  `db:"id,pk autoincr"`
+	UserID int64  `db:"user_id,fk:user.id ondelete:cascade"`
+	Title  string `db:"title"`
+	Body   string `db:"body"`
+}
+
+func (postV2) TableName() string { return "post" }
+
+func TestIntegration_ForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	userID, err := db.InsertOne(ctx, &User{Name: "A", Email: "a@e.c"})
+	assert.NoError(t, err)
+	_, err = db.Table("post").InsertOne(ctx, &Post{UserID: userID.(int64), Title: "kept"})
+	assert.NoError(t, err)
+	_, err = db.Table("post").InsertOne(ctx, &Post{UserID: 99, Title: "orphan"})
+	assert.NoError(t, err)
+	_, err = db.Exec(ctx, `CREATE TRIGGER post_body AFTER INSERT ON "post" BEGIN UPDATE "post" SET body = 'new' WHERE id = NEW.id; END`)
+	assert.NoError(t, err)
+
+	changes, err := db.Plan(ctx, postV2{})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, `add constraint post.fk_post_user_id: (none) -> FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE`, changes[0].String())
+	}
+
+	// The orphaned post keeps the rebuilt table from being committed.
+	err = db.Sync(ctx, postV2{})
+	assert.ErrorIs(t, err, dberr.ErrForeignKeyViolation)
+	changes, err = db.Plan(ctx, postV2{})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	_, err = db.Exec(ctx, `DELETE FROM "post" WHERE title = 'orphan'`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Sync(ctx, postV2{}))
+	changes, err = db.Plan(ctx, postV2{})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = db.Table("post").InsertOne(ctx, &postV2{UserID: 99, Title: "orphan"})
+	assert.ErrorIs(t, err, dberr.ErrForeignKeyViolation)
+
+	// The rebuilt table keeps its rows and triggers.
+	_, err = db.Table("post").InsertOne(ctx, &postV2{UserID: userID.(int64), Title: "new"})
+	assert.NoError(t, err)
+	var posts []postV2
+	assert.NoError(t, db.Table("post").FindMany(ctx, &posts))
+	if assert.Len(t, posts, 2) {
+		assert.Equal(t, "kept", posts[0].Title)
+		assert.Equal(t, "new", posts[1].Body)
+	}
+
+	_, err = db.Exec(ctx, `DELETE FROM "user" WHERE id = ?`, userID)
+	assert.NoError(t, err)
+	posts = nil
+	assert.NoError(t, db.Table("post").FindMany(ctx, &posts))
+	assert.Empty(t, posts, "posts are deleted with their user")
+}
+
+type userV3 struct {
+	ID    int64   `db:"id,pk autoincr"`
+	Name  string  `db:"name,uq"`
+	Email string  `db:"email,idx"`
+	Age   float64 `db:"age"`
+	Bio   string  `db:"bio"`
+	Plan  string  `db:"plan,notnull"`
+}
+
+func (userV3) TableName() string { return "user" }
+
+func TestIntegration_SyncRebuild(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	for i := 1; i <= 3; i++ {
+		_, err := db.InsertOne(ctx, &User{Name: fmt.Sprintf("User%d", i), Email: fmt.Sprintf("user%d@e.c", i), Age: 20 + i})
+		assert.NoError(t, err)
+	}
+	_, err := db.Exec(ctx, `CREATE VIEW adult AS SELECT name FROM "user" WHERE age >= 21`)
+	assert.NoError(t, err)
+
+	// userV2 changes the type of age and drops the UNIQUE constraint on
+	// email, which SQLite can only do by rebuilding the table.
+	assert.NoError(t, db.Sync(ctx, userV2{}))
+	changes, err := db.Plan(ctx, userV2{})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1, sql.PlanSQL(changes)) {
+		assert.Equal(t, sql.DropColumn, changes[0].Kind, "Sync keeps columns the struct no longer declares")
+		assert.Equal(t, "deleted_at", changes[0].Name)
+	}
+
+	var users []userV2
+	assert.NoError(t, db.Table("user").FindMany(ctx, &users))
+	if assert.Len(t, users, 3) {
+		assert.Equal(t, userV2{ID: 2, Name: "User2", Email: "user2@e.c", Age: 22}, users[1])
+	}
+	id, err := db.Table("user").InsertOne(ctx, &userV2{Name: "User4", Email: "user1@e.c", Age: 1.5})
+	assert.NoError(t, err, "email is no longer unique")
+	assert.Equal(t, int64(4), id)
+
+	var names []string
+	rows, err := db.Query(ctx, "SELECT name FROM adult")
+	if assert.NoError(t, err) {
+		for rows.Next() {
+			var name string
+			assert.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		assert.NoError(t, rows.Close())
+	}
+	assert.Equal(t, []string{"User1", "User2", "User3"}, names)
+
+	// A NOT NULL column without a default cannot be filled for existing
+	// rows; the rebuild is rolled back.
+	err = db.Sync(ctx, userV3{})
+	assert.ErrorIs(t, err, dberr.ErrNotNullViolation)
+	changes, err = db.Plan(ctx, userV3{})
+	assert.NoError(t, err)
+	assert.Equal(t, sql.AddColumn, changes[0].Kind)
+}
@@ -0,0 +1,118 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/masudur-rahman/styx/sql/builder"
+)
+
+// MaxParams is the maximum number of bind parameters SQLite accepts in a
+// single query (SQLITE_MAX_VARIABLE_NUMBER).
+const MaxParams = 32766
+
+// Statement is the query builder used by the SQLite engine.
+type Statement = builder.Statement
+
+// NewStatement returns an empty Statement rendering SQLite.
+func NewStatement() Statement {
+	return builder.NewStatement(Dialect{})
+}
+
+// Dialect renders SQLite: positional ? placeholders, double-quoted
+// identifiers and ON CONFLICT upserts (3.24+).
+type Dialect struct{}
+
+var _ builder.Dialect = Dialect{}
+
+func (Dialect) Name() string {
+	return "sqlite"
+}
+
+func (Dialect) Placeholder(int) string {
+	return "?"
+}
+
+func (Dialect) ShiftPlaceholders(query string, _ int) string {
+	return query
+}
+
+func (Dialect) MaxParams() int {
+	return MaxParams
+}
+
+func (Dialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (Dialect) SQLType(fieldType reflect.Type, autoincr bool) string {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if autoincr {
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint64:
+			return "INTEGER PRIMARY KEY AUTOINCREMENT"
+		}
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int32:
+		return "INTEGER"
+	case reflect.Int64, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.String:
+		return "TEXT"
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			return "BLOB"
+		}
+	case reflect.Struct:
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			return "DATETIME"
+		}
+	}
+
+	return ""
+}
+
+func (Dialect) SupportsReturning() bool {
+	return true
+}
+
+func (Dialect) SupportsWindowFunctions() bool {
+	return true
+}
+
+func (Dialect) UpsertClause(_ string, conflictCols, updateCols []string) string {
+	sets := make([]string, len(updateCols))
+	for i, col := range updateCols {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", col, col)
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(conflictCols, ", "), strings.Join(sets, ", "))
+}
+
+// LimitOffset renders LIMIT/OFFSET. SQLite rejects OFFSET without LIMIT, so
+// an offset alone is paired with LIMIT -1 (no limit).
+func (Dialect) LimitOffset(limit, offset int64) string {
+	switch {
+	case limit > 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit > 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		return fmt.Sprintf("LIMIT -1 OFFSET %d", offset)
+	}
+	return ""
+}
+
+func (Dialect) TranslateError(err error) error {
+	return TranslateError(err)
+}
@@ -0,0 +1,91 @@
+package lib
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/sql/builder"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// IsRetryable reports whether err is SQLITE_BUSY, or one of its extended
+// codes, raised when another connection holds a conflicting lock.
+func IsRetryable(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+}
+
+// constraintDetail captures what follows "... constraint failed: " in a
+// SQLite message: "user.email", "user.a, user.b" or a CHECK constraint's
+// name or expression.
+var constraintDetail = regexp.MustCompile(`^.*constraint failed: (.+?)(?: \(\d+\))?$`)
+
+// TranslateError wraps modernc sqlite errors into a *dberr.DBError by
+// extended result code. SQLite reports the failing columns, or the CHECK
+// constraint, only in the message text, which is parsed for them. Errors that
+// map to no dberr sentinel are returned unchanged.
+func TranslateError(err error) error {
+	var dbErr *dberr.DBError
+	if err == nil || errors.As(err, &dbErr) {
+		return err
+	}
+
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		if builder.IsConnError(err) {
+			return &dberr.DBError{Err: dberr.ErrConnectionFailed, Cause: err}
+		}
+		return err
+	}
+
+	translated := &dberr.DBError{Cause: err}
+	switch code := sqliteErr.Code(); {
+	case code == sqlite3.SQLITE_CONSTRAINT_UNIQUE, code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		translated.Err = dberr.ErrDuplicateEntry
+		translated.Table, translated.Column = failedColumns(sqliteErr.Error())
+	case code == sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		translated.Err = dberr.ErrNotNullViolation
+		translated.Table, translated.Column = failedColumns(sqliteErr.Error())
+	case code == sqlite3.SQLITE_CONSTRAINT_CHECK:
+		translated.Err = dberr.ErrCheckViolation
+		if m := constraintDetail.FindStringSubmatch(sqliteErr.Error()); m != nil {
+			translated.Constraint = m[1]
+		}
+	case code == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		translated.Err = dberr.ErrForeignKeyViolation
+	case code == sqlite3.SQLITE_BUSY_SNAPSHOT:
+		translated.Err = dberr.ErrSerializationFailure
+	case code&0xff == sqlite3.SQLITE_CANTOPEN:
+		translated.Err = dberr.ErrConnectionFailed
+	default:
+		return err
+	}
+	return translated
+}
+
+// failedColumns parses "table.col1, table.col2" out of a UNIQUE or NOT NULL
+// failure message into the table and a comma-separated column list.
+func failedColumns(msg string) (table, columns string) {
+	m := constraintDetail.FindStringSubmatch(msg)
+	if m == nil {
+		return "", ""
+	}
+
+	var cols []string
+	for _, qualified := range strings.Split(m[1], ", ") {
+		tbl, col, ok := strings.Cut(qualified, ".")
+		if !ok {
+			return "", m[1]
+		}
+		table = tbl
+		cols = append(cols, col)
+	}
+	return table, strings.Join(cols, ", ")
+}
@@ -0,0 +1,305 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	isql "github.com/masudur-rahman/styx/sql"
+)
+
+// PlanTable compares table, a struct, with the live table and returns the
+// changes that would make them match, without applying any. SQLite cannot
+// alter a column or a constraint in place; those changes carry no SQL, and
+// SyncTable makes them by rebuilding the table.
+func PlanTable(ctx context.Context, conn *sql.DB, table any) ([]isql.SchemaChange, error) {
+	tableName := GenerateTableName(table)
+	fields, err := getTableInfo(table)
+	if err != nil {
+		return nil, err
+	}
+	indexes := extractIndexes(table)
+
+	exist, err := tableExists(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		changes := []isql.SchemaChange{{
+			Kind:  isql.CreateTable,
+			Table: tableName,
+			SQL:   strings.TrimSuffix(createTableQuery(tableName, fields), ";"),
+		}}
+		for _, idx := range indexes {
+			changes = append(changes, addIndexChange(tableName, idx))
+		}
+		return changes, nil
+	}
+
+	columns, err := getExistingColumns(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	liveIndexes, err := getIndexes(ctx, conn, tableName, "c")
+	if err != nil {
+		return nil, err
+	}
+	liveUnique, err := getUniqueConstraints(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	liveForeignKeys, err := getForeignKeys(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drops come first, so that no index or constraint refers to a dropped
+	// column, and additions last, once their columns exist.
+	var changes, addConstraints, addIndexes []isql.SchemaChange
+	for _, live := range liveIndexes {
+		want := findIndex(indexes, live.Name)
+		if want != nil && want.Unique == live.Unique && sameColumns(want.Cols, live.Cols, false) {
+			continue
+		}
+		changes = append(changes, isql.SchemaChange{
+			Kind: isql.DropIndex, Table: tableName, Name: live.Name, From: describeIndex(live),
+			SQL: fmt.Sprintf("DROP INDEX \"%s\"", live.Name),
+		})
+		if want != nil {
+			addIndexes = append(addIndexes, addIndexChange(tableName, *want))
+		}
+	}
+	for _, idx := range indexes {
+		if findIndex(liveIndexes, idx.Name) == nil {
+			addIndexes = append(addIndexes, addIndexChange(tableName, idx))
+		}
+	}
+
+	groups := uniqueGroups(fields, columns)
+	for _, live := range liveUnique {
+		if !hasGroup(groups, live.Cols) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.DropConstraint, Table: tableName, Name: live.Name,
+				From: "UNIQUE (" + strings.Join(live.Cols, ", ") + ")",
+			})
+		}
+	}
+	for _, group := range groups {
+		if !hasGroup(liveUniqueColumns(liveUnique), group) {
+			addConstraints = append(addConstraints, isql.SchemaChange{
+				Kind: isql.AddConstraint, Table: tableName,
+				To: "UNIQUE (" + strings.Join(group, ", ") + ")",
+			})
+		}
+	}
+
+	dropForeignKeys, addForeignKeys := foreignKeyChanges(tableName, foreignKeys(tableName, fields), liveForeignKeys)
+	changes = append(changes, dropForeignKeys...)
+	addConstraints = append(addConstraints, addForeignKeys...)
+
+	changes = append(changes, columnChanges(tableName, fields, columns)...)
+	changes = append(changes, addConstraints...)
+	return append(changes, addIndexes...), nil
+}
+
+// columnChanges compares fields with the live columns of a table: columns
+// to add, alter or drop. Only additions and drops carry SQL.
+func columnChanges(tableName string, fields []fieldInfo, columns []columnInfo) []isql.SchemaChange {
+	var changes []isql.SchemaChange
+	for _, f := range fields {
+		col := findColumn(columns, f.Name)
+		if col == nil {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AddColumn, Table: tableName, Name: f.Name, To: f.Type,
+				SQL: generateAddColumnQuery(tableName, []string{f.Name + " " + f.Type}),
+			})
+			continue
+		}
+		if want := columnType(f.SQLType); want != "" && normalizeType(col.Type) != want {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterColumnType, Table: tableName, Name: f.Name, From: col.Type, To: want,
+			})
+		}
+		if normalizeDefault(col.Default) != normalizeDefault(f.Default) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterDefault, Table: tableName, Name: f.Name, From: col.Default, To: f.Default,
+			})
+		}
+		// SQLite reports INTEGER PRIMARY KEY columns as nullable.
+		if !f.PK && col.Nullable == f.NotNull {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.AlterNullable, Table: tableName, Name: f.Name,
+				From: nullability(!col.Nullable), To: nullability(f.NotNull),
+			})
+		}
+	}
+	for _, col := range columns {
+		if !hasField(fields, col.Name) {
+			changes = append(changes, isql.SchemaChange{
+				Kind: isql.DropColumn, Table: tableName, Name: col.Name, From: col.Type,
+				SQL: fmt.Sprintf("ALTER TABLE \"%s\" DROP COLUMN %s", tableName, col.Name),
+			})
+		}
+	}
+	return changes
+}
+
+// foreignKeyChanges compares the foreign keys a struct declares with the
+// live ones of its table, matched by column: live foreign keys that differ
+// or are not declared are dropped, and declared ones are added. SQLite
+// cannot change foreign keys in place, so the changes carry no SQL.
+func foreignKeyChanges(tableName string, want, live []foreignKeyInfo) (drops, adds []isql.SchemaChange) {
+	for _, fk := range live {
+		if declared := findForeignKey(want, fk.Column); declared != nil && sameForeignKey(*declared, fk) {
+			continue
+		}
+		drops = append(drops, isql.SchemaChange{
+			Kind: isql.DropConstraint, Table: tableName, Name: fk.Name, From: foreignKeyClause(fk),
+		})
+	}
+	for _, fk := range want {
+		if existing := findForeignKey(live, fk.Column); existing != nil && sameForeignKey(*existing, fk) {
+			continue
+		}
+		adds = append(adds, isql.SchemaChange{
+			Kind: isql.AddConstraint, Table: tableName, Name: fk.Name, To: foreignKeyClause(fk),
+		})
+	}
+	return drops, adds
+}
+
+func findForeignKey(fks []foreignKeyInfo, column string) *foreignKeyInfo {
+	for i := range fks {
+		if fks[i].Column == column {
+			return &fks[i]
+		}
+	}
+	return nil
+}
+
+// sameForeignKey reports whether a and b reference the same column with the
+// same actions, whatever their names.
+func sameForeignKey(a, b foreignKeyInfo) bool {
+	return a.RefTable == b.RefTable && a.RefColumn == b.RefColumn && a.OnDelete == b.OnDelete && a.OnUpdate == b.OnUpdate
+}
+
+// columnType returns the column type of a field's SQL type, without the
+// key clauses of an autoincrementing primary key.
+func columnType(sqlType string) string {
+	return normalizeType(strings.TrimSuffix(sqlType, " PRIMARY KEY AUTOINCREMENT"))
+}
+
+// normalizeType upper-cases a declared type and collapses its spaces.
+func normalizeType(t string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(t)), " ")
+}
+
+// normalizeDefault returns a DEFAULT expression in a form that compares
+// equal whether it was declared in a tag or read back with table_info.
+func normalizeDefault(def string) string {
+	def = strings.Trim(strings.TrimSpace(def), "'")
+	if strings.EqualFold(def, "null") {
+		return ""
+	}
+	return strings.ToLower(def)
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+func addIndexChange(tableName string, idx indexInfo) isql.SchemaChange {
+	return isql.SchemaChange{
+		Kind: isql.AddIndex, Table: tableName, Name: idx.Name, To: describeIndex(idx),
+		SQL: createIndexQuery(tableName, idx),
+	}
+}
+
+func describeIndex(idx indexInfo) string {
+	s := "(" + strings.Join(idx.Cols, ", ") + ")"
+	if idx.Unique {
+		s = "UNIQUE " + s
+	}
+	return s
+}
+
+func findIndex(indexes []indexInfo, name string) *indexInfo {
+	for i := range indexes {
+		if indexes[i].Name == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+func hasField(fields []fieldInfo, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueGroups returns the column sets of the UNIQUE constraints that fields
+// declare: one per uq column and one for the uqs columns together. uq
+// columns missing from the live table are left out, as adding the column
+// adds its constraint too.
+func uniqueGroups(fields []fieldInfo, columns []columnInfo) [][]string {
+	var groups [][]string
+	var composite []string
+	for _, f := range fields {
+		if f.Unique && findColumn(columns, f.Name) != nil {
+			groups = append(groups, []string{f.Name})
+		}
+		if f.IsComposite {
+			composite = append(composite, f.Name)
+		}
+	}
+	if len(composite) > 0 {
+		groups = append(groups, composite)
+	}
+	return groups
+}
+
+func liveUniqueColumns(constraints []indexInfo) [][]string {
+	groups := make([][]string, len(constraints))
+	for i, c := range constraints {
+		groups[i] = c.Cols
+	}
+	return groups
+}
+
+func hasGroup(groups [][]string, cols []string) bool {
+	for _, g := range groups {
+		if sameColumns(g, cols, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameColumns reports whether a and b list the same columns, in the same
+// order unless anyOrder is set.
+func sameColumns(a, b []string, anyOrder bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if anyOrder {
+		a = append([]string(nil), a...)
+		b = append([]string(nil), b...)
+		sort.Strings(a)
+		sort.Strings(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
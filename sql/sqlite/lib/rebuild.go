@@ -0,0 +1,164 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/masudur-rahman/styx/dberr"
+)
+
+// rebuildTable recreates an existing table from fields, for the schema
+// changes ALTER TABLE cannot make, as SQLite documents it in "Making Other
+// Kinds Of Table Schema Changes": the rows are copied into a new table that
+// then replaces the old one, and the table's indexes and triggers are
+// created again. The new table has the columns and constraints fields
+// declare, plus the live columns fields do not declare, so no data is lost.
+//
+// It runs in one transaction with foreign key enforcement off. If the rows
+// do not satisfy the new foreign keys, it fails with
+// dberr.ErrForeignKeyViolation and the table is left as it was.
+func rebuildTable(ctx context.Context, db *sql.DB, tableName string, fields []fieldInfo) error {
+	columns, err := getExistingColumns(ctx, db, tableName)
+	if err != nil {
+		return err
+	}
+	objects, err := getSchemaObjects(ctx, db, tableName)
+	if err != nil {
+		return err
+	}
+
+	fields = fields[:len(fields):len(fields)]
+	var names []string
+	for _, col := range columns {
+		names = append(names, col.Name)
+		if !hasField(fields, col.Name) {
+			fields = append(fields, keptField(col))
+		}
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Neither pragma takes effect inside a transaction. With legacy
+	// renaming, the rename does not check the views that refer to the
+	// dropped table.
+	restoreForeignKeys, err := setPragma(ctx, conn, "foreign_keys", false)
+	if err != nil {
+		return err
+	}
+	defer restoreForeignKeys()
+	restoreLegacyAlter, err := setPragma(ctx, conn, "legacy_alter_table", true)
+	if err != nil {
+		return err
+	}
+	defer restoreLegacyAlter()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	newName := "styx_new_" + tableName
+	cols := strings.Join(names, ", ")
+	queries := []string{
+		fmt.Sprintf("CREATE TABLE \"%s\" (%s)", newName, tableDefinition(tableName, fields)),
+		fmt.Sprintf("INSERT INTO \"%s\" (%s) SELECT %s FROM \"%s\"", newName, cols, cols, tableName),
+		fmt.Sprintf("DROP TABLE \"%s\"", tableName),
+		fmt.Sprintf("ALTER TABLE \"%s\" RENAME TO \"%s\"", newName, tableName),
+	}
+	for _, query := range append(queries, objects...) {
+		if _, err = tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("error rebuilding table %s: %w (query: %s)", tableName, TranslateError(err), query)
+		}
+	}
+
+	if err = checkForeignKeys(ctx, tx, tableName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// keptField describes a live column that the struct does not declare.
+func keptField(col columnInfo) fieldInfo {
+	definition := col.Type
+	if !col.Nullable {
+		definition += " NOT NULL"
+	}
+	if col.Default != "" {
+		definition += " DEFAULT " + col.Default
+	}
+	return fieldInfo{
+		Name:    col.Name,
+		Type:    definition,
+		SQLType: col.Type,
+		NotNull: !col.Nullable,
+		Default: col.Default,
+	}
+}
+
+// getSchemaObjects returns the CREATE statements of the indexes and triggers
+// of a table, indexes first, leaving out those SQLite creates for
+// constraints.
+func getSchemaObjects(ctx context.Context, conn *sql.DB, tableName string) ([]string, error) {
+	query := "SELECT sql FROM sqlite_master WHERE tbl_name = ? AND type IN ('index', 'trigger') AND sql IS NOT NULL ORDER BY type, name"
+	rows, err := conn.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting indexes and triggers for table %s: %v", tableName, err)
+	}
+	defer rows.Close()
+
+	var objects []string
+	for rows.Next() {
+		var stmt string
+		if err = rows.Scan(&stmt); err != nil {
+			return nil, fmt.Errorf("error scanning indexes and triggers for table %s: %v", tableName, err)
+		}
+		objects = append(objects, stmt)
+	}
+	return objects, rows.Err()
+}
+
+// setPragma sets a boolean pragma on conn and returns the function that
+// restores its previous value.
+func setPragma(ctx context.Context, conn *sql.Conn, name string, value bool) (restore func(), err error) {
+	var previous bool
+	if err = conn.QueryRowContext(ctx, "PRAGMA "+name).Scan(&previous); err != nil {
+		return nil, err
+	}
+	if previous == value {
+		return func() {}, nil
+	}
+	if _, err = conn.ExecContext(ctx, fmt.Sprintf("PRAGMA %s = %t", name, value)); err != nil {
+		return nil, err
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA %s = %t", name, previous))
+	}, nil
+}
+
+// checkForeignKeys fails with dberr.ErrForeignKeyViolation when a row of
+// the table references a row that does not exist.
+func checkForeignKeys(ctx context.Context, tx *sql.Tx, tableName string) error {
+	var table, parent string
+	var rowID sql.NullInt64
+	var fkID int
+	err := tx.QueryRowContext(ctx, fmt.Sprintf("PRAGMA foreign_key_check(\"%s\")", tableName)).
+		Scan(&table, &rowID, &parent, &fkID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &dberr.DBError{
+		Err:   dberr.ErrForeignKeyViolation,
+		Table: tableName,
+		Cause: fmt.Errorf("row %d of %s references a missing row of %s", rowID.Int64, tableName, parent),
+	}
+}
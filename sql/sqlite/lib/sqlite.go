@@ -16,7 +16,16 @@ import (
 )
 
 // GetSQLiteConnection opens a SQLite database and returns a *sql.DB connection pool.
+// Every connection of the pool enforces foreign keys, unless dbPath sets the
+// foreign_keys pragma itself.
 func GetSQLiteConnection(dbPath string) (*sql.DB, error) {
+	if !strings.Contains(dbPath, "foreign_keys") {
+		sep := "?"
+		if strings.Contains(dbPath, "?") {
+			sep = "&"
+		}
+		dbPath += sep + "_pragma=foreign_keys(1)"
+	}
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, err
@@ -1,9 +1,14 @@
 package lib
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"path/filepath"
 	"testing"
 
+	"github.com/masudur-rahman/styx/dberr"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,6 +24,11 @@ func Test_generateReadQuery(t *testing.T) {
 	})
 }
 
+func newStatement() *Statement {
+	stmt := NewStatement()
+	return &stmt
+}
+
 type insertTestDoc struct {
 	ID    int64  `db:"id,pk autoincr"`
 	Name  string `db:"name"`
@@ -27,7 +37,7 @@ type insertTestDoc struct {
 }
 
 func TestGenerateInsertQuery_skipsZeroValues(t *testing.T) {
-	stmt := new(Statement).Table("test_doc")
+	stmt := newStatement().Table("test_doc")
 	doc := insertTestDoc{Name: "alice", Email: "alice@test.com"}
 
 	query := stmt.GenerateInsertQuery(doc)
@@ -37,11 +47,11 @@ func TestGenerateInsertQuery_skipsZeroValues(t *testing.T) {
 	assert.NotContains(t, query, "score")
 	assert.NotContains(t, query, "id")
 	assert.Contains(t, query, "?")
-	assert.Equal(t, []any{"alice", "alice@test.com"}, stmt.args)
+	assert.Equal(t, []any{"alice", "alice@test.com"}, stmt.Args())
 }
 
 func TestGenerateInsertQuery_mustColsIncludesZeroValues(t *testing.T) {
-	stmt := new(Statement).Table("test_doc").MustCols("score")
+	stmt := newStatement().Table("test_doc").MustCols("score")
 	doc := insertTestDoc{Name: "alice"}
 
 	query := stmt.GenerateInsertQuery(doc)
@@ -58,7 +68,7 @@ type whereTestDoc struct {
 }
 
 func TestGenerateWhereClauseFromFilter_skipsZeroValues(t *testing.T) {
-	stmt := new(Statement)
+	stmt := newStatement()
 	filter := whereTestDoc{UserID: 99}
 
 	clause := stmt.GenerateWhereClauseFromFilter(filter)
@@ -66,11 +76,11 @@ func TestGenerateWhereClauseFromFilter_skipsZeroValues(t *testing.T) {
 	assert.Contains(t, clause, "user_id")
 	assert.NotContains(t, clause, "category_id")
 	assert.NotContains(t, clause, "score")
-	assert.Equal(t, []any{int64(99)}, stmt.args)
+	assert.Equal(t, []any{int64(99)}, stmt.Args())
 }
 
 func TestGenerateWhereClauseFromFilter_mustFilterColsIncludesZeroString(t *testing.T) {
-	stmt := new(Statement).MustFilterCols("category_id")
+	stmt := newStatement().MustFilterCols("category_id")
 	filter := whereTestDoc{UserID: 99}
 
 	clause := stmt.GenerateWhereClauseFromFilter(filter)
@@ -78,11 +88,11 @@ func TestGenerateWhereClauseFromFilter_mustFilterColsIncludesZeroString(t *testi
 	assert.Contains(t, clause, "user_id = ?")
 	assert.Contains(t, clause, "category_id = ?")
 	assert.NotContains(t, clause, "score")
-	assert.Equal(t, []any{int64(99), ""}, stmt.args)
+	assert.Equal(t, []any{int64(99), ""}, stmt.Args())
 }
 
 func TestGenerateWhereClauseFromFilter_mustFilterColsIncludesZeroInt(t *testing.T) {
-	stmt := new(Statement).MustFilterCols("score")
+	stmt := newStatement().MustFilterCols("score")
 	filter := whereTestDoc{UserID: 99}
 
 	clause := stmt.GenerateWhereClauseFromFilter(filter)
@@ -90,7 +100,7 @@ func TestGenerateWhereClauseFromFilter_mustFilterColsIncludesZeroInt(t *testing.
 	assert.Contains(t, clause, "user_id = ?")
 	assert.Contains(t, clause, "score = ?")
 	assert.NotContains(t, clause, "category_id")
-	assert.Equal(t, []any{int64(99), 0}, stmt.args)
+	assert.Equal(t, []any{int64(99), 0}, stmt.Args())
 }
 
 type reqTestDoc struct {
@@ -101,7 +111,7 @@ type reqTestDoc struct {
 }
 
 func TestGenerateWhereClauseFromFilter_reqTagIncludesZeroValues(t *testing.T) {
-	stmt := new(Statement)
+	stmt := newStatement()
 	filter := reqTestDoc{UserID: 99}
 
 	clause := stmt.GenerateWhereClauseFromFilter(filter)
@@ -110,11 +120,11 @@ func TestGenerateWhereClauseFromFilter_reqTagIncludesZeroValues(t *testing.T) {
 	assert.Contains(t, clause, "category_id = ?")
 	assert.Contains(t, clause, "alert_at = ?")
 	assert.NotContains(t, clause, "score")
-	assert.Equal(t, []any{int64(99), "", int64(0)}, stmt.args)
+	assert.Equal(t, []any{int64(99), "", int64(0)}, stmt.Args())
 }
 
 func TestGenerateInsertQuery_reqTagIncludesZeroValues(t *testing.T) {
-	stmt := new(Statement).Table("req_doc")
+	stmt := newStatement().Table("req_doc")
 	doc := reqTestDoc{UserID: 1}
 
 	query := stmt.GenerateInsertQuery(doc)
@@ -127,7 +137,7 @@ func TestGenerateInsertQuery_reqTagIncludesZeroValues(t *testing.T) {
 }
 
 func TestGenerateUpdateQuery_reqTagIncludesZeroValues(t *testing.T) {
-	stmt := new(Statement).Table("req_doc").Where("user_id = ?", 1)
+	stmt := newStatement().Table("req_doc").Where("user_id = ?", 1)
 	doc := reqTestDoc{UserID: 1}
 
 	query := stmt.GenerateUpdateQuery(doc)
@@ -136,14 +146,14 @@ func TestGenerateUpdateQuery_reqTagIncludesZeroValues(t *testing.T) {
 	assert.Contains(t, query, "alert_at = ?")
 	assert.NotContains(t, query, "score")
 	// SET args come before WHERE args in driver call
-	assert.Equal(t, int64(1), stmt.args[0])         // user_id SET value
-	assert.Equal(t, "", stmt.args[1])               // category_id SET value
-	assert.Equal(t, int64(0), stmt.args[2])         // alert_at SET value
-	assert.Equal(t, 1, stmt.args[len(stmt.args)-1]) // WHERE arg last
+	assert.Equal(t, int64(1), stmt.Args()[0])           // user_id SET value
+	assert.Equal(t, "", stmt.Args()[1])                 // category_id SET value
+	assert.Equal(t, int64(0), stmt.Args()[2])           // alert_at SET value
+	assert.Equal(t, 1, stmt.Args()[len(stmt.Args())-1]) // WHERE arg last
 }
 
 func TestGenerateWhereClauseFromFilter_noReqTag_skipsZero(t *testing.T) {
-	stmt := new(Statement)
+	stmt := newStatement()
 	filter := whereTestDoc{UserID: 99}
 
 	clause := stmt.GenerateWhereClauseFromFilter(filter)
@@ -154,7 +164,7 @@ func TestGenerateWhereClauseFromFilter_noReqTag_skipsZero(t *testing.T) {
 }
 
 func TestGenerateInsertQuery_allColsIncludesAllFields(t *testing.T) {
-	stmt := new(Statement).Table("test_doc").AllCols()
+	stmt := newStatement().Table("test_doc").AllCols()
 	doc := insertTestDoc{Name: "alice"}
 
 	query := stmt.GenerateInsertQuery(doc)
@@ -191,7 +201,7 @@ func TestCreateTableQuery_jsonColumns(t *testing.T) {
 }
 
 func TestGenerateInsertQuery_jsonArgsAsText(t *testing.T) {
-	stmt := new(Statement).Table("json_test_doc")
+	stmt := newStatement().Table("json_test_doc")
 	doc := jsonTestDoc{
 		Name:    "alice",
 		Payload: json.RawMessage(`{"a":1}`),
@@ -202,5 +212,125 @@ func TestGenerateInsertQuery_jsonArgsAsText(t *testing.T) {
 
 	assert.Contains(t, query, "payload")
 	assert.Contains(t, query, "address")
-	assert.Equal(t, []any{"alice", `{"a":1}`, `{"street":"Road 1","city":"Dhaka"}`}, stmt.args)
+	assert.Equal(t, []any{"alice", `{"a":1}`, `{"street":"Road 1","city":"Dhaka"}`}, stmt.Args())
+}
+
+func TestGenerateInsertManyQuery_oneRowPerDoc(t *testing.T) {
+	stmt := newStatement().Table("test_doc")
+	docs := []any{
+		insertTestDoc{Name: "alice", Email: "alice@test.com"},
+		&insertTestDoc{Name: "bob", Email: "bob@test.com"},
+	}
+
+	query := stmt.GenerateInsertManyQuery(docs)
+
+	assert.Equal(t, `INSERT INTO "test_doc" (name, email) VALUES (?, ?), (?, ?)`, query)
+	assert.Equal(t, []any{"alice", "alice@test.com", "bob", "bob@test.com"}, stmt.Args())
+}
+
+func TestInsertBatches_respectsParamLimit(t *testing.T) {
+	stmt := newStatement().Table("test_doc")
+	docs := make([]any, MaxParams/2+1)
+	for i := range docs {
+		docs[i] = insertTestDoc{Name: "n", Email: "e"}
+	}
+
+	batches := stmt.InsertBatches(docs)
+
+	assert.Len(t, batches, 2)
+	assert.Len(t, batches[0], MaxParams/2)
+	assert.Len(t, batches[1], 1)
+}
+
+func TestGenerateUpsertQuery(t *testing.T) {
+	stmt := newStatement().Table("test_doc").PKColumn("id")
+	doc := insertTestDoc{Name: "alice", Email: "alice@test.com", Score: 3}
+
+	query, err := stmt.GenerateUpsertQuery(doc, []string{"email"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `INSERT INTO "test_doc" (name, email, score) VALUES (?, ?, ?) ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name, score = EXCLUDED.score`, query)
+	assert.Equal(t, []any{"alice", "alice@test.com", 3}, stmt.Args())
+}
+
+func TestDialect_LimitOffset(t *testing.T) {
+	assert.Equal(t, "", Dialect{}.LimitOffset(0, 0))
+	assert.Equal(t, "LIMIT 5", Dialect{}.LimitOffset(5, 0))
+	assert.Equal(t, "LIMIT 5 OFFSET 10", Dialect{}.LimitOffset(5, 10))
+	assert.Equal(t, "LIMIT -1 OFFSET 10", Dialect{}.LimitOffset(0, 10), "SQLite needs a LIMIT before OFFSET")
+}
+
+func TestIsRetryable(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "busy.db")
+	first, err := GetSQLiteConnection(path)
+	assert.NoError(t, err)
+	defer first.Close()
+	second, err := GetSQLiteConnection(path)
+	assert.NoError(t, err)
+	defer second.Close()
+
+	conn, err := first.Conn(ctx)
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+	assert.NoError(t, err)
+
+	_, err = second.ExecContext(ctx, "CREATE TABLE t (id INTEGER)")
+	assert.Error(t, err)
+	assert.True(t, IsRetryable(err), "a write blocked by another writer is SQLITE_BUSY: %v", err)
+	assert.False(t, IsRetryable(errors.New("database is locked")))
+}
+
+func TestTranslateError(t *testing.T) {
+	ctx := context.Background()
+	db, err := GetSQLiteConnection(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	for _, q := range []string{
+		"PRAGMA foreign_keys = ON",
+		`CREATE TABLE parent (id INTEGER PRIMARY KEY)`,
+		`CREATE TABLE child (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT UNIQUE, a INT, b INT,
+			age INT CONSTRAINT age_positive CHECK (age > 0), parent_id INT REFERENCES parent(id), UNIQUE(a, b))`,
+		`INSERT INTO child (id, name, email, a, b) VALUES (1, 'x', 'e', 1, 1)`,
+	} {
+		_, err = db.ExecContext(ctx, q)
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		want       error
+		table      string
+		column     string
+		constraint string
+	}{
+		{"unique", `INSERT INTO child (id, name, email) VALUES (2, 'x', 'e')`, dberr.ErrDuplicateEntry, "child", "email", ""},
+		{"primary key", `INSERT INTO child (id, name) VALUES (1, 'x')`, dberr.ErrDuplicateEntry, "child", "id", ""},
+		{"composite unique", `INSERT INTO child (id, name, a, b) VALUES (3, 'x', 1, 1)`, dberr.ErrDuplicateEntry, "child", "a, b", ""},
+		{"not null", `INSERT INTO child (id) VALUES (4)`, dberr.ErrNotNullViolation, "child", "name", ""},
+		{"check", `INSERT INTO child (id, name, age) VALUES (5, 'x', -1)`, dberr.ErrCheckViolation, "", "", "age_positive"},
+		{"foreign key", `INSERT INTO child (id, name, parent_id) VALUES (6, 'x', 9)`, dberr.ErrForeignKeyViolation, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cause := db.ExecContext(ctx, tt.query)
+			err := TranslateError(cause)
+
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorIs(t, err, cause)
+			var dbErr *dberr.DBError
+			if assert.ErrorAs(t, err, &dbErr) {
+				assert.Equal(t, tt.table, dbErr.Table)
+				assert.Equal(t, tt.column, dbErr.Column)
+				assert.Equal(t, tt.constraint, dbErr.Constraint)
+			}
+		})
+	}
+
+	_, cause := db.ExecContext(ctx, "SELECT * FROM missing")
+	assert.Same(t, cause, TranslateError(cause))
 }
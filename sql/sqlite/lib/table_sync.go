@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-	"time"
 
 	isql "github.com/masudur-rahman/styx/sql"
 
@@ -17,6 +16,23 @@ type fieldInfo struct {
 	Name        string
 	Type        string
 	IsComposite bool
+	// SQLType is Type without the column constraints.
+	SQLType string
+	PK      bool
+	NotNull bool
+	Unique  bool
+	Default string
+	// References, OnDelete and OnUpdate declare a foreign key, see
+	// isql.ColumnOptions.
+	References, OnDelete, OnUpdate string
+}
+
+// columnInfo describes a column of a live table.
+type columnInfo struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  string
 }
 
 func GenerateTableName(table interface{}) string {
@@ -59,41 +75,118 @@ func createTable(ctx context.Context, conn *sql.DB, tableName string, fields []f
 	return err
 }
 
-func addMissingColumns(ctx context.Context, conn *sql.DB, tableName string, fields []fieldInfo) error {
-	columns, err := getExistingColumns(ctx, conn, tableName)
+// alterTable makes an existing table match fields. Missing columns are
+// added in place when ALTER TABLE can add them; any other difference Plan
+// reports, apart from dropped columns and indexes, is made by rebuilding the
+// table. Columns the struct no longer declares are kept either way.
+func alterTable(ctx context.Context, conn *sql.DB, table any, tableName string, fields []fieldInfo) error {
+	changes, err := PlanTable(ctx, conn, table)
 	if err != nil {
 		return err
 	}
 
-	missingColumns := getMissingColumns(fields, columns)
-	if len(missingColumns) > 0 {
-		alterQuery := generateAddColumnQuery(tableName, missingColumns)
-		_, err = ExecuteWriteQuery(ctx, alterQuery, conn)
-		if err != nil {
+	var addColumns []isql.SchemaChange
+	rebuild := false
+	for _, c := range changes {
+		switch c.Kind {
+		case isql.AddColumn:
+			addColumns = append(addColumns, c)
+			rebuild = rebuild || !addableColumn(fields, c.Name)
+		case isql.AlterColumnType, isql.AlterDefault, isql.AlterNullable, isql.AddConstraint, isql.DropConstraint:
+			rebuild = true
+		}
+	}
+	if rebuild {
+		return rebuildTable(ctx, conn, tableName, fields)
+	}
+
+	for _, c := range addColumns {
+		if _, err = ExecuteWriteQuery(ctx, c.SQL, conn); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// addableColumn reports whether ALTER TABLE ADD COLUMN can add the field
+// named name. SQLite refuses PRIMARY KEY and UNIQUE columns, and NOT NULL
+// columns without a default.
+func addableColumn(fields []fieldInfo, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return !f.PK && !f.Unique && (!f.NotNull || f.Default != "")
+		}
+	}
+	return true
+}
+
 func getFieldInfo(fieldType reflect.StructField, fieldValue reflect.Value) fieldInfo {
 	fieldName := getFieldName(fieldType)
 	columnConstraint, autoincr, isComposite := getFieldConstraint(fieldType)
-	if columnConstraint != "" {
-		columnConstraint = " " + columnConstraint
-	}
-	sqlType := getSQLType(fieldValue.Type(), autoincr)
+	opts := isql.GetColumnOptions(fieldType)
+	sqlType := Dialect{}.SQLType(fieldValue.Type(), autoincr)
 	if isql.IsJSONField(fieldType) {
 		// SQLite stores JSON as TEXT
 		sqlType = "TEXT"
 	}
+	sqlType = declaredType(sqlType, fieldValue.Type(), opts)
+
+	definition := sqlType
+	if opts.NotNull {
+		definition += " NOT NULL"
+	}
+	if opts.Default != "" {
+		definition += " DEFAULT " + opts.Default
+	}
+	if columnConstraint != "" {
+		definition += " " + columnConstraint
+	}
 	return fieldInfo{
 		Name:        fieldName,
-		Type:        removeDuplicateKeyword(sqlType + columnConstraint),
+		Type:        removeDuplicateKeyword(definition),
 		IsComposite: isComposite,
+		SQLType:     sqlType,
+		PK:          strings.Contains(columnConstraint, "PRIMARY KEY"),
+		NotNull:     opts.NotNull || strings.Contains(columnConstraint, "PRIMARY KEY"),
+		Unique:      strings.Contains(columnConstraint, "UNIQUE"),
+		Default:     opts.Default,
+		References:  opts.References,
+		OnDelete:    opts.OnDelete,
+		OnUpdate:    opts.OnUpdate,
 	}
 }
 
+// declaredType applies the type, size and precision options of a field to
+// sqlType, the type its Go type maps to.
+func declaredType(sqlType string, t reflect.Type, opts isql.ColumnOptions) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case opts.Type != "":
+		sqlType = opts.Type
+	case opts.Precision > 0:
+		sqlType = "NUMERIC"
+	case opts.Size > 0 && t.Kind() == reflect.String:
+		sqlType = "VARCHAR"
+	default:
+		return sqlType
+	}
+
+	if strings.Contains(sqlType, "(") {
+		return sqlType
+	}
+	switch {
+	case opts.Precision > 0 && opts.Scale > 0:
+		return fmt.Sprintf("%s(%d,%d)", sqlType, opts.Precision, opts.Scale)
+	case opts.Precision > 0:
+		return fmt.Sprintf("%s(%d)", sqlType, opts.Precision)
+	case opts.Size > 0:
+		return fmt.Sprintf("%s(%d)", sqlType, opts.Size)
+	}
+	return sqlType
+}
+
 func removeDuplicateKeyword(keyword string) string {
 	pk := "PRIMARY KEY"
 	count := strings.Count(keyword, pk)
@@ -111,7 +204,7 @@ func getFieldName(fieldType reflect.StructField) string {
 func getFieldConstraint(fieldType reflect.StructField) (fc string, autoincr bool, isComposite bool) {
 	constraints := []string{}
 	if dbTag := fieldType.Tag.Get("db"); dbTag != "" {
-		tagParts := strings.Split(dbTag, ",")
+		tagParts := strings.SplitN(dbTag, ",", 2)
 		if len(tagParts) > 1 {
 			for _, part := range strings.Fields(tagParts[1]) {
 				switch strings.ToUpper(part) {
@@ -238,8 +331,8 @@ func getUniqueColumnGroups(t reflect.Type) [][]string {
 	return result
 }
 
-func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]string, error) {
-	var columns []string
+func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]columnInfo, error) {
+	var columns []columnInfo
 
 	rows, err := conn.QueryContext(ctx, fmt.Sprintf("pragma table_info('%v')", tableName))
 	if err != nil {
@@ -249,12 +342,16 @@ func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]
 
 	for rows.Next() {
 		var x any
-		var column string
-		err = rows.Scan(&x, &column, &x, &x, &x, &x)
+		var col columnInfo
+		var notNull bool
+		var def sql.NullString
+		err = rows.Scan(&x, &col.Name, &col.Type, &notNull, &def, &x)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning column for table %s: %v", tableName, err)
 		}
-		columns = append(columns, column)
+		col.Nullable = !notNull
+		col.Default = def.String
+		columns = append(columns, col)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -264,53 +361,71 @@ func getExistingColumns(ctx context.Context, conn *sql.DB, tableName string) ([]
 	return columns, nil
 }
 
-func getMissingColumns(fields []fieldInfo, columns []string) []string {
-	var missingColumns []string
-
-	for _, f := range fields {
-		if !contains(columns, f.Name) {
-			missingColumns = append(missingColumns, fmt.Sprintf("%s %s", f.Name, f.Type))
+func findColumn(columns []columnInfo, name string) *columnInfo {
+	for i := range columns {
+		if columns[i].Name == name {
+			return &columns[i]
 		}
 	}
-
-	return missingColumns
+	return nil
 }
 
-func getUniqueConstraints(ctx context.Context, conn *sql.DB, tableName string) ([][]string, error) {
-	query := `
-	SELECT kcu.column_name
-	FROM information_schema.table_constraints tc
-	JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name
-	WHERE tc.table_name = $1 AND tc.constraint_type = 'UNIQUE'
-	ORDER BY kcu.ordinal_position;
-	`
+// getUniqueConstraints returns the UNIQUE constraints of a table, which
+// SQLite keeps as automatic indexes.
+func getUniqueConstraints(ctx context.Context, conn *sql.DB, tableName string) ([]indexInfo, error) {
+	return getIndexes(ctx, conn, tableName, "u")
+}
 
-	rows, err := conn.QueryContext(ctx, query, tableName)
+// getIndexes returns the indexes of a table with the given origin: "c" for
+// CREATE INDEX, "u" for UNIQUE constraints, "pk" for the primary key.
+func getIndexes(ctx context.Context, conn *sql.DB, tableName, origin string) ([]indexInfo, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("pragma index_list('%v')", tableName))
 	if err != nil {
-		return nil, fmt.Errorf("error getting unique constraints for table %s: %v", tableName, err)
+		return nil, fmt.Errorf("error getting indexes for table %s: %v", tableName, err)
 	}
-	defer rows.Close()
-
-	var columns []string
+	var indexes []indexInfo
 	for rows.Next() {
-		var column string
-		err = rows.Scan(&column)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning unique constraint for table %s: %v", tableName, err)
+		var x any
+		var idx indexInfo
+		var idxOrigin string
+		if err = rows.Scan(&x, &idx.Name, &idx.Unique, &idxOrigin, &x); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning index for table %s: %v", tableName, err)
+		}
+		if idxOrigin == origin {
+			indexes = append(indexes, idx)
 		}
-		columns = append(columns, column)
 	}
-
+	rows.Close()
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error getting unique constraints for table %s: %v", tableName, err)
+		return nil, fmt.Errorf("error getting indexes for table %s: %v", tableName, err)
 	}
 
-	var result [][]string
-	if len(columns) > 0 {
-		result = append(result, columns)
+	for i := range indexes {
+		if indexes[i].Cols, err = getIndexColumns(ctx, conn, indexes[i].Name); err != nil {
+			return nil, err
+		}
 	}
+	return indexes, nil
+}
+
+func getIndexColumns(ctx context.Context, conn *sql.DB, indexName string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("pragma index_info('%v')", indexName))
+	if err != nil {
+		return nil, fmt.Errorf("error getting columns of index %s: %v", indexName, err)
+	}
+	defer rows.Close()
 
-	return result, nil
+	var cols []string
+	for rows.Next() {
+		var x any
+		var col string
+		if err = rows.Scan(&x, &x, &col); err != nil {
+			return nil, fmt.Errorf("error scanning column of index %s: %v", indexName, err)
+		}
+		cols = append(cols, col)
+	}
+	return cols, rows.Err()
 }
 
 func generateDropConstraintStatement(tableName string, uqConstraints [][]string) string {
@@ -349,16 +464,13 @@ func generateAddConstraintStatement(tableName string,
 	return sql
 }
 
-func contains(slice []string, val string) bool {
-	for _, v := range slice {
-		if v == val {
-			return true
-		}
-	}
-	return false
+func createTableQuery(tableName string, fields []fieldInfo) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS \"%s\" (%s);", tableName, tableDefinition(tableName, fields))
 }
 
-func createTableQuery(tableName string, fields []fieldInfo) string {
+// tableDefinition returns the column and table constraint definitions of a
+// CREATE TABLE statement for fields.
+func tableDefinition(tableName string, fields []fieldInfo) string {
 	var columnDefs []string
 	var compositeKeyGroup []string
 	for _, field := range fields {
@@ -373,43 +485,111 @@ func createTableQuery(tableName string, fields []fieldInfo) string {
 		compositeKeySQL := fmt.Sprintf("UNIQUE(%s)", strings.Join(compositeKeyGroup, ", "))
 		columnSQL += ", " + compositeKeySQL
 	}
+	for _, fk := range foreignKeys(tableName, fields) {
+		columnSQL += fmt.Sprintf(", CONSTRAINT \"%s\" %s", fk.Name, foreignKeyClause(fk))
+	}
 
-	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS \"%s\" (%s);", tableName, columnSQL)
+	return columnSQL
 }
 
-func getSQLType(fieldType reflect.Type, autoincr bool) string {
-	for fieldType.Kind() == reflect.Ptr {
-		fieldType = fieldType.Elem()
-	}
-	if autoincr {
-		switch fieldType.Kind() {
-		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint64:
-			return "INTEGER PRIMARY KEY AUTOINCREMENT"
+// foreignKeyInfo is a FOREIGN KEY constraint on a single column.
+type foreignKeyInfo struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnDelete  string
+	OnUpdate  string
+}
+
+// foreignKeys returns the foreign keys fields declare, named
+// fk_<table>_<column>. A reference without a column points to id.
+func foreignKeys(tableName string, fields []fieldInfo) []foreignKeyInfo {
+	var fks []foreignKeyInfo
+	for _, f := range fields {
+		if f.References == "" {
+			continue
 		}
+		refTable, refColumn, ok := strings.Cut(f.References, ".")
+		if !ok {
+			refColumn = "id"
+		}
+		fks = append(fks, foreignKeyInfo{
+			Name:      fmt.Sprintf("fk_%s_%s", tableName, f.Name),
+			Column:    f.Name,
+			RefTable:  refTable,
+			RefColumn: refColumn,
+			OnDelete:  orNoAction(f.OnDelete),
+			OnUpdate:  orNoAction(f.OnUpdate),
+		})
+	}
+	return fks
+}
+
+func orNoAction(action string) string {
+	if action == "" {
+		return "NO ACTION"
+	}
+	return action
+}
+
+// foreignKeyClause renders fk as a table constraint, without its name.
+func foreignKeyClause(fk foreignKeyInfo) string {
+	clause := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES \"%s\" (%s)", fk.Column, fk.RefTable, fk.RefColumn)
+	if fk.OnDelete != "NO ACTION" {
+		clause += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "NO ACTION" {
+		clause += " ON UPDATE " + fk.OnUpdate
 	}
+	return clause
+}
 
-	switch fieldType.Kind() {
-	case reflect.Int, reflect.Int32:
-		return "INTEGER"
-	case reflect.Int64, reflect.Uint64:
-		return "INTEGER"
-	case reflect.Float32, reflect.Float64:
-		return "REAL"
-	case reflect.Bool:
-		return "BOOLEAN"
-	case reflect.String:
-		return "TEXT"
-	case reflect.Slice:
-		if fieldType.Elem().Kind() == reflect.Uint8 {
-			return "BLOB"
+// getForeignKeys returns the single-column foreign keys of a table. SQLite
+// does not report constraint names, so they are named as foreignKeys does.
+func getForeignKeys(ctx context.Context, conn *sql.DB, tableName string) ([]foreignKeyInfo, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("pragma foreign_key_list('%v')", tableName))
+	if err != nil {
+		return nil, fmt.Errorf("error getting foreign keys for table %s: %v", tableName, err)
+	}
+	defer rows.Close()
+
+	byID := map[int]*foreignKeyInfo{}
+	var ids []int
+	for rows.Next() {
+		var id, seq int
+		var fk foreignKeyInfo
+		var refColumn sql.NullString
+		var x any
+		err = rows.Scan(&id, &seq, &fk.RefTable, &fk.Column, &refColumn, &fk.OnUpdate, &fk.OnDelete, &x)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning foreign key for table %s: %v", tableName, err)
+		}
+		if _, ok := byID[id]; ok {
+			// Foreign keys on several columns are not declared by tags.
+			byID[id] = nil
+			continue
 		}
-	case reflect.Struct:
-		if fieldType == reflect.TypeOf(time.Time{}) {
-			return "DATETIME"
+		// A reference without a column points to the parent's primary key.
+		fk.RefColumn = refColumn.String
+		if !refColumn.Valid {
+			fk.RefColumn = "id"
 		}
+		fk.Name = fmt.Sprintf("fk_%s_%s", tableName, fk.Column)
+		byID[id] = &fk
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting foreign keys for table %s: %v", tableName, err)
 	}
 
-	return ""
+	var fks []foreignKeyInfo
+	for _, id := range ids {
+		if fk := byID[id]; fk != nil {
+			fks = append(fks, *fk)
+		}
+	}
+	return fks, nil
 }
 
 func tableExists(ctx context.Context, conn *sql.DB, tableName string) (bool, error) {
@@ -456,6 +636,7 @@ func extractIndexes(table any) []indexInfo {
 	}
 
 	named := map[string]*indexInfo{}
+	var names []string
 	var unnamed []indexInfo
 
 	for i := 0; i < tableType.NumField(); i++ {
@@ -475,54 +656,56 @@ func extractIndexes(table any) []indexInfo {
 
 		for _, part := range strings.Fields(parts[1]) {
 			lp := strings.ToLower(part)
-			if lp == "idx" {
+			switch {
+			case lp == "idx":
 				unnamed = append(unnamed, indexInfo{Cols: []string{colName}})
-			} else if lp == "unique_idx" {
+			case lp == "unique_idx":
 				unnamed = append(unnamed, indexInfo{Cols: []string{colName}, Unique: true})
-			} else if strings.HasPrefix(lp, "idx:") {
-				idxName := strings.TrimPrefix(lp, "idx:")
+			case strings.HasPrefix(lp, "idx:"), strings.HasPrefix(lp, "unique_idx:"):
+				unique, idxName, _ := strings.Cut(lp, ":")
 				if existing, ok := named[idxName]; ok {
 					existing.Cols = append(existing.Cols, colName)
 				} else {
-					named[idxName] = &indexInfo{Name: idxName, Cols: []string{colName}}
-				}
-			} else if strings.HasPrefix(lp, "unique_idx:") {
-				idxName := strings.TrimPrefix(lp, "unique_idx:")
-				if existing, ok := named[idxName]; ok {
-					existing.Cols = append(existing.Cols, colName)
-				} else {
-					named[idxName] = &indexInfo{Name: idxName, Cols: []string{colName}, Unique: true}
+					named[idxName] = &indexInfo{Name: idxName, Cols: []string{colName}, Unique: unique == "unique_idx"}
+					names = append(names, idxName)
 				}
 			}
 		}
 	}
 
+	// Named indexes keep their tag order so that the generated names of the
+	// unnamed ones that follow are stable.
 	var result []indexInfo
-	for _, idx := range named {
-		result = append(result, *idx)
+	for _, name := range names {
+		result = append(result, *named[name])
 	}
-	return append(result, unnamed...)
+	result = append(result, unnamed...)
+	for i := range result {
+		if result[i].Name == "" {
+			result[i].Name = fmt.Sprintf("idx_%s_%d", GenerateTableName(table), i)
+		}
+	}
+	return result
 }
 
 func createIndexes(ctx context.Context, conn *sql.DB, tableName string, indexes []indexInfo) error {
-	for i, idx := range indexes {
-		unique := ""
-		if idx.Unique {
-			unique = "UNIQUE "
-		}
-		name := idx.Name
-		if name == "" {
-			name = fmt.Sprintf("idx_%s_%d", tableName, i)
-		}
-		query := fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS \"%s\" ON \"%s\" (%s)",
-			unique, name, tableName, strings.Join(idx.Cols, ", "))
-		if _, err := ExecuteWriteQuery(ctx, query, conn); err != nil {
-			return fmt.Errorf("error creating index %s: %w", name, err)
+	for _, idx := range indexes {
+		if _, err := ExecuteWriteQuery(ctx, createIndexQuery(tableName, idx), conn); err != nil {
+			return fmt.Errorf("error creating index %s: %w", idx.Name, err)
 		}
 	}
 	return nil
 }
 
+func createIndexQuery(tableName string, idx indexInfo) string {
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS \"%s\" ON \"%s\" (%s)",
+		unique, idx.Name, tableName, strings.Join(idx.Cols, ", "))
+}
+
 // DropTable drops a table by name.
 func DropTable(ctx context.Context, conn *sql.DB, tableName string) error {
 	query := fmt.Sprintf("DROP TABLE IF EXISTS \"%s\"", tableName)
@@ -544,7 +727,7 @@ func SyncTable(ctx context.Context, conn *sql.DB, table interface{}) error {
 			return err
 		}
 	} else {
-		if err = addMissingColumns(ctx, conn, tableName, fields); err != nil {
+		if err = alterTable(ctx, conn, table, tableName, fields); err != nil {
 			return err
 		}
 	}
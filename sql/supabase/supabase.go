@@ -3,17 +3,27 @@ package supabase
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/pagination"
 	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/builder"
+	"github.com/masudur-rahman/styx/sql/cond"
 
 	"github.com/nedpals/supabase-go"
 )
 
 type Supabase struct {
-	table  string
-	id     any
-	client *supabase.Client
+	table   string
+	id      any
+	client  *supabase.Client
+	orderBy []builder.OrderTerm
+	limit   int64
+	after   string
+	before  string
 }
 
 func NewSupabase(client *supabase.Client) Supabase {
@@ -26,6 +36,16 @@ func (s Supabase) BeginTx(ctx context.Context) (isql.Engine, error) {
 	return nil, dberr.ErrTransactionNotStarted
 }
 
+func (s Supabase) BeginTxWithOptions(ctx context.Context, opts *sql.TxOptions) (isql.Engine, error) {
+	return nil, dberr.ErrTransactionNotStarted
+}
+
+// RunInTx fails with dberr.ErrNotSupported: PostgREST runs every request in
+// its own transaction.
+func (s Supabase) RunInTx(ctx context.Context, opts isql.TxOptions, fn func(isql.Engine) error) error {
+	return fmt.Errorf("%w: supabase has no client-side transactions", dberr.ErrNotSupported)
+}
+
 func (s Supabase) Commit() error {
 	return dberr.ErrTransactionNotStarted
 }
@@ -54,6 +74,10 @@ func (s Supabase) Where(cond string, args ...any) isql.Engine {
 	panic("implement me")
 }
 
+func (s Supabase) WhereCond(c cond.Cond) isql.Engine {
+	panic("implement me")
+}
+
 func (s Supabase) Columns(cols ...string) isql.Engine {
 	//TODO implement me
 	panic("implement me")
@@ -79,11 +103,14 @@ func (s Supabase) ShowSQL(showSQL bool) isql.Engine {
 }
 
 func (s Supabase) OrderBy(col string, direction ...string) isql.Engine {
-	panic("implement me")
+	desc := len(direction) > 0 && strings.ToUpper(direction[0]) == "DESC"
+	s.orderBy = append(s.orderBy[:len(s.orderBy):len(s.orderBy)], builder.OrderTerm{Col: col, Desc: desc})
+	return s
 }
 
 func (s Supabase) Limit(n int64) isql.Engine {
-	panic("implement me")
+	s.limit = n
+	return s
 }
 
 func (s Supabase) Offset(n int64) isql.Engine {
@@ -114,11 +141,27 @@ func (s Supabase) NotLike(col string, pattern string) isql.Engine {
 	panic("implement me")
 }
 
-func (s Supabase) Exists(subquery string, args ...any) isql.Engine {
+func (s Supabase) Exists(subquery any, args ...any) isql.Engine {
 	panic("implement me")
 }
 
-func (s Supabase) NotExists(subquery string, args ...any) isql.Engine {
+func (s Supabase) NotExists(subquery any, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (s Supabase) From(subquery any, alias string, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (s Supabase) With(name string, subquery any, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (s Supabase) WithRecursive(name string, subquery any, args ...any) isql.Engine {
+	panic("implement me")
+}
+
+func (s Supabase) Subquery(proto ...any) isql.Subquery {
 	panic("implement me")
 }
 
@@ -146,6 +189,16 @@ func (s Supabase) Paginate(page, perPage int64) isql.Engine {
 	panic("implement me")
 }
 
+func (s Supabase) After(cursor string) isql.Engine {
+	s.after, s.before = cursor, ""
+	return s
+}
+
+func (s Supabase) Before(cursor string) isql.Engine {
+	s.before, s.after = cursor, ""
+	return s
+}
+
 func (s Supabase) Join(table, condition string) isql.Engine {
 	panic("implement me")
 }
@@ -170,6 +223,10 @@ func (s Supabase) WithDeleted() isql.Engine {
 	panic("implement me")
 }
 
+func (s Supabase) SoftDeleteCol(col string) isql.Engine {
+	panic("implement me")
+}
+
 func (s Supabase) ForceDelete(ctx context.Context, filter ...any) error {
 	panic("implement me")
 }
@@ -215,6 +272,77 @@ func (s Supabase) FindMany(ctx context.Context, documents any, filter ...any) er
 	return nil
 }
 
+func (s Supabase) FindPage(ctx context.Context, documents any, page, perPage int64, filter ...any) (pagination.Paginator, error) {
+	panic("implement me")
+}
+
+// FindCursor pages through the table in keyset order through PostgREST
+// filters: the cursor becomes col=gt.value for a single ORDER BY column
+// and an or=(...) tree for several.
+func (s Supabase) FindCursor(ctx context.Context, documents any, filter ...any) (pagination.CursorPage, error) {
+	terms := builder.WithTieBreaker(s.orderBy, isql.GetPKColumn(documents))
+	cols := make([]string, len(terms))
+	for i, t := range terms {
+		cols[i] = t.Col
+	}
+	limit := s.limit
+	if limit <= 0 {
+		limit = builder.DefaultCursorLimit
+	}
+
+	cl := s.client.DB.From(s.table).Select("*").Limit(int(limit + 1))
+	if len(filter) > 0 {
+		for _, kv := range generateFilters(filter[0]) {
+			cl.Eq(kv.key, kv.value)
+		}
+	}
+
+	backward := s.before != ""
+	if cursor := s.after + s.before; cursor != "" {
+		values, err := builder.CursorValues(cursor, cols)
+		if err != nil {
+			return pagination.CursorPage{}, err
+		}
+		keysetFilter(&cl.FilterRequestBuilder, terms, values, !backward)
+	}
+
+	order := make([]string, len(terms))
+	for i, t := range terms {
+		dir := "asc"
+		if t.Desc != backward {
+			dir = "desc"
+		}
+		order[i] = t.Col + "." + dir
+	}
+	setParam(&cl.FilterRequestBuilder, "order", terms[0].Col, strings.Join(order, ","))
+
+	reflect.ValueOf(documents).Elem().SetLen(0)
+	if err := cl.ExecuteWithContext(ctx, documents); err != nil {
+		return pagination.CursorPage{}, err
+	}
+	return builder.FinishCursorPage(documents, cols, limit, s.after, s.before)
+}
+
+func (s Supabase) Rows(ctx context.Context, proto any, filter ...any) (isql.RowScanner, error) {
+	panic("implement me")
+}
+
+func (s Supabase) Iterate(ctx context.Context, proto any, fn func(row any) error, filter ...any) error {
+	panic("implement me")
+}
+
+func (s Supabase) Scalar(ctx context.Context, dest any, filter ...any) error {
+	panic("implement me")
+}
+
+func (s Supabase) FindMaps(ctx context.Context, filter ...any) ([]map[string]any, error) {
+	panic("implement me")
+}
+
+func (s Supabase) CountRows(ctx context.Context, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
 func (s Supabase) InsertOne(ctx context.Context, document any) (id any, err error) {
 	docs := []Doc{}
 	err = s.client.DB.From(s.table).Insert(document).Execute(&docs)
@@ -237,6 +365,30 @@ func (s Supabase) InsertMany(ctx context.Context, documents []any) ([]any, error
 	return ids, nil
 }
 
+// Upsert posts document with PostgREST's "resolution=merge-duplicates", which
+// resolves conflicts on the primary key and updates every provided column.
+// The client cannot send on_conflict, so other conflict columns and explicit
+// update columns are rejected.
+func (s Supabase) Upsert(ctx context.Context, document any, conflictCols []string, updateCols ...string) (id any, err error) {
+	pkCol := isql.GetPKColumn(document)
+	if len(conflictCols) > 1 || (len(conflictCols) == 1 && conflictCols[0] != pkCol) {
+		return nil, fmt.Errorf("%w: supabase upsert only resolves conflicts on %q", dberr.ErrInvalidQuery, pkCol)
+	}
+	if len(updateCols) > 0 {
+		return nil, fmt.Errorf("%w: supabase upsert does not support update columns", dberr.ErrInvalidQuery)
+	}
+
+	docs := []Doc{}
+	err = s.client.DB.From(s.table).Upsert(document).Execute(&docs)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, dberr.ErrNotFound
+	}
+	return docs[0].ID, nil
+}
+
 func (s Supabase) UpdateOne(ctx context.Context, document any) error {
 	if err := dberr.CheckIDNonEmpty(s.id); err != nil {
 		return err
@@ -266,10 +418,42 @@ func (s Supabase) DeleteOne(ctx context.Context, filter ...any) error {
 	return cl.Execute(&rs)
 }
 
+func (s Supabase) UpdateMany(ctx context.Context, document any) (int64, error) {
+	panic("implement me")
+}
+
+func (s Supabase) DeleteMany(ctx context.Context, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
+func (s Supabase) UpdateOneReturning(ctx context.Context, document any, out any) (int64, error) {
+	panic("implement me")
+}
+
+func (s Supabase) DeleteOneReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
+func (s Supabase) ForceDeleteReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
+func (s Supabase) RestoreReturning(ctx context.Context, out any, filter ...any) (int64, error) {
+	panic("implement me")
+}
+
 func (s Supabase) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
 	panic("implement me")
 }
 
+func (s Supabase) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	panic("implement me")
+}
+
+func (s Supabase) QueryInto(ctx context.Context, dest any, query string, args ...any) error {
+	panic("implement me")
+}
+
 func (s Supabase) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	panic("implement me")
 }
@@ -279,8 +463,17 @@ func (s Supabase) Sync(ctx context.Context, tables ...any) error {
 	panic("implement me")
 }
 
+func (s Supabase) Plan(ctx context.Context, tables ...any) ([]isql.SchemaChange, error) {
+	panic("implement me")
+}
+
 func (s Supabase) DropTable(ctx context.Context, name string) error {
 	panic("implement me")
 }
 
+// Dialect reports "supabase": the engine speaks PostgREST, not SQL.
+func (s Supabase) Dialect() string {
+	return "supabase"
+}
+
 func (s Supabase) Close() error { return nil }
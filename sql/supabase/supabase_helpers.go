@@ -8,7 +8,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/masudur-rahman/styx/sql/builder"
+
 	"github.com/iancoleman/strcase"
+	postgrest "github.com/nedpals/postgrest-go/pkg"
 	supabase "github.com/nedpals/supabase-go"
 )
 
@@ -98,3 +101,61 @@ func handleSliceAny(v []any) string {
 	}
 	return value
 }
+
+// keysetFilter restricts cl to the rows after values in the order of terms,
+// or before them when forward is false.
+func keysetFilter(cl *postgrest.FilterRequestBuilder, terms []builder.OrderTerm, values []any, forward bool) {
+	ops := make([]string, len(terms))
+	for i, t := range terms {
+		ops[i] = "gt"
+		if t.Desc == forward {
+			ops[i] = "lt"
+		}
+	}
+	if len(terms) == 1 {
+		cl.Filter(terms[0].Col, ops[0], postgrestValue(values[0], false))
+		return
+	}
+
+	ors := make([]string, len(terms))
+	for i := range terms {
+		conds := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, terms[j].Col+".eq."+postgrestValue(values[j], true))
+		}
+		conds = append(conds, terms[i].Col+"."+ops[i]+"."+postgrestValue(values[i], true))
+		if len(conds) == 1 {
+			ors[i] = conds[0]
+		} else {
+			ors[i] = "and(" + strings.Join(conds, ",") + ")"
+		}
+	}
+	setParam(cl, "or", "("+terms[0].Col, "("+strings.Join(ors, ",")+")")
+}
+
+// setParam adds the query parameter key=value, where value starts with
+// head and a dot, such as the first column of an order or or parameter.
+// postgrest-go has no raw parameter setter, but Filter renders
+// operator.criteria, so head is passed as the operator and the rest of
+// value as the criteria.
+func setParam(cl *postgrest.FilterRequestBuilder, key, head, value string) {
+	cl.Filter(key, head, strings.TrimPrefix(value, head+"."))
+}
+
+// postgrestValue formats a cursor value for a PostgREST filter. Inside
+// logic trees such as or=(...), values with reserved characters are quoted.
+func postgrestValue(v any, quote bool) string {
+	var s string
+	switch t := v.(type) {
+	case time.Time:
+		s = t.Format(time.RFC3339Nano)
+	case []byte:
+		s = string(t)
+	default:
+		s = fmt.Sprint(v)
+	}
+	if quote && strings.ContainsAny(s, `,.:()" \`) {
+		s = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TxOptions configures Engine.RunInTx.
+type TxOptions struct {
+	// Tx sets the isolation level and read-only flag of the transaction.
+	// Nil uses the driver defaults.
+	Tx *sql.TxOptions
+	// MaxRetries is how many times fn is run again after a retryable failure
+	// (serialization failure, deadlock, busy database). Zero disables retries.
+	MaxRetries int
+	// Backoff returns how long to wait before retry n, starting at 1. Nil
+	// uses DefaultBackoff.
+	Backoff func(n int) time.Duration
+}
+
+// DefaultBackoff waits 10ms before the first retry and doubles the wait on
+// every further retry, up to one second.
+func DefaultBackoff(n int) time.Duration {
+	d := 10 * time.Millisecond
+	for i := 1; i < n && d < time.Second; i++ {
+		d *= 2
+	}
+	if d > time.Second {
+		d = time.Second
+	}
+	return d
+}
+
+// RunInTx runs fn in a transaction begun on db with opts.Tx. The transaction
+// is committed when fn returns nil and rolled back when it returns an error
+// or panics; a panic is re-raised after the rollback. When fn or the commit
+// fails with an error for which retryable reports true, the whole
+// transaction is run again, up to opts.MaxRetries times. Engines implement
+// RunInTx with this helper and their driver's retryable errors.
+func RunInTx(ctx context.Context, db Engine, opts TxOptions, retryable func(error) bool, fn func(Engine) error) error {
+	backoff := opts.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := runTxOnce(ctx, db, opts.Tx, fn)
+		if err == nil || attempt >= opts.MaxRetries || retryable == nil || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff(attempt + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func runTxOnce(ctx context.Context, db Engine, opts *sql.TxOptions, fn func(Engine) error) (err error) {
+	tx, err := db.BeginTxWithOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,125 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	isql "github.com/masudur-rahman/styx/sql"
+	"github.com/masudur-rahman/styx/sql/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var errBusy = errors.New("busy")
+
+func isBusy(err error) bool {
+	return errors.Is(err, errBusy)
+}
+
+func noBackoff(int) time.Duration {
+	return 0
+}
+
+func TestRunInTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commits on success", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		gomock.InOrder(
+			db.EXPECT().BeginTxWithOptions(ctx, nil).Return(db, nil),
+			db.EXPECT().Commit().Return(nil),
+		)
+
+		err := isql.RunInTx(ctx, db, isql.TxOptions{}, isBusy, func(tx isql.Engine) error {
+			assert.Same(t, db, tx)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		boom := errors.New("boom")
+		db.EXPECT().BeginTxWithOptions(ctx, nil).Return(db, nil)
+		db.EXPECT().Rollback().Return(nil)
+
+		err := isql.RunInTx(ctx, db, isql.TxOptions{MaxRetries: 3}, isBusy, func(isql.Engine) error {
+			return boom
+		})
+		assert.ErrorIs(t, err, boom, "non-retryable errors are not retried")
+	})
+
+	t.Run("rolls back and re-panics", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		db.EXPECT().BeginTxWithOptions(ctx, nil).Return(db, nil)
+		db.EXPECT().Rollback().Return(nil)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			_ = isql.RunInTx(ctx, db, isql.TxOptions{}, isBusy, func(isql.Engine) error {
+				panic("boom")
+			})
+		})
+	})
+
+	t.Run("retries retryable failures with backoff", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		db.EXPECT().BeginTxWithOptions(ctx, nil).Return(db, nil).Times(3)
+		db.EXPECT().Rollback().Return(nil).Times(2)
+		db.EXPECT().Commit().Return(nil)
+
+		var waits []int
+		calls := 0
+		opts := isql.TxOptions{
+			MaxRetries: 2,
+			Backoff: func(n int) time.Duration {
+				waits = append(waits, n)
+				return 0
+			},
+		}
+		err := isql.RunInTx(ctx, db, opts, isBusy, func(isql.Engine) error {
+			calls++
+			if calls < 3 {
+				return errBusy
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []int{1, 2}, waits)
+	})
+
+	t.Run("retries a failed commit and gives up after MaxRetries", func(t *testing.T) {
+		db := mock.NewMockEngine(gomock.NewController(t))
+		db.EXPECT().BeginTxWithOptions(ctx, nil).Return(db, nil).Times(2)
+		db.EXPECT().Commit().Return(errBusy).Times(2)
+
+		err := isql.RunInTx(ctx, db, isql.TxOptions{MaxRetries: 1, Backoff: noBackoff}, isBusy, func(isql.Engine) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, errBusy)
+	})
+
+	t.Run("stops retrying when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		db := mock.NewMockEngine(gomock.NewController(t))
+		db.EXPECT().BeginTxWithOptions(ctx, nil).Return(db, nil)
+		db.EXPECT().Rollback().Return(nil)
+
+		err := isql.RunInTx(ctx, db, isql.TxOptions{MaxRetries: 5, Backoff: func(int) time.Duration { return time.Hour }}, isBusy, func(isql.Engine) error {
+			cancel()
+			return errBusy
+		})
+		assert.ErrorIs(t, err, errBusy)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Millisecond, isql.DefaultBackoff(1))
+	assert.Equal(t, 20*time.Millisecond, isql.DefaultBackoff(2))
+	assert.Equal(t, 80*time.Millisecond, isql.DefaultBackoff(4))
+	assert.Equal(t, time.Second, isql.DefaultBackoff(20))
+}
@@ -2,52 +2,170 @@ package styx
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/masudur-rahman/styx/nosql"
 	"github.com/masudur-rahman/styx/sql"
 )
 
+// Sides of a UnitOfWork reported by TxError.
+const (
+	SideSQL   = "sql"
+	SideNoSQL = "nosql"
+)
+
+// TxError reports which side of a UnitOfWork failed during Begin, Commit or
+// Rollback. Committed lists the sides that had already committed when the
+// failure happened; a non-empty Committed on a commit failure means the unit
+// of work was only partially applied.
+type TxError struct {
+	Op        string
+	Side      string
+	Committed []string
+	Err       error
+}
+
+func (e *TxError) Error() string {
+	msg := fmt.Sprintf("styx: %s %s transaction: %v", e.Op, e.Side, e.Err)
+	if len(e.Committed) > 0 {
+		msg += fmt.Sprintf(" (already committed: %s)", strings.Join(e.Committed, ", "))
+	}
+	return msg
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
+
+// IsPartialCommit reports whether err is a TxError raised after at least one
+// side had already committed.
+func IsPartialCommit(err error) bool {
+	var te *TxError
+	return errors.As(err, &te) && len(te.Committed) > 0
+}
+
 // UnitOfWork represents the unit of work for coordinating transactions
 type UnitOfWork struct {
 	SQL   sql.Engine
 	NoSQL nosql.Engine
+
+	// depth counts the Begin calls on already started units of work.
+	depth int
 }
 
-// Begin starts a new transaction
+// Begin starts a transaction on every configured engine. If the NoSQL side
+// fails to start, the already started SQL transaction is rolled back.
+//
+// Begin is re-entrant: called on a unit of work returned by Begin, it opens
+// a SQL savepoint and joins the running NoSQL transaction, which has no
+// savepoints. Commit and Rollback of such a nested unit then only release or
+// roll back the savepoint; NoSQL writes are settled by the outermost unit.
 func (uow UnitOfWork) Begin(ctx context.Context) (UnitOfWork, error) {
 	cp := UnitOfWork{
 		SQL:   uow.SQL,
 		NoSQL: uow.NoSQL,
 	}
+	if uow.inTx() {
+		cp.depth = uow.depth + 1
+		if uow.SQL != nil {
+			sqlTx, err := uow.SQL.BeginTx(ctx)
+			if err != nil {
+				return UnitOfWork{}, &TxError{Op: "begin", Side: SideSQL, Err: err}
+			}
+			cp.SQL = sqlTx
+		}
+		return cp, nil
+	}
+
+	cp.depth = 1
 	if uow.SQL != nil {
 		sqlTx, err := uow.SQL.BeginTx(ctx)
 		if err != nil {
-			return UnitOfWork{}, err
+			return UnitOfWork{}, &TxError{Op: "begin", Side: SideSQL, Err: err}
 		}
 		cp.SQL = sqlTx
 	}
-	// For NoSQL databases, no action needed for beginning a transaction
+	if uow.NoSQL != nil {
+		nosqlTx, err := uow.NoSQL.BeginTx(ctx)
+		if err != nil {
+			if cp.SQL != nil {
+				err = errors.Join(err, cp.SQL.Rollback())
+			}
+			return UnitOfWork{}, &TxError{Op: "begin", Side: SideNoSQL, Err: err}
+		}
+		cp.NoSQL = nosqlTx
+	}
 	return cp, nil
 }
 
-// Commit commits the transaction
+// Commit commits both sides in two phases: SQL first, then NoSQL. If the SQL
+// commit fails the NoSQL transaction is rolled back, so nothing is applied.
+// If the NoSQL commit fails after SQL has committed, the SQL changes stay and
+// the returned TxError lists SideSQL in Committed.
 func (uow UnitOfWork) Commit() error {
+	if uow.nested() {
+		if uow.SQL == nil {
+			return nil
+		}
+		if err := uow.SQL.Commit(); err != nil {
+			return &TxError{Op: "commit", Side: SideSQL, Err: err}
+		}
+		return nil
+	}
+
+	var committed []string
 	if uow.SQL != nil {
 		if err := uow.SQL.Commit(); err != nil {
-			return err
+			if uow.NoSQL != nil {
+				err = errors.Join(err, uow.NoSQL.Rollback())
+			}
+			return &TxError{Op: "commit", Side: SideSQL, Err: err}
+		}
+		committed = append(committed, SideSQL)
+	}
+	if uow.NoSQL != nil {
+		if err := uow.NoSQL.Commit(); err != nil {
+			return &TxError{Op: "commit", Side: SideNoSQL, Committed: committed, Err: err}
 		}
 	}
-	// For NoSQL databases, no action needed for committing a transaction
 	return nil
 }
 
-// Rollback rolls back the transaction
+// Rollback rolls back both sides. Both are attempted even if the first fails.
+// A nested unit of work only rolls back to its SQL savepoint.
 func (uow UnitOfWork) Rollback() error {
+	if uow.nested() {
+		if uow.SQL == nil {
+			return nil
+		}
+		if err := uow.SQL.Rollback(); err != nil {
+			return &TxError{Op: "rollback", Side: SideSQL, Err: err}
+		}
+		return nil
+	}
+
+	var errs []error
 	if uow.SQL != nil {
 		if err := uow.SQL.Rollback(); err != nil {
-			return err
+			errs = append(errs, &TxError{Op: "rollback", Side: SideSQL, Err: err})
 		}
 	}
-	// For NoSQL databases, no action needed for rolling back a transaction
-	return nil
+	if uow.NoSQL != nil {
+		if err := uow.NoSQL.Rollback(); err != nil {
+			errs = append(errs, &TxError{Op: "rollback", Side: SideNoSQL, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// inTx reports whether uow was returned by Begin.
+func (uow UnitOfWork) inTx() bool {
+	return uow.depth > 0
+}
+
+// nested reports whether uow was begun inside another unit of work.
+func (uow UnitOfWork) nested() bool {
+	return uow.depth > 1
 }
@@ -0,0 +1,167 @@
+package styx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/masudur-rahman/styx/dberr"
+	"github.com/masudur-rahman/styx/nosql/arangodb"
+	nosqlmock "github.com/masudur-rahman/styx/nosql/mock"
+	sqlmock "github.com/masudur-rahman/styx/sql/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockUnitOfWork(t *testing.T) (UnitOfWork, *sqlmock.MockEngine, *nosqlmock.MockEngine) {
+	ctrl := gomock.NewController(t)
+	sqlEngine := sqlmock.NewMockEngine(ctrl)
+	nosqlEngine := nosqlmock.NewMockEngine(ctrl)
+
+	sqlEngine.EXPECT().BeginTx(gomock.Any()).Return(sqlEngine, nil)
+	nosqlEngine.EXPECT().BeginTx(gomock.Any()).Return(nosqlEngine, nil)
+
+	tx, err := UnitOfWork{SQL: sqlEngine, NoSQL: nosqlEngine}.Begin(context.Background())
+	require.NoError(t, err)
+	return tx, sqlEngine, nosqlEngine
+}
+
+func TestUnitOfWork_Begin(t *testing.T) {
+	t.Run("nosql failure rolls back sql", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		sqlEngine := sqlmock.NewMockEngine(ctrl)
+		nosqlEngine := nosqlmock.NewMockEngine(ctrl)
+		boom := errors.New("boom")
+
+		sqlEngine.EXPECT().BeginTx(gomock.Any()).Return(sqlEngine, nil)
+		nosqlEngine.EXPECT().BeginTx(gomock.Any()).Return(nil, boom)
+		sqlEngine.EXPECT().Rollback().Return(nil)
+
+		_, err := UnitOfWork{SQL: sqlEngine, NoSQL: nosqlEngine}.Begin(context.Background())
+		var te *TxError
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, SideNoSQL, te.Side)
+		assert.ErrorIs(t, err, boom)
+	})
+
+	t.Run("arangodb without declared collections", func(t *testing.T) {
+		_, err := UnitOfWork{NoSQL: arangodb.NewArangoDB(nil)}.Begin(context.Background())
+		var te *TxError
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, SideNoSQL, te.Side)
+		assert.ErrorIs(t, err, dberr.ErrInvalidEntityName)
+	})
+
+	t.Run("sql only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		sqlEngine := sqlmock.NewMockEngine(ctrl)
+		sqlEngine.EXPECT().BeginTx(gomock.Any()).Return(sqlEngine, nil)
+		sqlEngine.EXPECT().Commit().Return(nil)
+
+		tx, err := UnitOfWork{SQL: sqlEngine}.Begin(context.Background())
+		require.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+	})
+}
+
+func TestUnitOfWork_Commit(t *testing.T) {
+	t.Run("both sides commit", func(t *testing.T) {
+		tx, sqlEngine, nosqlEngine := newMockUnitOfWork(t)
+		gomock.InOrder(
+			sqlEngine.EXPECT().Commit().Return(nil),
+			nosqlEngine.EXPECT().Commit().Return(nil),
+		)
+		assert.NoError(t, tx.Commit())
+	})
+
+	t.Run("sql failure rolls back nosql", func(t *testing.T) {
+		tx, sqlEngine, nosqlEngine := newMockUnitOfWork(t)
+		boom := errors.New("boom")
+		sqlEngine.EXPECT().Commit().Return(boom)
+		nosqlEngine.EXPECT().Rollback().Return(nil)
+
+		err := tx.Commit()
+		var te *TxError
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, SideSQL, te.Side)
+		assert.Empty(t, te.Committed)
+		assert.False(t, IsPartialCommit(err))
+		assert.ErrorIs(t, err, boom)
+	})
+
+	t.Run("nosql failure after sql commit", func(t *testing.T) {
+		tx, sqlEngine, nosqlEngine := newMockUnitOfWork(t)
+		boom := errors.New("boom")
+		sqlEngine.EXPECT().Commit().Return(nil)
+		nosqlEngine.EXPECT().Commit().Return(boom)
+
+		err := tx.Commit()
+		var te *TxError
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, SideNoSQL, te.Side)
+		assert.Equal(t, []string{SideSQL}, te.Committed)
+		assert.True(t, IsPartialCommit(err))
+	})
+}
+
+func TestUnitOfWork_Rollback(t *testing.T) {
+	tx, sqlEngine, nosqlEngine := newMockUnitOfWork(t)
+	sqlEngine.EXPECT().Rollback().Return(dberr.ErrTransactionNotStarted)
+	nosqlEngine.EXPECT().Rollback().Return(nil)
+
+	err := tx.Rollback()
+	assert.ErrorIs(t, err, dberr.ErrTransactionNotStarted)
+
+	var te *TxError
+	require.ErrorAs(t, err, &te)
+	assert.Equal(t, SideSQL, te.Side)
+}
+
+func TestUnitOfWork_Nested(t *testing.T) {
+	t.Run("nested begin opens a sql savepoint only", func(t *testing.T) {
+		tx, sqlEngine, nosqlEngine := newMockUnitOfWork(t)
+		savepoint := sqlmock.NewMockEngine(gomock.NewController(t))
+		sqlEngine.EXPECT().BeginTx(gomock.Any()).Return(savepoint, nil)
+
+		inner, err := tx.Begin(context.Background())
+		require.NoError(t, err)
+		assert.Same(t, savepoint, inner.SQL)
+		assert.Same(t, nosqlEngine, inner.NoSQL)
+
+		savepoint.EXPECT().Commit().Return(nil)
+		assert.NoError(t, inner.Commit())
+
+		gomock.InOrder(
+			sqlEngine.EXPECT().Commit().Return(nil),
+			nosqlEngine.EXPECT().Commit().Return(nil),
+		)
+		assert.NoError(t, tx.Commit())
+	})
+
+	t.Run("nested rollback leaves the outer unit running", func(t *testing.T) {
+		tx, sqlEngine, _ := newMockUnitOfWork(t)
+		savepoint := sqlmock.NewMockEngine(gomock.NewController(t))
+		sqlEngine.EXPECT().BeginTx(gomock.Any()).Return(savepoint, nil)
+
+		inner, err := tx.Begin(context.Background())
+		require.NoError(t, err)
+
+		savepoint.EXPECT().Rollback().Return(nil)
+		assert.NoError(t, inner.Rollback())
+	})
+
+	t.Run("nosql only nests without a transaction of its own", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		nosqlEngine := nosqlmock.NewMockEngine(ctrl)
+		nosqlEngine.EXPECT().BeginTx(gomock.Any()).Return(nosqlEngine, nil)
+
+		tx, err := UnitOfWork{NoSQL: nosqlEngine}.Begin(context.Background())
+		require.NoError(t, err)
+		inner, err := tx.Begin(context.Background())
+		require.NoError(t, err)
+		assert.NoError(t, inner.Commit())
+		assert.NoError(t, inner.Rollback())
+	})
+}